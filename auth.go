@@ -0,0 +1,165 @@
+package libvirt
+
+/*
+#include <stdlib.h>
+#include <libvirt/libvirt.h>
+
+virConnectPtr openAuthHelper(const char *uri, int *credtype, unsigned int ncredtype, long callbackID, unsigned int flags);
+*/
+import "C"
+import (
+	"io"
+	"reflect"
+	"runtime"
+	"unsafe"
+)
+
+// CredentialType defines the type of a credential requested by libvirt
+// during the authentication.
+type CredentialType uint32
+
+// Possible values for CredentialType.
+const (
+	CredUsername     CredentialType = C.VIR_CRED_USERNAME
+	CredAuthname     CredentialType = C.VIR_CRED_AUTHNAME
+	CredLanguage     CredentialType = C.VIR_CRED_LANGUAGE
+	CredCNonce       CredentialType = C.VIR_CRED_CNONCE
+	CredPassphrase   CredentialType = C.VIR_CRED_PASSPHRASE
+	CredEchoPrompt   CredentialType = C.VIR_CRED_ECHOPROMPT
+	CredNoEchoPrompt CredentialType = C.VIR_CRED_NOECHOPROMPT
+	CredRealm        CredentialType = C.VIR_CRED_REALM
+	CredExternal     CredentialType = C.VIR_CRED_EXTERNAL
+)
+
+// Credential is a single credential requested by libvirt. The callback which
+// receives it should store the answer in "Result".
+type Credential struct {
+	Type          CredentialType
+	Prompt        string
+	Challenge     string
+	DefaultResult string
+	Result        string
+}
+
+// CredentialCallback is called when libvirt needs credentials to
+// authenticate a connection. It should fill the field "Result" of every
+// credential in "creds". If an error is returned, the authentication fails.
+type CredentialCallback func(creds []Credential) error
+
+// Auth describes how the credentials are collected by OpenAuth.
+// "CredentialTypes" lists the credential types which "Callback" is able to
+// handle.
+type Auth struct {
+	CredentialTypes []CredentialType
+	Callback        CredentialCallback
+}
+
+// OpenAuth creates a new libvirt connection to the Hypervisor, like Open,
+// but it also authenticates the connection when the driver requires it (e.g.
+// SASL or polkit). The credentials are collected by "auth"; if it's nil, the
+// libvirt default authentication callback is used, which prompts for the
+// credentials on the console.
+func OpenAuth(uri string, mode ConnectionMode, auth *Auth, logOutput io.Writer) (Connection, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	cUri := C.CString(uri)
+	defer C.free(unsafe.Pointer(cUri))
+
+	logger := newLogger(logOutput)
+
+	var cFlags C.uint
+	switch mode {
+	case ReadWrite:
+		cFlags = 0
+	case ReadOnly:
+		cFlags = C.VIR_CONNECT_RO
+	default:
+		return Connection{}, ErrInvalidConnectionMode
+	}
+
+	if uri == DefaultURI {
+		logger.Printf("opening authenticated connection (mode = %v) to the default URI...\n", mode)
+	} else {
+		logger.Printf("opening authenticated connection (mode = %v) to %v...\n", mode, uri)
+	}
+
+	var cConn C.virConnectPtr
+	if auth == nil {
+		cConn = C.virConnectOpenAuth(cUri, C.virConnectAuthPtrDefault, cFlags)
+	} else {
+		cCredTypes := make([]C.int, len(auth.CredentialTypes))
+		for i, typ := range auth.CredentialTypes {
+			cCredTypes[i] = C.int(typ)
+		}
+
+		var cCredTypesPtr *C.int
+		if len(cCredTypes) > 0 {
+			cCredTypesPtr = &cCredTypes[0]
+		}
+
+		id := registerCallback(auth.Callback)
+		defer unregisterCallback(id)
+
+		cConn = C.openAuthHelper(cUri, cCredTypesPtr, C.uint(len(cCredTypes)), C.long(id), cFlags)
+	}
+
+	if cConn == nil {
+		err := LastError()
+		logger.Printf("an error occurred: %v\n", err)
+		return Connection{}, err
+	}
+
+	logger.Println("connection established")
+
+	conn := Connection{
+		log:        logger,
+		virConnect: cConn,
+		eventLoop:  eventLoopRegistered(),
+		state:      &connectionState{},
+	}
+
+	return conn, nil
+}
+
+//export connectAuthCallback
+func connectAuthCallback(cCreds C.virConnectCredentialPtr, cNCreds C.uint, cID C.long) C.int {
+	cb, ok := lookupCallback(int(cID))
+	if !ok {
+		return -1
+	}
+
+	callback, ok := cb.(CredentialCallback)
+	if !ok || callback == nil {
+		return -1
+	}
+
+	var cCredsSlice []C.virConnectCredential
+	credsSH := (*reflect.SliceHeader)(unsafe.Pointer(&cCredsSlice))
+	credsSH.Data = uintptr(unsafe.Pointer(cCreds))
+	credsSH.Cap = int(cNCreds)
+	credsSH.Len = int(cNCreds)
+
+	creds := make([]Credential, len(cCredsSlice))
+	for i, cCred := range cCredsSlice {
+		creds[i] = Credential{
+			Type:          CredentialType(cCred._type),
+			Prompt:        C.GoString(cCred.prompt),
+			Challenge:     C.GoString(cCred.challenge),
+			DefaultResult: C.GoString(cCred.defresult),
+		}
+	}
+
+	if err := callback(creds); err != nil {
+		return -1
+	}
+
+	// libvirt takes ownership of the results and frees them afterwards, so
+	// they must be allocated on the C heap.
+	for i := range cCredsSlice {
+		cCredsSlice[i].result = C.CString(creds[i].Result)
+		cCredsSlice[i].resultlen = C.uint(len(creds[i].Result))
+	}
+
+	return 0
+}
@@ -0,0 +1,92 @@
+package libvirt
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/cd1/utils-golang"
+)
+
+// newTestAuthURI writes a custom configuration for the "test" driver which
+// requires authentication, and returns the URI to connect to it.
+func newTestAuthURI(t *testing.T, username string, password string) (string, func()) {
+	file, err := ioutil.TempFile("", "auth-node-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	data := struct{ Username, Password string }{username, password}
+
+	if err = testAuthNodeTmpl.Execute(file, data); err != nil {
+		os.Remove(file.Name())
+		t.Fatal(err)
+	}
+
+	return fmt.Sprintf("test://%v", file.Name()), func() { os.Remove(file.Name()) }
+}
+
+func TestOpenAuthDefault(t *testing.T) {
+	conn, err := OpenAuth("test:///default", ReadWrite, nil, testLogOutput)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = conn.Close(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestOpenAuthCallback(t *testing.T) {
+	username := utils.RandomString()
+	password := utils.RandomString()
+
+	uri, cleanUp := newTestAuthURI(t, username, password)
+	defer cleanUp()
+
+	auth := &Auth{
+		CredentialTypes: []CredentialType{CredAuthname, CredPassphrase},
+		Callback: func(creds []Credential) error {
+			return errors.New("authentication refused by the test")
+		},
+	}
+
+	if _, err := OpenAuth(uri, ReadWrite, auth, testLogOutput); err == nil {
+		t.Error("an error was not returned when the credential callback failed")
+	}
+
+	var requested []CredentialType
+	auth.Callback = func(creds []Credential) error {
+		for i, c := range creds {
+			requested = append(requested, c.Type)
+
+			switch c.Type {
+			case CredAuthname:
+				creds[i].Result = username
+			case CredPassphrase:
+				creds[i].Result = password
+			}
+		}
+
+		return nil
+	}
+
+	conn, err := OpenAuth(uri, ReadWrite, auth, testLogOutput)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	if len(requested) == 0 {
+		t.Error("the credential callback was not called")
+	}
+
+	for _, typ := range requested {
+		if typ != CredAuthname && typ != CredPassphrase {
+			t.Errorf("unexpected credential type requested; got=%v", typ)
+		}
+	}
+}
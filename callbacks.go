@@ -0,0 +1,49 @@
+package libvirt
+
+import (
+	"sync"
+)
+
+// callbacks holds the Go functions which may be invoked by libvirt from C
+// code. C code can't keep references to Go values, so only an integer ID is
+// handed over to libvirt as the callback's opaque data; the exported Go
+// functions use that ID to find the actual function.
+var callbacks = struct {
+	sync.RWMutex
+	nextID int
+	funcs  map[int]interface{}
+}{
+	funcs: make(map[int]interface{}),
+}
+
+// registerCallback stores a Go callback and returns the ID which identifies
+// it on the C side.
+func registerCallback(cb interface{}) int {
+	callbacks.Lock()
+	defer callbacks.Unlock()
+
+	callbacks.nextID++
+	id := callbacks.nextID
+	callbacks.funcs[id] = cb
+
+	return id
+}
+
+// lookupCallback finds the Go callback identified by "id".
+func lookupCallback(id int) (interface{}, bool) {
+	callbacks.RLock()
+	defer callbacks.RUnlock()
+
+	cb, ok := callbacks.funcs[id]
+
+	return cb, ok
+}
+
+// unregisterCallback removes the Go callback identified by "id", so it can be
+// garbage collected.
+func unregisterCallback(id int) {
+	callbacks.Lock()
+	defer callbacks.Unlock()
+
+	delete(callbacks.funcs, id)
+}
@@ -0,0 +1,220 @@
+package libvirt
+
+// The C functions below forward the libvirt callbacks to the Go functions
+// exported by this package. They can't be defined next to the "//export"
+// directives because cgo doesn't allow C definitions in those files.
+
+/*
+#include <stdint.h>
+#include <libvirt/libvirt.h>
+
+extern int connectAuthCallback(virConnectCredentialPtr, unsigned int, long);
+extern void connectCloseCallback(virConnectPtr, int, long);
+extern void freeCallback(long);
+extern void domainEventLifecycleCallback(virConnectPtr, virDomainPtr, int, int, long);
+extern void domainEventRebootCallback(virConnectPtr, virDomainPtr, long);
+extern void domainEventRTCChangeCallback(virConnectPtr, virDomainPtr, long long, long);
+extern void domainEventWatchdogCallback(virConnectPtr, virDomainPtr, int, long);
+extern void domainEventIOErrorCallback(virConnectPtr, virDomainPtr, char *, char *, int, char *, long);
+extern void domainEventDeviceRemovedCallback(virConnectPtr, virDomainPtr, char *, long);
+extern void networkEventLifecycleCallback(virConnectPtr, virNetworkPtr, int, int, long);
+extern void nodeDeviceEventLifecycleCallback(virConnectPtr, virNodeDevicePtr, int, int, long);
+extern void nodeDeviceEventUpdateCallback(virConnectPtr, virNodeDevicePtr, long);
+extern void secretEventLifecycleCallback(virConnectPtr, virSecretPtr, int, int, long);
+extern void secretEventValueChangedCallback(virConnectPtr, virSecretPtr, long);
+extern void errorFuncCallback(virErrorPtr);
+
+static void freeCallbackHelper(void *opaque)
+{
+    freeCallback((long)(intptr_t)opaque);
+}
+
+static int connectAuthCallbackHelper(virConnectCredentialPtr cred, unsigned int ncred, void *cbdata)
+{
+    return connectAuthCallback(cred, ncred, (long)(intptr_t)cbdata);
+}
+
+virConnectPtr openAuthHelper(const char *uri, int *credtype, unsigned int ncredtype, long callbackID, unsigned int flags)
+{
+    virConnectAuth auth = {
+        .credtype = credtype,
+        .ncredtype = ncredtype,
+        .cb = connectAuthCallbackHelper,
+        .cbdata = (void *)(intptr_t)callbackID,
+    };
+
+    return virConnectOpenAuth(uri, &auth, flags);
+}
+
+static void connectCloseCallbackHelper(virConnectPtr conn, int reason, void *opaque)
+{
+    connectCloseCallback(conn, reason, (long)(intptr_t)opaque);
+}
+
+int registerCloseCallbackHelper(virConnectPtr conn, long callbackID)
+{
+    return virConnectRegisterCloseCallback(conn, connectCloseCallbackHelper,
+                                           (void *)(intptr_t)callbackID,
+                                           freeCallbackHelper);
+}
+
+int unregisterCloseCallbackHelper(virConnectPtr conn)
+{
+    return virConnectUnregisterCloseCallback(conn, connectCloseCallbackHelper);
+}
+
+static void domainEventLifecycleCallbackHelper(virConnectPtr conn, virDomainPtr dom, int event, int detail, void *opaque)
+{
+    domainEventLifecycleCallback(conn, dom, event, detail, (long)(intptr_t)opaque);
+}
+
+static void domainEventRebootCallbackHelper(virConnectPtr conn, virDomainPtr dom, void *opaque)
+{
+    domainEventRebootCallback(conn, dom, (long)(intptr_t)opaque);
+}
+
+static void domainEventRTCChangeCallbackHelper(virConnectPtr conn, virDomainPtr dom, long long utcoffset, void *opaque)
+{
+    domainEventRTCChangeCallback(conn, dom, utcoffset, (long)(intptr_t)opaque);
+}
+
+static void domainEventWatchdogCallbackHelper(virConnectPtr conn, virDomainPtr dom, int action, void *opaque)
+{
+    domainEventWatchdogCallback(conn, dom, action, (long)(intptr_t)opaque);
+}
+
+static void domainEventIOErrorCallbackHelper(virConnectPtr conn, virDomainPtr dom, const char *srcPath, const char *devAlias, int action, const char *reason, void *opaque)
+{
+    domainEventIOErrorCallback(conn, dom, (char *)srcPath, (char *)devAlias, action, (char *)reason, (long)(intptr_t)opaque);
+}
+
+static void domainEventDeviceRemovedCallbackHelper(virConnectPtr conn, virDomainPtr dom, const char *devAlias, void *opaque)
+{
+    domainEventDeviceRemovedCallback(conn, dom, (char *)devAlias, (long)(intptr_t)opaque);
+}
+
+int domainEventRegisterAnyHelper(virConnectPtr conn, virDomainPtr dom, int eventID, long callbackID)
+{
+    virConnectDomainEventGenericCallback cb;
+
+    switch (eventID) {
+    case VIR_DOMAIN_EVENT_ID_LIFECYCLE:
+        cb = VIR_DOMAIN_EVENT_CALLBACK(domainEventLifecycleCallbackHelper);
+        break;
+    case VIR_DOMAIN_EVENT_ID_REBOOT:
+        cb = VIR_DOMAIN_EVENT_CALLBACK(domainEventRebootCallbackHelper);
+        break;
+    case VIR_DOMAIN_EVENT_ID_RTC_CHANGE:
+        cb = VIR_DOMAIN_EVENT_CALLBACK(domainEventRTCChangeCallbackHelper);
+        break;
+    case VIR_DOMAIN_EVENT_ID_WATCHDOG:
+        cb = VIR_DOMAIN_EVENT_CALLBACK(domainEventWatchdogCallbackHelper);
+        break;
+    case VIR_DOMAIN_EVENT_ID_IO_ERROR_REASON:
+        cb = VIR_DOMAIN_EVENT_CALLBACK(domainEventIOErrorCallbackHelper);
+        break;
+    case VIR_DOMAIN_EVENT_ID_DEVICE_REMOVED:
+        cb = VIR_DOMAIN_EVENT_CALLBACK(domainEventDeviceRemovedCallbackHelper);
+        break;
+    default:
+        return -1;
+    }
+
+    return virConnectDomainEventRegisterAny(conn, dom, eventID, cb,
+                                            (void *)(intptr_t)callbackID,
+                                            freeCallbackHelper);
+}
+
+static void networkEventLifecycleCallbackHelper(virConnectPtr conn, virNetworkPtr net, int event, int detail, void *opaque)
+{
+    networkEventLifecycleCallback(conn, net, event, detail, (long)(intptr_t)opaque);
+}
+
+int networkEventRegisterAnyHelper(virConnectPtr conn, virNetworkPtr net, int eventID, long callbackID)
+{
+    virConnectNetworkEventGenericCallback cb;
+
+    switch (eventID) {
+    case VIR_NETWORK_EVENT_ID_LIFECYCLE:
+        cb = VIR_NETWORK_EVENT_CALLBACK(networkEventLifecycleCallbackHelper);
+        break;
+    default:
+        return -1;
+    }
+
+    return virConnectNetworkEventRegisterAny(conn, net, eventID, cb,
+                                             (void *)(intptr_t)callbackID,
+                                             freeCallbackHelper);
+}
+
+static void nodeDeviceEventLifecycleCallbackHelper(virConnectPtr conn, virNodeDevicePtr dev, int event, int detail, void *opaque)
+{
+    nodeDeviceEventLifecycleCallback(conn, dev, event, detail, (long)(intptr_t)opaque);
+}
+
+static void nodeDeviceEventUpdateCallbackHelper(virConnectPtr conn, virNodeDevicePtr dev, void *opaque)
+{
+    nodeDeviceEventUpdateCallback(conn, dev, (long)(intptr_t)opaque);
+}
+
+int nodeDeviceEventRegisterAnyHelper(virConnectPtr conn, virNodeDevicePtr dev, int eventID, long callbackID)
+{
+    virConnectNodeDeviceEventGenericCallback cb;
+
+    switch (eventID) {
+    case VIR_NODE_DEVICE_EVENT_ID_LIFECYCLE:
+        cb = VIR_NODE_DEVICE_EVENT_CALLBACK(nodeDeviceEventLifecycleCallbackHelper);
+        break;
+    case VIR_NODE_DEVICE_EVENT_ID_UPDATE:
+        cb = VIR_NODE_DEVICE_EVENT_CALLBACK(nodeDeviceEventUpdateCallbackHelper);
+        break;
+    default:
+        return -1;
+    }
+
+    return virConnectNodeDeviceEventRegisterAny(conn, dev, eventID, cb,
+                                                (void *)(intptr_t)callbackID,
+                                                freeCallbackHelper);
+}
+
+static void secretEventLifecycleCallbackHelper(virConnectPtr conn, virSecretPtr secret, int event, int detail, void *opaque)
+{
+    secretEventLifecycleCallback(conn, secret, event, detail, (long)(intptr_t)opaque);
+}
+
+static void secretEventValueChangedCallbackHelper(virConnectPtr conn, virSecretPtr secret, void *opaque)
+{
+    secretEventValueChangedCallback(conn, secret, (long)(intptr_t)opaque);
+}
+
+int secretEventRegisterAnyHelper(virConnectPtr conn, virSecretPtr secret, int eventID, long callbackID)
+{
+    virConnectSecretEventGenericCallback cb;
+
+    switch (eventID) {
+    case VIR_SECRET_EVENT_ID_LIFECYCLE:
+        cb = VIR_SECRET_EVENT_CALLBACK(secretEventLifecycleCallbackHelper);
+        break;
+    case VIR_SECRET_EVENT_ID_VALUE_CHANGED:
+        cb = VIR_SECRET_EVENT_CALLBACK(secretEventValueChangedCallbackHelper);
+        break;
+    default:
+        return -1;
+    }
+
+    return virConnectSecretEventRegisterAny(conn, secret, eventID, cb,
+                                            (void *)(intptr_t)callbackID,
+                                            freeCallbackHelper);
+}
+
+static void errorFuncHelper(void *userData, virErrorPtr error)
+{
+    errorFuncCallback(error);
+}
+
+void setErrorFuncHelper(void)
+{
+    virSetErrorFunc(NULL, errorFuncHelper);
+}
+*/
+import "C"
@@ -5,18 +5,20 @@ package libvirt
 #include <stdlib.h>
 #include <libvirt/libvirt.h>
 #include <libvirt/virterror.h>
-
-void emptyErrorFunc(void *userData, virErrorPtr error) {
-    // do nothing
-}
 */
 import "C"
 import (
+	"bytes"
 	"errors"
 	"io"
 	"io/ioutil"
 	"log"
+	"os"
 	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
 	"unicode/utf8"
 	"unsafe"
 )
@@ -25,8 +27,113 @@ import (
 type Connection struct {
 	log        *log.Logger
 	virConnect C.virConnectPtr
+	eventLoop  bool             // whether an event loop was registered before opening
+	state      *connectionState // shared by all copies of the connection
+}
+
+// connectionState holds the state of a connection which must be seen by all
+// of its copies, as the methods have value receivers.
+type connectionState struct {
+	sync.RWMutex
+	closed bool
+}
+
+// NodeInfo holds information about the host on which the hypervisor is
+// running.
+type NodeInfo struct {
+	Model   string // CPU model
+	Memory  uint64 // memory size in kiB
+	CPUs    uint   // number of active CPUs
+	MHz     uint   // expected CPU frequency, 0 if not known or on unusual architectures
+	Nodes   uint   // number of NUMA cells, 1 for unusual NUMA topologies or uniform memory access
+	Sockets uint   // number of CPU sockets per node if nodes > 1, 1 in case of unusual NUMA topology
+	Cores   uint   // number of cores per socket, total number of processors in case of unusual NUMA topology
+	Threads uint   // number of threads per core, 1 in case of unusual NUMA topology
+}
+
+// NodeCPUStatsAllCPUs can be used as the CPU number in "CPUStats" to get the
+// statistics of all CPUs together.
+const NodeCPUStatsAllCPUs = C.VIR_NODE_CPU_STATS_ALL_CPUS
+
+// NodeCPUStats holds the CPU statistics of the node. All values are cumulative
+// times, in nanoseconds. Any field reported by the driver which doesn't have a
+// corresponding struct field is stored in "Extra".
+type NodeCPUStats struct {
+	Kernel uint64
+	User   uint64
+	Idle   uint64
+	IOWait uint64
+	Extra  map[string]uint64
+}
+
+// NodeMemoryStatsAllCells can be used as the cell number in "MemoryStats" to
+// get the statistics of the whole node.
+const NodeMemoryStatsAllCells = C.VIR_NODE_MEMORY_STATS_ALL_CELLS
+
+// NodeMemoryStats holds the memory statistics of the node. All values are in
+// kiB. Any field reported by the driver which doesn't have a corresponding
+// struct field is stored in "Extra".
+type NodeMemoryStats struct {
+	Total   uint64
+	Free    uint64
+	Buffers uint64
+	Cached  uint64
+	Extra   map[string]uint64
+}
+
+// NodeAllocPagesFlag defines how the pages are allocated by "AllocPages".
+type NodeAllocPagesFlag uint32
+
+// Possible values for NodeAllocPagesFlag.
+const (
+	NodeAllocPagesAdd NodeAllocPagesFlag = C.VIR_NODE_ALLOC_PAGES_ADD
+	NodeAllocPagesSet NodeAllocPagesFlag = C.VIR_NODE_ALLOC_PAGES_SET
+)
+
+// NodeSuspendTarget defines the power state to which a system is suspended.
+type NodeSuspendTarget uint32
+
+// Possible values for NodeSuspendTarget.
+const (
+	NodeSuspendTargetMem    NodeSuspendTarget = C.VIR_NODE_SUSPEND_TARGET_MEM    // suspend to RAM (S3)
+	NodeSuspendTargetDisk   NodeSuspendTarget = C.VIR_NODE_SUSPEND_TARGET_DISK   // suspend to disk (S4)
+	NodeSuspendTargetHybrid NodeSuspendTarget = C.VIR_NODE_SUSPEND_TARGET_HYBRID // suspend to both
+)
+
+// SecurityModel holds the security model used by the hypervisor.
+type SecurityModel struct {
+	Model string // security model name (e.g. "selinux")
+	DOI   string // domain of interpretation
 }
 
+// CompareCPUFlag defines how a CPU is compared by "CompareCPU".
+type CompareCPUFlag uint32
+
+// Possible values for CompareCPUFlag.
+const (
+	CompareCPUFailIncompatible CompareCPUFlag = C.VIR_CONNECT_COMPARE_CPU_FAIL_INCOMPATIBLE
+	CompareCPUValidateXML      CompareCPUFlag = C.VIR_CONNECT_COMPARE_CPU_VALIDATE_XML
+)
+
+// CPUCompareResult is the result of a CPU comparison.
+type CPUCompareResult int32
+
+// Possible values for CPUCompareResult.
+const (
+	CPUCompareIncompatible CPUCompareResult = C.VIR_CPU_COMPARE_INCOMPATIBLE
+	CPUCompareIdentical    CPUCompareResult = C.VIR_CPU_COMPARE_IDENTICAL
+	CPUCompareSuperset     CPUCompareResult = C.VIR_CPU_COMPARE_SUPERSET
+)
+
+// BaselineCPUFlag defines how the baseline CPU is computed by "BaselineCPU".
+type BaselineCPUFlag uint32
+
+// Possible values for BaselineCPUFlag.
+const (
+	BaselineCPUExpandFeatures BaselineCPUFlag = C.VIR_CONNECT_BASELINE_CPU_EXPAND_FEATURES
+	BaselineCPUMigratable     BaselineCPUFlag = C.VIR_CONNECT_BASELINE_CPU_MIGRATABLE
+)
+
 // ConnectionMode is the type of connection to the libvirt hypervisor.
 type ConnectionMode uint
 
@@ -45,11 +152,29 @@ const DefaultURI = ""
 // "ReadOnly" or "ReadWrite" is used.
 var ErrInvalidConnectionMode = errors.New("invalid libvirt connection mode")
 
-func init() {
-	// Supress the native error output. There's no way to do this per
-	// connection, so we have to do this globally.
-	C.virSetErrorFunc(nil, C.virErrorFunc(unsafe.Pointer(C.emptyErrorFunc)))
-}
+// ErrKeepAliveUnsupported is returned by "SetKeepAlive" when the remote party
+// doesn't support keepalive messages.
+var ErrKeepAliveUnsupported = errors.New("keepalive is not supported by the remote party")
+
+// ErrInvalidCellCount is returned by "CellsFreeMemory", "FreePages" and
+// "AllocPages" when the number of cells is not a positive value.
+var ErrInvalidCellCount = errors.New("the number of cells must be positive")
+
+// ErrNoPageSizes is returned by "FreePages" and "AllocPages" when no page size
+// is specified.
+var ErrNoPageSizes = errors.New("no page size was specified")
+
+// ErrSecurityModelUnavailable is returned by "SecurityModel" when the
+// hypervisor doesn't have a security model.
+var ErrSecurityModelUnavailable = errors.New("the hypervisor doesn't have a security model")
+
+// ErrInvalidConnection is returned by every method of "Connection" when the
+// connection hasn't been opened (e.g. a zero value) or when it has already
+// been closed.
+var ErrInvalidConnection = errors.New("the connection is not open")
+
+// ErrInvalidUUID is returned when a UUID string can't be parsed.
+var ErrInvalidUUID = errors.New("invalid UUID")
 
 // newLogger creates a logger object to be used across a libvirt
 // connection. It prints the messages to the default error output.
@@ -57,10 +182,70 @@ func newLogger(output io.Writer) *log.Logger {
 	return log.New(output, "libvirt-golang: ", log.LstdFlags)
 }
 
+// goStringFromArray converts a fixed-size C char array, which is not
+// necessarily NUL-terminated, into a Go string.
+func goStringFromArray(cArray *C.char, size int) string {
+	b := C.GoBytes(unsafe.Pointer(cArray), C.int(size))
+
+	if i := bytes.IndexByte(b, 0); i != -1 {
+		b = b[:i]
+	}
+
+	return string(b)
+}
+
+// asciiSpace holds the whitespace characters skipped by libvirt around a UUID.
+const asciiSpace = " \t\n\v\f\r"
+
+// parseUUID parses a UUID string the same way libvirt does: surrounding
+// whitespace is ignored, and any number of dashes and spaces may appear before
+// each pair of hexadecimal digits (e.g. the canonical format).
+func parseUUID(str string) ([16]byte, error) {
+	var uuid [16]byte
+
+	str = strings.TrimLeft(str, asciiSpace)
+
+	for i := 0; i < len(uuid); i++ {
+		str = strings.TrimLeft(str, "- ")
+
+		if len(str) < 2 {
+			return uuid, ErrInvalidUUID
+		}
+
+		b, err := strconv.ParseUint(str[:2], 16, 8)
+		if err != nil {
+			return uuid, ErrInvalidUUID
+		}
+
+		uuid[i] = byte(b)
+		str = str[2:]
+	}
+
+	if strings.TrimLeft(str, asciiSpace) != "" {
+		return uuid, ErrInvalidUUID
+	}
+
+	return uuid, nil
+}
+
+// cStringOrNil converts a Go string into a C string, like C.CString, but an
+// empty string is converted into NULL. The result must be released with
+// C.free.
+func cStringOrNil(str string) *C.char {
+	if str == "" {
+		return nil
+	}
+
+	return C.CString(str)
+}
+
 // Open creates a new libvirt connection to the Hypervisor. The
 // connection mode specifies whether the connection will be read-write
 // or read-only. The URIs are documented at http://libvirt.org/uri.html.
 func Open(uri string, mode ConnectionMode, logOutput io.Writer) (Connection, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	cUri := C.CString(uri)
 	defer C.free(unsafe.Pointer(cUri))
 
@@ -93,11 +278,30 @@ func Open(uri string, mode ConnectionMode, logOutput io.Writer) (Connection, err
 	conn := Connection{
 		log:        logger,
 		virConnect: cConn,
+		eventLoop:  eventLoopRegistered(),
+		state:      &connectionState{},
 	}
 
 	return conn, nil
 }
 
+// valid checks whether the connection may be used, i.e. it has been opened
+// and it hasn't been closed yet.
+func (conn Connection) valid() error {
+	if conn.virConnect == nil || conn.state == nil {
+		return ErrInvalidConnection
+	}
+
+	conn.state.RLock()
+	defer conn.state.RUnlock()
+
+	if conn.state.closed {
+		return ErrInvalidConnection
+	}
+
+	return nil
+}
+
 // OpenDefault creates a new read-write libvirt connection to the
 // hypervisor using the default URI.
 func OpenDefault() (Connection, error) {
@@ -106,11 +310,10 @@ func OpenDefault() (Connection, error) {
 
 // Close closes the connection to the Hypervisor. Connections are reference
 // counted; the count is explicitly increased by the initial open (Open,
-// OpenAuth, and the like) as well as Ref (not implemented yet); it is also
-// temporarily increased by other API that depend on the connection remaining
-// alive. The open and every Ref call should have a matching Close, and all
-// other references will be released after the corresponding operation
-// completes.
+// OpenAuth, and the like) as well as Ref; it is also temporarily increased by
+// other API that depend on the connection remaining alive. The open and every
+// Ref call should have a matching Close, and all other references will be
+// released after the corresponding operation completes.
 // It returns a positive number if at least 1 reference remains on success. The
 // returned value should not be assumed to be the total reference count. A
 // return of 0 implies no references remain and the connection is closed and
@@ -119,6 +322,13 @@ func OpenDefault() (Connection, error) {
 // connection, but the application should not try to further use a connection
 // after the Close that matches the initial open.
 func (conn Connection) Close() (int32, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := conn.valid(); err != nil {
+		return 0, err
+	}
+
 	conn.log.Println("closing connection...")
 	cRet := C.virConnectClose(conn.virConnect)
 	ret := int32(cRet)
@@ -129,6 +339,12 @@ func (conn Connection) Close() (int32, error) {
 		return 0, err
 	}
 
+	if ret == 0 {
+		conn.state.Lock()
+		conn.state.closed = true
+		conn.state.Unlock()
+	}
+
 	conn.log.Printf("connection closed; remaining references: %v\n", ret)
 
 	return ret, nil
@@ -136,6 +352,13 @@ func (conn Connection) Close() (int32, error) {
 
 // Version gets the version level of the Hypervisor running.
 func (conn Connection) Version() (uint64, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := conn.valid(); err != nil {
+		return 0, err
+	}
+
 	var cVersion C.ulong
 	conn.log.Println("reading hypervisor version...")
 	cRet := C.virConnectGetVersion(conn.virConnect, &cVersion)
@@ -156,6 +379,13 @@ func (conn Connection) Version() (uint64, error) {
 // LibVersion provides the version of libvirt used by the daemon running on
 // the host.
 func (conn Connection) LibVersion() (uint64, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := conn.valid(); err != nil {
+		return 0, err
+	}
+
 	var cVersion C.ulong
 	conn.log.Println("reading libvirt version...")
 	cRet := C.virConnectGetLibVersion(conn.virConnect, &cVersion)
@@ -173,83 +403,100 @@ func (conn Connection) LibVersion() (uint64, error) {
 	return version, nil
 }
 
+// VersionInfo gets the version level of the Hypervisor running, decoded into
+// its components.
+func (conn Connection) VersionInfo() (VersionNumber, error) {
+	version, err := conn.Version()
+	if err != nil {
+		return VersionNumber{}, err
+	}
+
+	return newVersionNumber(version), nil
+}
+
+// LibVersionInfo provides the version of libvirt used by the daemon running on
+// the host, decoded into its components.
+func (conn Connection) LibVersionInfo() (VersionNumber, error) {
+	version, err := conn.LibVersion()
+	if err != nil {
+		return VersionNumber{}, err
+	}
+
+	return newVersionNumber(version), nil
+}
+
 // IsAlive determines if the connection to the hypervisor is still alive.
-// If an error occurs, the function will also return "false" and the error
-// message will be written to the log.
+// If the check fails, an error is returned along with "false", which must not
+// be taken as an answer.
 func (conn Connection) IsAlive() (bool, error) {
-	conn.log.Println("checking whether connection is alive...")
-	cRet := C.virConnectIsAlive(conn.virConnect)
-	ret := int32(cRet)
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
 
-	if ret == -1 {
-		err := LastError()
-		conn.log.Printf("an error occurred: %v\n", err)
+	if err := conn.valid(); err != nil {
 		return false, err
 	}
 
-	alive := (ret == 1)
+	cRet := C.virConnectIsAlive(conn.virConnect)
+	ret := int32(cRet)
 
-	if alive {
-		conn.log.Println("connection is alive")
-	} else {
-		conn.log.Println("connection is not alive")
+	if ret == -1 {
+		return false, LastError()
 	}
 
-	return alive, nil
+	return ret == 1, nil
 }
 
 // IsEncrypted determines if the connection to the hypervisor is encrypted.
-// If an error occurs, the function will also return "false" and the error
-// message will be written to the log.
+// If the check fails, an error is returned along with "false", which must not
+// be taken as an answer.
 func (conn Connection) IsEncrypted() (bool, error) {
-	conn.log.Println("checking whether connection is encrypted...")
-	cRet := C.virConnectIsEncrypted(conn.virConnect)
-	ret := int32(cRet)
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
 
-	if ret == -1 {
-		err := LastError()
-		conn.log.Printf("an error occurred: %v\n", err)
+	if err := conn.valid(); err != nil {
 		return false, err
 	}
 
-	encrypted := (ret == 1)
+	cRet := C.virConnectIsEncrypted(conn.virConnect)
+	ret := int32(cRet)
 
-	if encrypted {
-		conn.log.Println("connection is encrypted")
-	} else {
-		conn.log.Println("connection is not encrypted")
+	if ret == -1 {
+		return false, LastError()
 	}
 
-	return encrypted, nil
+	return ret == 1, nil
 }
 
 // IsSecure determines if the connection to the hypervisor is secure.
-// If an error occurs, the function will also return "false" and the error
-// message will be written to the log.
+// If the check fails, an error is returned along with "false", which must not
+// be taken as an answer.
 func (conn Connection) IsSecure() (bool, error) {
-	conn.log.Println("checking whether connection is secure...")
-	cRet := C.virConnectIsSecure(conn.virConnect)
-	ret := int32(cRet)
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
 
-	if ret == -1 {
-		err := LastError()
-		conn.log.Printf("an error occurred: %v\n", err)
+	if err := conn.valid(); err != nil {
 		return false, err
 	}
 
-	secure := (ret == 1)
+	cRet := C.virConnectIsSecure(conn.virConnect)
+	ret := int32(cRet)
 
-	if secure {
-		conn.log.Println("connection is secure")
-	} else {
-		conn.log.Println("connection is not secure")
+	if ret == -1 {
+		return false, LastError()
 	}
 
-	return secure, nil
+	return ret == 1, nil
 }
 
 // Capabilities provides capabilities of the hypervisor/driver.
 func (conn Connection) Capabilities() (string, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := conn.valid(); err != nil {
+		return "", err
+	}
+
 	conn.log.Println("reading connection capabilities...")
 	cCap := C.virConnectGetCapabilities(conn.virConnect)
 	if cCap == nil {
@@ -265,11 +512,86 @@ func (conn Connection) Capabilities() (string, error) {
 	return cap, nil
 }
 
+// DomainCapabilities returns an XML document describing the capabilities of
+// the hypervisor for creating domains with the given emulator binary,
+// architecture, machine type and virtualization type (e.g. which devices,
+// machine types and CPU modes are supported). Any of the parameters may be
+// empty, in which case the hypervisor picks a sensible default. "flags" is
+// currently unused by libvirt and should be 0.
+func (conn Connection) DomainCapabilities(emulatorBin string, arch string, machine string, virtType string, flags uint32) (string, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := conn.valid(); err != nil {
+		return "", err
+	}
+
+	cEmulatorBin := cStringOrNil(emulatorBin)
+	defer C.free(unsafe.Pointer(cEmulatorBin))
+
+	cArch := cStringOrNil(arch)
+	defer C.free(unsafe.Pointer(cArch))
+
+	cMachine := cStringOrNil(machine)
+	defer C.free(unsafe.Pointer(cMachine))
+
+	cVirtType := cStringOrNil(virtType)
+	defer C.free(unsafe.Pointer(cVirtType))
+
+	conn.log.Printf("reading domain capabilities (emulatorBin = %v, arch = %v, machine = %v, virtType = %v, flags = %v)...\n", emulatorBin, arch, machine, virtType, flags)
+	cCap := C.virConnectGetDomainCapabilities(conn.virConnect, cEmulatorBin, cArch, cMachine, cVirtType, C.uint(flags))
+	if cCap == nil {
+		err := LastError()
+		conn.log.Printf("an error occurred: %v\n", err)
+		return "", err
+	}
+	defer C.free(unsafe.Pointer(cCap))
+
+	cap := C.GoString(cCap)
+	conn.log.Printf("domain capabilities XML length: %v runes\n", utf8.RuneCountInString(cap))
+
+	return cap, nil
+}
+
+// StoragePoolCapabilities returns an XML document describing the storage pool
+// types and the volume formats supported by the connection. If the daemon is
+// too old to support this call, the returned error has the code ErrNoSupport.
+// "flags" is currently unused by libvirt and should be 0.
+func (conn Connection) StoragePoolCapabilities(flags uint32) (string, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := conn.valid(); err != nil {
+		return "", err
+	}
+
+	conn.log.Printf("reading storage pool capabilities (flags = %v)...\n", flags)
+	cCap := C.virConnectGetStoragePoolCapabilities(conn.virConnect, C.uint(flags))
+	if cCap == nil {
+		err := LastError()
+		conn.log.Printf("an error occurred: %v\n", err)
+		return "", err
+	}
+	defer C.free(unsafe.Pointer(cCap))
+
+	cap := C.GoString(cCap)
+	conn.log.Printf("storage pool capabilities XML length: %v runes\n", utf8.RuneCountInString(cap))
+
+	return cap, nil
+}
+
 // Hostname returns a system hostname on which the hypervisor is running
 // (based on the result of the gethostname system call, but possibly expanded
 // to a fully-qualified domain name via getaddrinfo). If we are connected to a
 // remote system, then this returns the hostname of the remote system.
 func (conn Connection) Hostname() (string, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := conn.valid(); err != nil {
+		return "", err
+	}
+
 	conn.log.Println("reading system hostname...")
 	cHostname := C.virConnectGetHostname(conn.virConnect)
 	if cHostname == nil {
@@ -290,6 +612,13 @@ func (conn Connection) Hostname() (string, error) {
 // of a domain XML. This information is generally available only for
 // hypervisors running with root privileges.
 func (conn Connection) Sysinfo() (string, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := conn.valid(); err != nil {
+		return "", err
+	}
+
 	conn.log.Println("reading system info...")
 	cSysinfo := C.virConnectGetSysinfo(conn.virConnect, 0)
 	if cSysinfo == nil {
@@ -310,6 +639,13 @@ func (conn Connection) Sysinfo() (string, error) {
 // acceleration is present. For more details about the hypervisor, use
 // Capabilities.
 func (conn Connection) Type() (string, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := conn.valid(); err != nil {
+		return "", err
+	}
+
 	conn.log.Println("reading hypervisor driver name...")
 	cType := C.virConnectGetType(conn.virConnect)
 	if cType == nil {
@@ -330,6 +666,13 @@ func (conn Connection) Type() (string, error) {
 // then the driver will return a non-NULL URI which can be used to connect tos
 // the same hypervisor later.
 func (conn Connection) URI() (string, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := conn.valid(); err != nil {
+		return "", err
+	}
+
 	conn.log.Println("reading connection URI...")
 	cURI := C.virConnectGetURI(conn.virConnect)
 	if cURI == nil {
@@ -344,11 +687,18 @@ func (conn Connection) URI() (string, error) {
 	return uri, nil
 }
 
-// Ref increments the reference count on the connection. For each additional
-// call to this method, there shall be a corresponding call to Close to release
-// the reference count, once the caller no longer needs the reference to
-// this object.
+// Ref adds a reference to the connection, so that it stays open until Close
+// has been called once for the initial open and once for every Ref. This is
+// useful when a connection is shared by several goroutines: each of them can
+// take its own reference and release it with Close when it is done.
 func (conn Connection) Ref() error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := conn.valid(); err != nil {
+		return err
+	}
+
 	conn.log.Println("incrementing connection's reference count...")
 	cRet := C.virConnectRef(conn.virConnect)
 	ret := int32(cRet)
@@ -363,70 +713,88 @@ func (conn Connection) Ref() error {
 	return nil
 }
 
-// CPUModelNames gets the list of supported CPU models for a
-// specific architecture.
-func (conn Connection) CPUModelNames(arch string) ([]string, error) {
-	cArch := C.CString(arch)
-	defer C.free(unsafe.Pointer(cArch))
+// NodeInfo extracts hardware information about the node (i.e. the host on
+// which the hypervisor is running).
+func (conn Connection) NodeInfo() (NodeInfo, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
 
-	var cModels []*C.char
-	modelsSH := (*reflect.SliceHeader)(unsafe.Pointer(&cModels))
+	if err := conn.valid(); err != nil {
+		return NodeInfo{}, err
+	}
 
-	conn.log.Printf("querying supported CPU models for %v...\n", arch)
-	cRet := C.virConnectGetCPUModelNames(conn.virConnect, cArch, (***C.char)(unsafe.Pointer(&modelsSH.Data)), 0)
+	var cInfo C.virNodeInfo
+	conn.log.Println("reading node info...")
+	cRet := C.virNodeGetInfo(conn.virConnect, &cInfo)
 	ret := int32(cRet)
 
 	if ret == -1 {
 		err := LastError()
 		conn.log.Printf("an error occurred: %v\n", err)
-		return nil, err
+		return NodeInfo{}, err
 	}
-	defer C.free(unsafe.Pointer(modelsSH.Data))
-
-	modelsSH.Cap = int(ret)
-	modelsSH.Len = int(ret)
 
-	models := make([]string, ret)
-	for i := range models {
-		models[i] = C.GoString(cModels[i])
-		defer C.free(unsafe.Pointer(cModels[i]))
+	info := NodeInfo{
+		Model:   goStringFromArray(&cInfo.model[0], len(cInfo.model)),
+		Memory:  uint64(cInfo.memory),
+		CPUs:    uint(cInfo.cpus),
+		MHz:     uint(cInfo.mhz),
+		Nodes:   uint(cInfo.nodes),
+		Sockets: uint(cInfo.sockets),
+		Cores:   uint(cInfo.cores),
+		Threads: uint(cInfo.threads),
 	}
 
-	conn.log.Printf("CPU models count: %v\n", ret)
+	conn.log.Printf("node info: %+v\n", info)
 
-	return models, nil
+	return info, nil
 }
 
-// MaxVCPUs provides the maximum number of virtual CPUs supported for a guest
-// VM of a specific type. The 'type' parameter here corresponds to the 'type'
-// attribute in the <domain> element of the XML
-func (conn Connection) MaxVCPUs(typ string) (int32, error) {
-	cTyp := C.CString(typ)
-	defer C.free(unsafe.Pointer(cTyp))
+// FreeMemory provides the free memory available on the node, in bytes. If
+// the driver doesn't support this call, an error is returned.
+func (conn Connection) FreeMemory() (uint64, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
 
-	conn.log.Printf("querying maximum VCPUs count for %v...\n", typ)
-	cRet := C.virConnectGetMaxVcpus(conn.virConnect, cTyp)
-	ret := int32(cRet)
+	if err := conn.valid(); err != nil {
+		return 0, err
+	}
 
-	if ret == -1 {
+	conn.log.Println("querying node free memory...")
+	cMemory := C.virNodeGetFreeMemory(conn.virConnect)
+	memory := uint64(cMemory)
+
+	if memory == 0 {
 		err := LastError()
 		conn.log.Printf("an error occurred: %v\n", err)
 		return 0, err
 	}
 
-	conn.log.Printf("max VCPUs count: %v\n", ret)
+	conn.log.Printf("node free memory: %v bytes\n", memory)
 
-	return ret, nil
+	return memory, nil
 }
 
-// ListDomains collects a possibly-filtered list of all domains, and return an
-// array of information for each.
-func (conn Connection) ListDomains(flags DomainListFlag) ([]Domain, error) {
-	var cDomains []C.virDomainPtr
-	domainsSH := (*reflect.SliceHeader)(unsafe.Pointer(&cDomains))
+// CellsFreeMemory provides the free memory, in bytes, of each NUMA cell of the
+// node, starting at cell "startCell" and returning at most "maxCells" values.
+// The number of NUMA cells of the node can be read from NodeInfo.
+func (conn Connection) CellsFreeMemory(startCell int, maxCells int) ([]uint64, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
 
-	conn.log.Printf("reading domains (flags = %v)...\n", flags)
-	cRet := C.virConnectListAllDomains(conn.virConnect, (**C.virDomainPtr)(unsafe.Pointer(&domainsSH.Data)), C.uint(flags))
+	if err := conn.valid(); err != nil {
+		return nil, err
+	}
+
+	if maxCells <= 0 {
+		conn.log.Printf("an error occurred: %v\n", ErrInvalidCellCount)
+		return nil, ErrInvalidCellCount
+	}
+
+	cMemories := make([]C.ulonglong, maxCells)
+
+	conn.log.Printf("querying free memory of cells %v to %v...\n", startCell, startCell+maxCells-1)
+	cRet := C.virNodeGetCellsFreeMemory(conn.virConnect, &cMemories[0], C.int(startCell), C.int(maxCells))
 	ret := int32(cRet)
 
 	if ret == -1 {
@@ -434,44 +802,902 @@ func (conn Connection) ListDomains(flags DomainListFlag) ([]Domain, error) {
 		conn.log.Printf("an error occurred: %v\n", err)
 		return nil, err
 	}
-	defer C.free(unsafe.Pointer(domainsSH.Data))
-
-	domainsSH.Cap = int(ret)
-	domainsSH.Len = int(ret)
 
-	domains := make([]Domain, ret)
-	for i := range domains {
-		domains[i] = Domain{
-			log:       conn.log,
-			virDomain: cDomains[i],
-		}
+	memories := make([]uint64, ret)
+	for i := range memories {
+		memories[i] = uint64(cMemories[i])
 	}
 
-	conn.log.Printf("domains count: %v\n", ret)
+	conn.log.Printf("free memory read from %v cells\n", ret)
 
-	return domains, nil
+	return memories, nil
 }
 
-// CreateDomain launches a new guest domain, based on an XML description
-// similar to the one returned by Domain.XML() This function may require
-// privileged access to the hypervisor. The domain is not persistent, so its
-// definition will disappear when it is destroyed, or if the host is restarted
-// (see Domain.Define() to define persistent domains).
-func (conn Connection) CreateDomain(xml string, flags DomainCreateFlag) (Domain, error) {
-	cXML := C.CString(xml)
-	defer C.free(unsafe.Pointer(cXML))
+// CPUStats provides the CPU statistics of the node. If "cpuNum" is
+// NodeCPUStatsAllCPUs, the statistics of all CPUs are summed up; otherwise,
+// only the statistics of the specified CPU are returned.
+func (conn Connection) CPUStats(cpuNum int) (NodeCPUStats, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
 
-	conn.log.Printf("creating domain (flags = %v)...\n", flags)
-	cDomain := C.virDomainCreateXML(conn.virConnect, cXML, C.uint(flags))
-	if cDomain == nil {
+	if err := conn.valid(); err != nil {
+		return NodeCPUStats{}, err
+	}
+
+	var cNParams C.int
+
+	conn.log.Printf("querying number of CPU statistics for CPU %v...\n", cpuNum)
+	cRet := C.virNodeGetCPUStats(conn.virConnect, C.int(cpuNum), nil, &cNParams, 0)
+	ret := int32(cRet)
+
+	if ret == -1 {
 		err := LastError()
 		conn.log.Printf("an error occurred: %v\n", err)
-		return Domain{}, err
+		return NodeCPUStats{}, err
 	}
 
-	conn.log.Println("domain created")
+	stats := NodeCPUStats{
+		Extra: make(map[string]uint64),
+	}
 
-	dom := Domain{
+	if cNParams == 0 {
+		conn.log.Println("no CPU statistics available")
+		return stats, nil
+	}
+
+	cParams := make([]C.virNodeCPUStats, cNParams)
+
+	conn.log.Printf("reading %v CPU statistics for CPU %v...\n", cNParams, cpuNum)
+	cRet = C.virNodeGetCPUStats(conn.virConnect, C.int(cpuNum), &cParams[0], &cNParams, 0)
+	ret = int32(cRet)
+
+	if ret == -1 {
+		err := LastError()
+		conn.log.Printf("an error occurred: %v\n", err)
+		return NodeCPUStats{}, err
+	}
+
+	for _, cParam := range cParams[:cNParams] {
+		field := goStringFromArray(&cParam.field[0], len(cParam.field))
+		value := uint64(cParam.value)
+
+		switch field {
+		case "kernel":
+			stats.Kernel = value
+		case "user":
+			stats.User = value
+		case "idle":
+			stats.Idle = value
+		case "iowait":
+			stats.IOWait = value
+		default:
+			stats.Extra[field] = value
+		}
+	}
+
+	conn.log.Printf("CPU statistics: %+v\n", stats)
+
+	return stats, nil
+}
+
+// MemoryStats provides the memory statistics of the node. If "cellNum" is
+// NodeMemoryStatsAllCells, the statistics of the whole node are returned;
+// otherwise, only the statistics of the specified NUMA cell are returned.
+func (conn Connection) MemoryStats(cellNum int) (NodeMemoryStats, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := conn.valid(); err != nil {
+		return NodeMemoryStats{}, err
+	}
+
+	var cNParams C.int
+
+	conn.log.Printf("querying number of memory statistics for cell %v...\n", cellNum)
+	cRet := C.virNodeGetMemoryStats(conn.virConnect, C.int(cellNum), nil, &cNParams, 0)
+	ret := int32(cRet)
+
+	if ret == -1 {
+		err := LastError()
+		conn.log.Printf("an error occurred: %v\n", err)
+		return NodeMemoryStats{}, err
+	}
+
+	stats := NodeMemoryStats{
+		Extra: make(map[string]uint64),
+	}
+
+	if cNParams == 0 {
+		conn.log.Println("no memory statistics available")
+		return stats, nil
+	}
+
+	cParams := make([]C.virNodeMemoryStats, cNParams)
+
+	conn.log.Printf("reading %v memory statistics for cell %v...\n", cNParams, cellNum)
+	cRet = C.virNodeGetMemoryStats(conn.virConnect, C.int(cellNum), &cParams[0], &cNParams, 0)
+	ret = int32(cRet)
+
+	if ret == -1 {
+		err := LastError()
+		conn.log.Printf("an error occurred: %v\n", err)
+		return NodeMemoryStats{}, err
+	}
+
+	for _, cParam := range cParams[:cNParams] {
+		field := goStringFromArray(&cParam.field[0], len(cParam.field))
+		value := uint64(cParam.value)
+
+		switch field {
+		case "total":
+			stats.Total = value
+		case "free":
+			stats.Free = value
+		case "buffers":
+			stats.Buffers = value
+		case "cached":
+			stats.Cached = value
+		default:
+			stats.Extra[field] = value
+		}
+	}
+
+	conn.log.Printf("memory statistics: %+v\n", stats)
+
+	return stats, nil
+}
+
+// CPUMap provides the map of the node CPUs. "cpus" has one element per CPU
+// present on the node (including the offline ones), indexed by the CPU
+// number; each value tells whether that CPU is online. "online" is the number
+// of online CPUs.
+func (conn Connection) CPUMap() (online uint, cpus []bool, err error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := conn.valid(); err != nil {
+		return 0, nil, err
+	}
+
+	var cCPUMap *C.uchar
+	var cOnline C.uint
+
+	conn.log.Println("reading node CPU map...")
+	cRet := C.virNodeGetCPUMap(conn.virConnect, &cCPUMap, &cOnline, 0)
+	ret := int32(cRet)
+
+	if ret == -1 {
+		err = LastError()
+		conn.log.Printf("an error occurred: %v\n", err)
+		return 0, nil, err
+	}
+	defer C.free(unsafe.Pointer(cCPUMap))
+
+	cpuMap := C.GoBytes(unsafe.Pointer(cCPUMap), C.int(cpuMapLen(int(ret))))
+	cpus = cpuMapFromBytes(cpuMap, int(ret))
+
+	online = uint(cOnline)
+
+	conn.log.Printf("node CPU map: %v CPUs, %v online\n", len(cpus), online)
+
+	return online, cpus, nil
+}
+
+// FreePages provides the number of free pages of each size in "pageSizes"
+// (in kiB), for "cellCount" NUMA cells starting at "startCell". The result
+// maps each cell number to another map, which maps each page size to its
+// number of free pages.
+func (conn Connection) FreePages(pageSizes []uint64, startCell int, cellCount int) (map[int]map[uint64]uint64, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := conn.valid(); err != nil {
+		return nil, err
+	}
+
+	if len(pageSizes) == 0 {
+		conn.log.Printf("an error occurred: %v\n", ErrNoPageSizes)
+		return nil, ErrNoPageSizes
+	}
+
+	if cellCount <= 0 {
+		conn.log.Printf("an error occurred: %v\n", ErrInvalidCellCount)
+		return nil, ErrInvalidCellCount
+	}
+
+	cPageSizes := make([]C.uint, len(pageSizes))
+	for i, size := range pageSizes {
+		cPageSizes[i] = C.uint(size)
+	}
+
+	cCounts := make([]C.ulonglong, len(pageSizes)*cellCount)
+
+	conn.log.Printf("querying free pages (sizes = %v) of cells %v to %v...\n", pageSizes, startCell, startCell+cellCount-1)
+	cRet := C.virNodeGetFreePages(conn.virConnect, C.uint(len(cPageSizes)), &cPageSizes[0], C.int(startCell), C.uint(cellCount), &cCounts[0], 0)
+	ret := int32(cRet)
+
+	if ret == -1 {
+		err := LastError()
+		conn.log.Printf("an error occurred: %v\n", err)
+		return nil, err
+	}
+
+	pages := make(map[int]map[uint64]uint64)
+	for i := 0; i < int(ret); i++ {
+		cell := startCell + i/len(pageSizes)
+		size := pageSizes[i%len(pageSizes)]
+
+		if _, ok := pages[cell]; !ok {
+			pages[cell] = make(map[uint64]uint64)
+		}
+
+		pages[cell][size] = uint64(cCounts[i])
+	}
+
+	conn.log.Printf("free pages: %v\n", pages)
+
+	return pages, nil
+}
+
+// AllocPages allocates or frees pages in the huge page pool of "cellCount"
+// NUMA cells starting at "startCell". "pageCounts" maps each page size (in
+// kiB) to its number of pages. With NodeAllocPagesAdd, the page counts are
+// added to the current pool size; with NodeAllocPagesSet, they become the new
+// pool size. The number of cells which were successfully adjusted is
+// returned.
+func (conn Connection) AllocPages(pageCounts map[uint64]uint64, startCell int, cellCount int, flags NodeAllocPagesFlag) (int, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := conn.valid(); err != nil {
+		return 0, err
+	}
+
+	if len(pageCounts) == 0 {
+		conn.log.Printf("an error occurred: %v\n", ErrNoPageSizes)
+		return 0, ErrNoPageSizes
+	}
+
+	if cellCount <= 0 {
+		conn.log.Printf("an error occurred: %v\n", ErrInvalidCellCount)
+		return 0, ErrInvalidCellCount
+	}
+
+	cPageSizes := make([]C.uint, 0, len(pageCounts))
+	cPageCounts := make([]C.ulonglong, 0, len(pageCounts))
+	for size, count := range pageCounts {
+		cPageSizes = append(cPageSizes, C.uint(size))
+		cPageCounts = append(cPageCounts, C.ulonglong(count))
+	}
+
+	conn.log.Printf("allocating pages (counts = %v, flags = %v) on cells %v to %v...\n", pageCounts, flags, startCell, startCell+cellCount-1)
+	cRet := C.virNodeAllocPages(conn.virConnect, C.uint(len(cPageSizes)), &cPageSizes[0], &cPageCounts[0], C.int(startCell), C.uint(cellCount), C.uint(flags))
+	ret := int32(cRet)
+
+	if ret == -1 {
+		err := LastError()
+		conn.log.Printf("an error occurred: %v\n", err)
+		return 0, err
+	}
+
+	conn.log.Printf("pages allocated on %v cells\n", ret)
+
+	return int(ret), nil
+}
+
+// NodeMemoryParameters gets the memory parameters of the node (e.g. the
+// "shm_pages_to_scan" and "shm_sleep_millisecs" KSM tunables).
+func (conn Connection) NodeMemoryParameters() (TypedParams, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := conn.valid(); err != nil {
+		return nil, err
+	}
+
+	conn.log.Println("reading node memory parameters...")
+	params, err := readTypedParams(func(cParams *C.virTypedParameter, cNParams *C.int) C.int {
+		return C.virNodeGetMemoryParameters(conn.virConnect, cParams, cNParams, 0)
+	})
+	if err != nil {
+		conn.log.Printf("an error occurred: %v\n", err)
+		return nil, err
+	}
+
+	conn.log.Printf("node memory parameters: %v\n", params)
+
+	return params, nil
+}
+
+// SetNodeMemoryParameters changes the memory parameters of the node. Only the
+// parameters in "params" are changed. "flags" is currently unused by libvirt
+// and should be 0.
+func (conn Connection) SetNodeMemoryParameters(params TypedParams, flags uint32) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := conn.valid(); err != nil {
+		return err
+	}
+
+	cParams, cNParams, err := params.cTypedParams()
+	if err != nil {
+		conn.log.Printf("an error occurred: %v\n", err)
+		return err
+	}
+	defer freeCTypedParams(cParams, cNParams)
+
+	conn.log.Printf("changing node memory parameters to %v (flags = %v)...\n", params, flags)
+	cRet := C.virNodeSetMemoryParameters(conn.virConnect, cParams, cNParams, C.uint(flags))
+	ret := int32(cRet)
+
+	if ret == -1 {
+		err := LastError()
+		conn.log.Printf("an error occurred: %v\n", err)
+		return err
+	}
+
+	conn.log.Println("node memory parameters changed")
+
+	return nil
+}
+
+// SetIdentity overrides the identity of the client which is used by the daemon
+// to check the access to the APIs (e.g. by polkit), so a privileged client can
+// make calls on behalf of another user. The identity is described by typed
+// parameters such as "user-name" (string), "unix-user-id" (uint64),
+// "group-name" (string), "unix-group-id" (uint64), "process-id" (int64),
+// "process-time" (uint64), "sasl-user-name" (string),
+// "x509-distinguished-name" (string) and "selinux-context" (string); any other
+// parameter is passed to libvirt as is. Only privileged clients may change
+// their identity. "flags" is currently unused by libvirt and should be 0.
+func (conn Connection) SetIdentity(params TypedParams, flags uint32) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := conn.valid(); err != nil {
+		return err
+	}
+
+	cParams, cNParams, err := params.cTypedParams()
+	if err != nil {
+		conn.log.Printf("an error occurred: %v\n", err)
+		return err
+	}
+	defer freeCTypedParams(cParams, cNParams)
+
+	conn.log.Printf("changing client identity to %v (flags = %v)...\n", params, flags)
+	cRet := C.virConnectSetIdentity(conn.virConnect, cParams, cNParams, C.uint(flags))
+	ret := int32(cRet)
+
+	if ret == -1 {
+		err := LastError()
+		conn.log.Printf("an error occurred: %v\n", err)
+		return err
+	}
+
+	conn.log.Println("client identity changed")
+
+	return nil
+}
+
+// SecurityModel extracts the security model of the hypervisor. If the
+// hypervisor doesn't have a security model, ErrSecurityModelUnavailable is
+// returned.
+func (conn Connection) SecurityModel() (SecurityModel, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := conn.valid(); err != nil {
+		return SecurityModel{}, err
+	}
+
+	var cModel C.virSecurityModel
+	conn.log.Println("reading node security model...")
+	cRet := C.virNodeGetSecurityModel(conn.virConnect, &cModel)
+	ret := int32(cRet)
+
+	if ret == -1 {
+		err := LastError()
+		conn.log.Printf("an error occurred: %v\n", err)
+		return SecurityModel{}, err
+	}
+
+	model := SecurityModel{
+		Model: goStringFromArray(&cModel.model[0], len(cModel.model)),
+		DOI:   goStringFromArray(&cModel.doi[0], len(cModel.doi)),
+	}
+
+	if model.Model == "" {
+		conn.log.Printf("an error occurred: %v\n", ErrSecurityModelUnavailable)
+		return SecurityModel{}, ErrSecurityModelUnavailable
+	}
+
+	conn.log.Printf("security model: %+v\n", model)
+
+	return model, nil
+}
+
+// SetKeepAlive starts sending keepalive messages after "interval" seconds of
+// inactivity and considers the connection to be broken when no response is
+// received after "count" keepalive messages sent in a row. In other words,
+// sending count + 1 keepalive message results in closing the connection. When
+// "interval" is <= 0, no keepalive messages will be sent. When "count" is 0,
+// the connection will be automatically closed after "interval" seconds of
+// inactivity without sending any keepalive messages.
+// Keepalive messages are handled by the libvirt event loop, so an event loop
+// implementation must be registered (see EventRegisterDefaultImpl) before the
+// connection is opened, and it must be running (see EventLoop); otherwise,
+// ErrNoEventLoop is returned. After a broken connection is detected, IsAlive
+// returns false.
+// If the remote party doesn't support keepalive messages,
+// ErrKeepAliveUnsupported is returned.
+func (conn Connection) SetKeepAlive(interval int32, count uint32) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := conn.valid(); err != nil {
+		return err
+	}
+
+	if !conn.eventLoop {
+		conn.log.Printf("an error occurred: %v\n", ErrNoEventLoop)
+		return ErrNoEventLoop
+	}
+
+	conn.log.Printf("setting connection keepalive (interval = %v, count = %v)...\n", interval, count)
+	cRet := C.virConnectSetKeepAlive(conn.virConnect, C.int(interval), C.uint(count))
+	ret := int32(cRet)
+
+	if ret == -1 {
+		err := LastError()
+		conn.log.Printf("an error occurred: %v\n", err)
+		return err
+	}
+
+	if ret == 1 {
+		conn.log.Printf("an error occurred: %v\n", ErrKeepAliveUnsupported)
+		return ErrKeepAliveUnsupported
+	}
+
+	conn.log.Println("keepalive set")
+
+	return nil
+}
+
+// CPUModelNames gets the list of supported CPU models for a
+// specific architecture.
+func (conn Connection) CPUModelNames(arch string) ([]string, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := conn.valid(); err != nil {
+		return nil, err
+	}
+
+	cArch := C.CString(arch)
+	defer C.free(unsafe.Pointer(cArch))
+
+	var cModels []*C.char
+	modelsSH := (*reflect.SliceHeader)(unsafe.Pointer(&cModels))
+
+	conn.log.Printf("querying supported CPU models for %v...\n", arch)
+	cRet := C.virConnectGetCPUModelNames(conn.virConnect, cArch, (***C.char)(unsafe.Pointer(&modelsSH.Data)), 0)
+	ret := int32(cRet)
+
+	if ret == -1 {
+		err := LastError()
+		conn.log.Printf("an error occurred: %v\n", err)
+		return nil, err
+	}
+	defer C.free(unsafe.Pointer(modelsSH.Data))
+
+	modelsSH.Cap = int(ret)
+	modelsSH.Len = int(ret)
+
+	models := make([]string, ret)
+	for i := range models {
+		models[i] = C.GoString(cModels[i])
+		defer C.free(unsafe.Pointer(cModels[i]))
+	}
+
+	conn.log.Printf("CPU models count: %v\n", ret)
+
+	return models, nil
+}
+
+// CompareCPU compares the CPU described by "xml" with the host CPU. If
+// CompareCPUFailIncompatible is used and the CPUs are incompatible, an error
+// describing the incompatibility is returned instead of
+// CPUCompareIncompatible.
+func (conn Connection) CompareCPU(xml string, flags CompareCPUFlag) (CPUCompareResult, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := conn.valid(); err != nil {
+		return CPUCompareIncompatible, err
+	}
+
+	cXML := C.CString(xml)
+	defer C.free(unsafe.Pointer(cXML))
+
+	conn.log.Printf("comparing CPU (flags = %v) with the host CPU...\n", flags)
+	cRet := C.virConnectCompareCPU(conn.virConnect, cXML, C.uint(flags))
+	ret := int32(cRet)
+
+	if ret == C.VIR_CPU_COMPARE_ERROR {
+		err := LastError()
+		conn.log.Printf("an error occurred: %v\n", err)
+		return CPUCompareIncompatible, err
+	}
+
+	result := CPUCompareResult(ret)
+
+	conn.log.Printf("CPU comparison result: %v\n", result)
+
+	return result, nil
+}
+
+// BaselineCPU computes the most feature-rich CPU which is compatible with all
+// CPUs described by "xmls". The result is returned as a <cpu> XML element.
+func (conn Connection) BaselineCPU(xmls []string, flags BaselineCPUFlag) (string, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := conn.valid(); err != nil {
+		return "", err
+	}
+
+	cXMLs := make([]*C.char, len(xmls))
+	for i, xml := range xmls {
+		cXMLs[i] = C.CString(xml)
+		defer C.free(unsafe.Pointer(cXMLs[i]))
+	}
+
+	var cXMLsPtr **C.char
+	if len(cXMLs) > 0 {
+		cXMLsPtr = &cXMLs[0]
+	}
+
+	conn.log.Printf("computing baseline CPU of %v CPUs (flags = %v)...\n", len(xmls), flags)
+	cBaseline := C.virConnectBaselineCPU(conn.virConnect, cXMLsPtr, C.uint(len(cXMLs)), C.uint(flags))
+
+	if cBaseline == nil {
+		err := LastError()
+		conn.log.Printf("an error occurred: %v\n", err)
+		return "", err
+	}
+	defer C.free(unsafe.Pointer(cBaseline))
+
+	baseline := C.GoString(cBaseline)
+
+	conn.log.Printf("baseline CPU size: %v\n", utf8.RuneCountInString(baseline))
+
+	return baseline, nil
+}
+
+// BaselineHypervisorCPU computes the most feature-rich CPU which is compatible
+// with all CPUs described by "xmls" and which can be provided by the
+// hypervisor. Unlike BaselineCPU, it considers what the hypervisor is able to
+// enable. "emulator", "arch", "machine" and "virtType" describe the hypervisor
+// which will run the guests; the empty ones are picked by libvirt. The result
+// is returned as a <cpu> XML element.
+func (conn Connection) BaselineHypervisorCPU(emulator string, arch string, machine string, virtType string, xmls []string, flags BaselineCPUFlag) (string, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := conn.valid(); err != nil {
+		return "", err
+	}
+
+	cEmulator := cStringOrNil(emulator)
+	defer C.free(unsafe.Pointer(cEmulator))
+
+	cArch := cStringOrNil(arch)
+	defer C.free(unsafe.Pointer(cArch))
+
+	cMachine := cStringOrNil(machine)
+	defer C.free(unsafe.Pointer(cMachine))
+
+	cVirtType := cStringOrNil(virtType)
+	defer C.free(unsafe.Pointer(cVirtType))
+
+	cXMLs := make([]*C.char, len(xmls))
+	for i, xml := range xmls {
+		cXMLs[i] = C.CString(xml)
+		defer C.free(unsafe.Pointer(cXMLs[i]))
+	}
+
+	var cXMLsPtr **C.char
+	if len(cXMLs) > 0 {
+		cXMLsPtr = &cXMLs[0]
+	}
+
+	conn.log.Printf("computing hypervisor baseline CPU of %v CPUs (emulator = %v, arch = %v, machine = %v, virtType = %v, flags = %v)...\n", len(xmls), emulator, arch, machine, virtType, flags)
+	cBaseline := C.virConnectBaselineHypervisorCPU(conn.virConnect, cEmulator, cArch, cMachine, cVirtType, cXMLsPtr, C.uint(len(cXMLs)), C.uint(flags))
+
+	if cBaseline == nil {
+		err := LastError()
+		conn.log.Printf("an error occurred: %v\n", err)
+		return "", err
+	}
+	defer C.free(unsafe.Pointer(cBaseline))
+
+	baseline := C.GoString(cBaseline)
+
+	conn.log.Printf("hypervisor baseline CPU size: %v\n", utf8.RuneCountInString(baseline))
+
+	return baseline, nil
+}
+
+// MaxVCPUs provides the maximum number of virtual CPUs supported for a guest
+// VM of a specific type. The 'type' parameter here corresponds to the 'type'
+// attribute in the <domain> element of the XML (e.g. "kvm" or "qemu"). If it's
+// empty, the driver's default type is used.
+func (conn Connection) MaxVCPUs(typ string) (int32, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := conn.valid(); err != nil {
+		return 0, err
+	}
+
+	var cTyp *C.char
+	if typ != "" {
+		cTyp = C.CString(typ)
+		defer C.free(unsafe.Pointer(cTyp))
+
+		conn.log.Printf("querying maximum VCPUs count for %v...\n", typ)
+	} else {
+		conn.log.Println("querying maximum VCPUs count for the default type...")
+	}
+
+	cRet := C.virConnectGetMaxVcpus(conn.virConnect, cTyp)
+	ret := int32(cRet)
+
+	if ret == -1 {
+		err := LastError()
+		conn.log.Printf("an error occurred: %v\n", err)
+		return 0, err
+	}
+
+	conn.log.Printf("max VCPUs count: %v\n", ret)
+
+	return ret, nil
+}
+
+// DomainXMLFromNative converts a native hypervisor configuration (e.g. a QEMU
+// command line, with the format "qemu-argv") into a domain XML. "flags" is
+// currently unused by libvirt and should be 0.
+func (conn Connection) DomainXMLFromNative(format string, config string, flags uint32) (string, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := conn.valid(); err != nil {
+		return "", err
+	}
+
+	cFormat := C.CString(format)
+	defer C.free(unsafe.Pointer(cFormat))
+
+	cConfig := C.CString(config)
+	defer C.free(unsafe.Pointer(cConfig))
+
+	conn.log.Printf("converting native configuration (format = %v, flags = %v) into domain XML...\n", format, flags)
+	cXML := C.virConnectDomainXMLFromNative(conn.virConnect, cFormat, cConfig, C.uint(flags))
+
+	if cXML == nil {
+		err := LastError()
+		conn.log.Printf("an error occurred: %v\n", err)
+		return "", err
+	}
+	defer C.free(unsafe.Pointer(cXML))
+
+	xml := C.GoString(cXML)
+
+	conn.log.Printf("domain XML size: %v\n", utf8.RuneCountInString(xml))
+
+	return xml, nil
+}
+
+// DomainXMLToNative converts a domain XML into a native hypervisor
+// configuration (e.g. a QEMU command line, with the format "qemu-argv").
+// "flags" is currently unused by libvirt and should be 0.
+func (conn Connection) DomainXMLToNative(format string, xml string, flags uint32) (string, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := conn.valid(); err != nil {
+		return "", err
+	}
+
+	cFormat := C.CString(format)
+	defer C.free(unsafe.Pointer(cFormat))
+
+	cXML := C.CString(xml)
+	defer C.free(unsafe.Pointer(cXML))
+
+	conn.log.Printf("converting domain XML into native configuration (format = %v, flags = %v)...\n", format, flags)
+	cConfig := C.virConnectDomainXMLToNative(conn.virConnect, cFormat, cXML, C.uint(flags))
+
+	if cConfig == nil {
+		err := LastError()
+		conn.log.Printf("an error occurred: %v\n", err)
+		return "", err
+	}
+	defer C.free(unsafe.Pointer(cConfig))
+
+	config := C.GoString(cConfig)
+
+	conn.log.Printf("native configuration size: %v\n", utf8.RuneCountInString(config))
+
+	return config, nil
+}
+
+// NumOfDomains provides the number of active domains.
+func (conn Connection) NumOfDomains() (int, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := conn.valid(); err != nil {
+		return 0, err
+	}
+
+	conn.log.Println("counting active domains...")
+	cRet := C.virConnectNumOfDomains(conn.virConnect)
+	ret := int32(cRet)
+
+	if ret == -1 {
+		err := LastError()
+		conn.log.Printf("an error occurred: %v\n", err)
+		return 0, err
+	}
+
+	conn.log.Printf("active domains: %v\n", ret)
+
+	return int(ret), nil
+}
+
+// NumOfDefinedDomains provides the number of defined but inactive domains.
+func (conn Connection) NumOfDefinedDomains() (int, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := conn.valid(); err != nil {
+		return 0, err
+	}
+
+	conn.log.Println("counting inactive domains...")
+	cRet := C.virConnectNumOfDefinedDomains(conn.virConnect)
+	ret := int32(cRet)
+
+	if ret == -1 {
+		err := LastError()
+		conn.log.Printf("an error occurred: %v\n", err)
+		return 0, err
+	}
+
+	conn.log.Printf("inactive domains: %v\n", ret)
+
+	return int(ret), nil
+}
+
+// ListDomains collects a possibly-filtered list of all domains, and return an
+// array of information for each.
+func (conn Connection) ListDomains(flags DomainListFlag) ([]Domain, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := conn.valid(); err != nil {
+		return nil, err
+	}
+
+	var cDomains []C.virDomainPtr
+	domainsSH := (*reflect.SliceHeader)(unsafe.Pointer(&cDomains))
+
+	conn.log.Printf("reading domains (flags = %v)...\n", flags)
+	cRet := C.virConnectListAllDomains(conn.virConnect, (**C.virDomainPtr)(unsafe.Pointer(&domainsSH.Data)), C.uint(flags))
+	ret := int32(cRet)
+
+	if ret == -1 {
+		err := LastError()
+		conn.log.Printf("an error occurred: %v\n", err)
+		return nil, err
+	}
+	defer C.free(unsafe.Pointer(domainsSH.Data))
+
+	domainsSH.Cap = int(ret)
+	domainsSH.Len = int(ret)
+
+	domains := make([]Domain, ret)
+	for i := range domains {
+		domains[i] = Domain{
+			log:       conn.log,
+			virDomain: cDomains[i],
+		}
+	}
+
+	conn.log.Printf("domains count: %v\n", ret)
+
+	return domains, nil
+}
+
+// CreateDomain launches a new guest domain, based on an XML description
+// similar to the one returned by Domain.XML() This function may require
+// privileged access to the hypervisor. The domain is not persistent, so its
+// definition will disappear when it is destroyed, or if the host is restarted
+// (see Domain.Define() to define persistent domains). With
+// DomCreateAutodestroy, the domain is destroyed when the connection is
+// closed; the domain object keeps a reference to the connection, so the
+// connection is only closed after the domain object has been freed, too.
+func (conn Connection) CreateDomain(xml string, flags DomainCreateFlag) (Domain, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := conn.valid(); err != nil {
+		return Domain{}, err
+	}
+
+	cXML := C.CString(xml)
+	defer C.free(unsafe.Pointer(cXML))
+
+	conn.log.Printf("creating domain (flags = %v)...\n", flags)
+	cDomain := C.virDomainCreateXML(conn.virConnect, cXML, C.uint(flags))
+	if cDomain == nil {
+		err := LastError()
+		conn.log.Printf("an error occurred: %v\n", err)
+		return Domain{}, err
+	}
+
+	conn.log.Println("domain created")
+
+	dom := Domain{
+		log:       conn.log,
+		virDomain: cDomain,
+	}
+
+	return dom, nil
+}
+
+// CreateDomainWithFiles launches a new guest domain, like CreateDomain, and
+// passes "files" to its init process; the files will be available as the
+// file descriptors 3, 4, etc. in the same order. Not every driver supports
+// passing files (e.g. only the LXC driver does); in that case, an error is
+// returned.
+func (conn Connection) CreateDomainWithFiles(xml string, files []*os.File, flags DomainCreateFlag) (Domain, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := conn.valid(); err != nil {
+		return Domain{}, err
+	}
+
+	cXML := C.CString(xml)
+	defer C.free(unsafe.Pointer(cXML))
+
+	cFDs := cFileDescriptors(files)
+
+	var cFDsPtr *C.int
+	if len(cFDs) > 0 {
+		cFDsPtr = &cFDs[0]
+	}
+
+	conn.log.Printf("creating domain with %v files (flags = %v)...\n", len(files), flags)
+	cDomain := C.virDomainCreateXMLWithFiles(conn.virConnect, cXML, C.uint(len(cFDs)), cFDsPtr, C.uint(flags))
+
+	// the files must not be closed by the garbage collector before libvirt
+	// has used their descriptors
+	runtime.KeepAlive(files)
+
+	if cDomain == nil {
+		err := LastError()
+		conn.log.Printf("an error occurred: %v\n", err)
+		return Domain{}, err
+	}
+
+	conn.log.Println("domain created")
+
+	dom := Domain{
 		log:       conn.log,
 		virDomain: cDomain,
 	}
@@ -483,6 +1709,13 @@ func (conn Connection) CreateDomain(xml string, flags DomainCreateFlag) (Domain,
 // persistent, until explicitly undefined with Domain.Undefine(). A previous
 // definition for this domain would be overridden if it already exists.
 func (conn Connection) DefineDomain(xml string) (Domain, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := conn.valid(); err != nil {
+		return Domain{}, err
+	}
+
 	cXML := C.CString(xml)
 	defer C.free(unsafe.Pointer(cXML))
 
@@ -504,10 +1737,51 @@ func (conn Connection) DefineDomain(xml string) (Domain, error) {
 	return dom, nil
 }
 
+// DefineDomainFlags defines a domain like DefineDomain. With
+// DomDefineValidate, the XML is validated against the schema, and the returned
+// error describes the offending elements.
+func (conn Connection) DefineDomainFlags(xml string, flags DomainDefineFlag) (Domain, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := conn.valid(); err != nil {
+		return Domain{}, err
+	}
+
+	cXML := C.CString(xml)
+	defer C.free(unsafe.Pointer(cXML))
+
+	conn.log.Printf("defining domain (flags = %v)...\n", flags)
+	cDomain := C.virDomainDefineXMLFlags(conn.virConnect, cXML, C.uint(flags))
+	if cDomain == nil {
+		err := LastError()
+		conn.log.Printf("an error occurred: %v\n", err)
+		return Domain{}, err
+	}
+
+	conn.log.Println("domain defined")
+
+	dom := Domain{
+		log:       conn.log,
+		virDomain: cDomain,
+	}
+
+	return dom, nil
+}
+
 // LookupDomainByID tries to find a domain based on the hypervisor ID number.
 // Note that this won't work for inactive domains which have an ID of -1, in
-// that case a lookup based on the Name or UUID need to be done instead.
+// that case a lookup based on the Name or UUID need to be done instead. If
+// there's no running domain with that ID, the returned error has the code
+// ErrNoDomain.
 func (conn Connection) LookupDomainByID(id uint32) (Domain, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := conn.valid(); err != nil {
+		return Domain{}, err
+	}
+
 	conn.log.Printf("looking up domain with ID = %v...\n", id)
 	cDomain := C.virDomainLookupByID(conn.virConnect, C.int(id))
 	if cDomain == nil {
@@ -527,8 +1801,17 @@ func (conn Connection) LookupDomainByID(id uint32) (Domain, error) {
 }
 
 // LookupDomainByName tries to lookup a domain on the given hypervisor based on
-// its name.
+// its name. If there's no such domain, the returned error has the code
+// ErrNoDomain. "Free" should be used to free the resources after the domain
+// object is no longer needed.
 func (conn Connection) LookupDomainByName(name string) (Domain, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := conn.valid(); err != nil {
+		return Domain{}, err
+	}
+
 	cName := C.CString(name)
 	defer C.free(unsafe.Pointer(cName))
 
@@ -550,45 +1833,182 @@ func (conn Connection) LookupDomainByName(name string) (Domain, error) {
 	return dom, nil
 }
 
-// LookupDomainByUUID tries to lookup a domain on the given hypervisor based on
-// its UUID.
-func (conn Connection) LookupDomainByUUID(uuid string) (Domain, error) {
-	cUUID := C.CString(uuid)
-	defer C.free(unsafe.Pointer(cUUID))
+// LookupDomainByUUIDString tries to lookup a domain on the given hypervisor
+// based on its UUID, either in the canonical format (with dashes) or as 32
+// hexadecimal digits. If the UUID is invalid, ErrInvalidUUID is returned.
+func (conn Connection) LookupDomainByUUIDString(uuid string) (Domain, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := conn.valid(); err != nil {
+		return Domain{}, err
+	}
+
+	if _, err := parseUUID(uuid); err != nil {
+		conn.log.Printf("an error occurred: %v\n", err)
+		return Domain{}, err
+	}
+
+	cUUID := C.CString(uuid)
+	defer C.free(unsafe.Pointer(cUUID))
+
+	conn.log.Printf("looking up domain with UUID = %v...\n", uuid)
+	cDomain := C.virDomainLookupByUUIDString(conn.virConnect, cUUID)
+	if cDomain == nil {
+		err := LastError()
+		conn.log.Printf("an error occurred: %v\n", err)
+		return Domain{}, err
+	}
+
+	conn.log.Println("domain found")
+
+	dom := Domain{
+		log:       conn.log,
+		virDomain: cDomain,
+	}
+
+	return dom, nil
+}
+
+// LookupDomainByUUID tries to lookup a domain on the given hypervisor based on
+// its raw UUID.
+func (conn Connection) LookupDomainByUUID(uuid [16]byte) (Domain, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := conn.valid(); err != nil {
+		return Domain{}, err
+	}
+
+	conn.log.Printf("looking up domain with UUID = %x...\n", uuid)
+	cDomain := C.virDomainLookupByUUID(conn.virConnect, (*C.uchar)(unsafe.Pointer(&uuid[0])))
+	if cDomain == nil {
+		err := LastError()
+		conn.log.Printf("an error occurred: %v\n", err)
+		return Domain{}, err
+	}
+
+	conn.log.Println("domain found")
+
+	dom := Domain{
+		log:       conn.log,
+		virDomain: cDomain,
+	}
+
+	return dom, nil
+}
+
+// RestoreDomain restores a domain saved to disk by Save().
+func (conn Connection) RestoreDomain(from string) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := conn.valid(); err != nil {
+		return err
+	}
+
+	cFrom := C.CString(from)
+	defer C.free(unsafe.Pointer(cFrom))
+
+	conn.log.Printf("restoring domain from file %v...\n", from)
+	cRet := C.virDomainRestore(conn.virConnect, cFrom)
+	ret := int32(cRet)
+
+	if ret == -1 {
+		err := LastError()
+		conn.log.Printf("an error occurred: %v\n", err)
+		return err
+	}
+
+	conn.log.Println("domain restored")
+
+	return nil
+}
+
+// RestoreDomainFlags restores a domain saved to disk by Save(). If "xml"
+// isn't empty, it replaces the domain XML stored in the saved file; only
+// host-specific details, such as disk paths, may be changed. The flags
+// DomSaveRunning and DomSavePaused override the state in which the domain is
+// restored, and DomSaveBypassCache avoids the file system cache.
+func (conn Connection) RestoreDomainFlags(from string, xml string, flags DomainSaveFlag) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := conn.valid(); err != nil {
+		return err
+	}
+
+	cFrom := C.CString(from)
+	defer C.free(unsafe.Pointer(cFrom))
+
+	cXML := cStringOrNil(xml)
+	defer C.free(unsafe.Pointer(cXML))
+
+	conn.log.Printf("restoring domain from file %v (flags = %v)...\n", from, flags)
+	cRet := C.virDomainRestoreFlags(conn.virConnect, cFrom, cXML, C.uint(flags))
+	ret := int32(cRet)
+
+	if ret == -1 {
+		err := LastError()
+		conn.log.Printf("an error occurred: %v\n", err)
+		return err
+	}
+
+	conn.log.Println("domain restored")
+
+	return nil
+}
+
+// SaveImageXML provides an XML description of the domain saved to disk by
+// Save(). The only flag which may be used is DomXMLSecure.
+func (conn Connection) SaveImageXML(file string, flags DomainXMLFlag) (string, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := conn.valid(); err != nil {
+		return "", err
+	}
+
+	cFile := C.CString(file)
+	defer C.free(unsafe.Pointer(cFile))
 
-	conn.log.Printf("looking up domain with UUID = %v...\n", uuid)
-	cDomain := C.virDomainLookupByUUIDString(conn.virConnect, cUUID)
-	if cDomain == nil {
+	conn.log.Printf("reading XML of domain saved to file %v (flags = %v)...\n", file, flags)
+	cXML := C.virDomainSaveImageGetXMLDesc(conn.virConnect, cFile, C.uint(flags))
+
+	if cXML == nil {
 		err := LastError()
 		conn.log.Printf("an error occurred: %v\n", err)
-		return Domain{}, err
+		return "", err
 	}
+	defer C.free(unsafe.Pointer(cXML))
 
-	conn.log.Println("domain found")
+	xml := C.GoString(cXML)
 
-	dom := Domain{
-		log:       conn.log,
-		virDomain: cDomain,
-	}
+	conn.log.Printf("XML length: %v runes\n", utf8.RuneCountInString(xml))
 
-	return dom, nil
+	return xml, nil
 }
 
-// RestoreDomain restores a domain saved to disk by Save().
-func (conn Connection) RestoreDomain(from string, xml string, flags DomainSaveFlag) error {
-	cFrom := C.CString(from)
-	defer C.free(unsafe.Pointer(cFrom))
+// DefineSaveImageXML replaces the domain XML stored in a file saved by Save().
+// Only host-specific details, such as disk paths, may be changed. The flags
+// DomSaveRunning and DomSavePaused change the state in which the domain will
+// be restored.
+func (conn Connection) DefineSaveImageXML(file string, xml string, flags DomainSaveFlag) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
 
-	var cXML *C.char
-	if xml != "" {
-		cXML = C.CString(xml)
-		defer C.free(unsafe.Pointer(cXML))
-	} else {
-		cXML = nil
+	if err := conn.valid(); err != nil {
+		return err
 	}
 
-	conn.log.Printf("restoring domain from file %v (flags = %v)...\n", from, flags)
-	cRet := C.virDomainRestoreFlags(conn.virConnect, cFrom, cXML, C.uint(flags))
+	cFile := C.CString(file)
+	defer C.free(unsafe.Pointer(cFile))
+
+	cXML := C.CString(xml)
+	defer C.free(unsafe.Pointer(cXML))
+
+	conn.log.Printf("updating XML of domain saved to file %v (length = %v, flags = %v)...\n", file, utf8.RuneCountInString(xml), flags)
+	cRet := C.virDomainSaveImageDefineXML(conn.virConnect, cFile, cXML, C.uint(flags))
 	ret := int32(cRet)
 
 	if ret == -1 {
@@ -597,7 +2017,7 @@ func (conn Connection) RestoreDomain(from string, xml string, flags DomainSaveFl
 		return err
 	}
 
-	conn.log.Println("domain restored")
+	conn.log.Println("saved domain XML updated")
 
 	return nil
 }
@@ -616,6 +2036,13 @@ func (conn Connection) RestoreDomain(from string, xml string, flags DomainSaveFl
 // libvirt nor to any other node. Flag SecListNoPrivate selects
 // non-private secrets.
 func (conn Connection) ListSecrets(flags SecretListFlag) ([]Secret, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := conn.valid(); err != nil {
+		return nil, err
+	}
+
 	var cSecrets []C.virSecretPtr
 	secretsSH := (*reflect.SliceHeader)(unsafe.Pointer(&cSecrets))
 
@@ -654,6 +2081,13 @@ func (conn Connection) ListSecrets(flags SecretListFlag) ([]Secret, error) {
 // "Free" should be used to free the resources after the secret object is no
 // longer needed.
 func (conn Connection) DefineSecret(xml string) (Secret, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := conn.valid(); err != nil {
+		return Secret{}, err
+	}
+
 	cXML := C.CString(xml)
 	defer C.free(unsafe.Pointer(cXML))
 
@@ -681,6 +2115,13 @@ func (conn Connection) DefineSecret(xml string) (Secret, error) {
 // "Free" should be used to free the resources after the secret object is no
 // longer needed.
 func (conn Connection) LookupSecretByUUID(uuid string) (Secret, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := conn.valid(); err != nil {
+		return Secret{}, err
+	}
+
 	cUUID := C.CString(uuid)
 	defer C.free(unsafe.Pointer(cUUID))
 
@@ -705,6 +2146,13 @@ func (conn Connection) LookupSecretByUUID(uuid string) (Secret, error) {
 // "Free" should be used to free the resources after the secret object is no
 // longer needed.
 func (conn Connection) LookupSecretByUsage(usageType SecretUsageType, usageID string) (Secret, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := conn.valid(); err != nil {
+		return Secret{}, err
+	}
+
 	cUsageType := C.int(usageType)
 	cUsageID := C.CString(usageID)
 	defer C.free(unsafe.Pointer(cUsageID))
@@ -727,16 +2175,22 @@ func (conn Connection) LookupSecretByUsage(usageType SecretUsageType, usageID st
 // FindStoragePoolSources talks to a storage backend and attempts to
 // auto-discover the set of available storage pool sources. e.g. For iSCSI this
 // would be a set of iSCSI targets. For NFS this would be a list of exported
-// paths. The "source" (optional for some storage pool types, e.g. local ones)
-// is an instance of the storage pool's source element specifying where to look
-// for the pools.
-// "source" is not required for some types (e.g., those querying local storage
-// resources only)
+// paths. The "source" is an instance of the storage pool's source element
+// specifying where to look for the pools; it is not required for some types
+// (e.g. those querying local storage resources only), in which case it may be
+// empty.
 func (conn Connection) FindStoragePoolSources(typ string, source string) (string, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := conn.valid(); err != nil {
+		return "", err
+	}
+
 	cType := C.CString(typ)
 	defer C.free(unsafe.Pointer(cType))
 
-	cSource := C.CString(source)
+	cSource := cStringOrNil(source)
 	defer C.free(unsafe.Pointer(cSource))
 
 	conn.log.Printf("finding storage pool sources (type = %v)...\n", typ)
@@ -755,6 +2209,54 @@ func (conn Connection) FindStoragePoolSources(typ string, source string) (string
 	return sources, nil
 }
 
+// NumOfStoragePools provides the number of active storage pools.
+func (conn Connection) NumOfStoragePools() (int, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := conn.valid(); err != nil {
+		return 0, err
+	}
+
+	conn.log.Println("counting active storage pools...")
+	cRet := C.virConnectNumOfStoragePools(conn.virConnect)
+	ret := int32(cRet)
+
+	if ret == -1 {
+		err := LastError()
+		conn.log.Printf("an error occurred: %v\n", err)
+		return 0, err
+	}
+
+	conn.log.Printf("active storage pools: %v\n", ret)
+
+	return int(ret), nil
+}
+
+// NumOfDefinedStoragePools provides the number of defined but inactive storage pools.
+func (conn Connection) NumOfDefinedStoragePools() (int, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := conn.valid(); err != nil {
+		return 0, err
+	}
+
+	conn.log.Println("counting inactive storage pools...")
+	cRet := C.virConnectNumOfDefinedStoragePools(conn.virConnect)
+	ret := int32(cRet)
+
+	if ret == -1 {
+		err := LastError()
+		conn.log.Printf("an error occurred: %v\n", err)
+		return 0, err
+	}
+
+	conn.log.Printf("inactive storage pools: %v\n", ret)
+
+	return int(ret), nil
+}
+
 // ListStoragePools collects the list of storage pools, and allocates an array
 // to store those objects.
 // Normally, all storage pools are returned; however, "flags" can be used to
@@ -774,6 +2276,13 @@ func (conn Connection) FindStoragePoolSources(typ string, source string) (string
 // PoolListDisk, PoolListISCSI, PoolListSCSI, PoolListMPath, PoolListRBD,
 // PoolListSheepdog.
 func (conn Connection) ListStoragePools(flags StoragePoolListFlag) ([]StoragePool, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := conn.valid(); err != nil {
+		return nil, err
+	}
+
 	var cStoragePools []C.virStoragePoolPtr
 	cStoragePoolsSH := (*reflect.SliceHeader)(unsafe.Pointer(&cStoragePools))
 
@@ -809,6 +2318,13 @@ func (conn Connection) ListStoragePools(flags StoragePoolListFlag) ([]StoragePoo
 // "Free" should be used to free the resources after the storage pool object is
 // no longer needed.
 func (conn Connection) DefineStoragePool(xml string) (StoragePool, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := conn.valid(); err != nil {
+		return StoragePool{}, err
+	}
+
 	cXML := C.CString(xml)
 	defer C.free(unsafe.Pointer(cXML))
 
@@ -837,6 +2353,13 @@ func (conn Connection) DefineStoragePool(xml string) (StoragePool, error) {
 // "Free" should be used to free the resources after the storage pool object is
 // no longer needed.
 func (conn Connection) CreateStoragePool(xml string) (StoragePool, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := conn.valid(); err != nil {
+		return StoragePool{}, err
+	}
+
 	cXML := C.CString(xml)
 	defer C.free(unsafe.Pointer(cXML))
 
@@ -863,6 +2386,13 @@ func (conn Connection) CreateStoragePool(xml string) (StoragePool, error) {
 // "Free" should be used to free the resources after the storage pool object is
 // no longer needed.
 func (conn Connection) LookupStoragePoolByName(name string) (StoragePool, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := conn.valid(); err != nil {
+		return StoragePool{}, err
+	}
+
 	cName := C.CString(name)
 	defer C.free(unsafe.Pointer(cName))
 
@@ -890,6 +2420,13 @@ func (conn Connection) LookupStoragePoolByName(name string) (StoragePool, error)
 // "Free" should be used to free the resources after the storage pool object is
 // no longer needed.
 func (conn Connection) LookupStoragePoolByUUID(uuid string) (StoragePool, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := conn.valid(); err != nil {
+		return StoragePool{}, err
+	}
+
 	cUUID := C.CString(uuid)
 	defer C.free(unsafe.Pointer(cUUID))
 
@@ -917,6 +2454,13 @@ func (conn Connection) LookupStoragePoolByUUID(uuid string) (StoragePool, error)
 //"Free" should be used to free the resources after the storage volume object is
 // no longer needed.
 func (conn Connection) LookupStorageVolumeByPath(path string) (StorageVolume, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := conn.valid(); err != nil {
+		return StorageVolume{}, err
+	}
+
 	cPath := C.CString(path)
 	defer C.free(unsafe.Pointer(cPath))
 
@@ -944,6 +2488,13 @@ func (conn Connection) LookupStorageVolumeByPath(path string) (StorageVolume, er
 // "Free" should be used to free the resources after the storage volume object
 // is no longer needed.
 func (conn Connection) LookupStorageVolumeByKey(key string) (StorageVolume, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := conn.valid(); err != nil {
+		return StorageVolume{}, err
+	}
+
 	cKey := C.CString(key)
 	defer C.free(unsafe.Pointer(cKey))
 
@@ -974,6 +2525,13 @@ func (conn Connection) LookupStorageVolumeByKey(key string) (StorageVolume, erro
 // If a non-blocking data stream is required passed StrNonBlock for flags,
 // otherwise pass StrDefault.
 func (conn Connection) NewStream(flags StreamFlag) (Stream, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := conn.valid(); err != nil {
+		return Stream{}, err
+	}
+
 	conn.log.Printf("creating stream (flags = %v)...\n", flags)
 	cStream := C.virStreamNew(conn.virConnect, C.uint(flags))
 
@@ -1003,6 +2561,13 @@ func (conn Connection) NewStream(flags StreamFlag) (Stream, error) {
 // The only group of "flags" is IfaceListActive (up) and
 // IfaceListInactive (down) to filter the interfaces by state.
 func (conn Connection) ListInterfaces(flags InterfaceListFlag) ([]Interface, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := conn.valid(); err != nil {
+		return nil, err
+	}
+
 	var cInterfaces []C.virInterfacePtr
 	cInterfacesSH := (*reflect.SliceHeader)(unsafe.Pointer(&cInterfaces))
 
@@ -1032,3 +2597,277 @@ func (conn Connection) ListInterfaces(flags InterfaceListFlag) ([]Interface, err
 
 	return interfaces, nil
 }
+
+// NumOfNetworks provides the number of active networks.
+func (conn Connection) NumOfNetworks() (int, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := conn.valid(); err != nil {
+		return 0, err
+	}
+
+	conn.log.Println("counting active networks...")
+	cRet := C.virConnectNumOfNetworks(conn.virConnect)
+	ret := int32(cRet)
+
+	if ret == -1 {
+		err := LastError()
+		conn.log.Printf("an error occurred: %v\n", err)
+		return 0, err
+	}
+
+	conn.log.Printf("active networks: %v\n", ret)
+
+	return int(ret), nil
+}
+
+// NumOfDefinedNetworks provides the number of defined but inactive networks.
+func (conn Connection) NumOfDefinedNetworks() (int, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := conn.valid(); err != nil {
+		return 0, err
+	}
+
+	conn.log.Println("counting inactive networks...")
+	cRet := C.virConnectNumOfDefinedNetworks(conn.virConnect)
+	ret := int32(cRet)
+
+	if ret == -1 {
+		err := LastError()
+		conn.log.Printf("an error occurred: %v\n", err)
+		return 0, err
+	}
+
+	conn.log.Printf("inactive networks: %v\n", ret)
+
+	return int(ret), nil
+}
+
+// ListNetworks collects the list of networks, and allocates an array to store
+// those objects.
+// Normally, all networks are returned; however, "flags" can be used to filter
+// the results for a smaller list of targeted networks.
+func (conn Connection) ListNetworks(flags NetworkListFlag) ([]Network, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := conn.valid(); err != nil {
+		return nil, err
+	}
+
+	var cNetworks []C.virNetworkPtr
+	networksSH := (*reflect.SliceHeader)(unsafe.Pointer(&cNetworks))
+
+	conn.log.Printf("reading networks (flags = %v)...\n", flags)
+	cRet := C.virConnectListAllNetworks(conn.virConnect, (**C.virNetworkPtr)(unsafe.Pointer(&networksSH.Data)), C.uint(flags))
+	ret := int32(cRet)
+
+	if ret == -1 {
+		err := LastError()
+		conn.log.Printf("an error occurred: %v\n", err)
+		return nil, err
+	}
+	defer C.free(unsafe.Pointer(networksSH.Data))
+
+	networksSH.Cap = int(ret)
+	networksSH.Len = int(ret)
+
+	networks := make([]Network, ret)
+	for i := range networks {
+		networks[i] = Network{
+			log:        conn.log,
+			virNetwork: cNetworks[i],
+		}
+	}
+
+	conn.log.Printf("networks count: %v\n", ret)
+
+	return networks, nil
+}
+
+// DefineNetwork defines a network, but does not start it.
+// "Free" should be used to free the resources after the network object is no
+// longer needed.
+func (conn Connection) DefineNetwork(xml string) (Network, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := conn.valid(); err != nil {
+		return Network{}, err
+	}
+
+	cXML := C.CString(xml)
+	defer C.free(unsafe.Pointer(cXML))
+
+	conn.log.Printf("defining network (XML length = %v)...\n", utf8.RuneCountInString(xml))
+	cNet := C.virNetworkDefineXML(conn.virConnect, cXML)
+
+	if cNet == nil {
+		err := LastError()
+		conn.log.Printf("an error occurred: %v\n", err)
+		return Network{}, err
+	}
+
+	conn.log.Println("network defined")
+
+	net := Network{
+		log:        conn.log,
+		virNetwork: cNet,
+	}
+
+	return net, nil
+}
+
+// LookupNetworkByName tries to lookup a network on the given hypervisor based
+// on its name.
+// "Free" should be used to free the resources after the network object is no
+// longer needed.
+func (conn Connection) LookupNetworkByName(name string) (Network, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := conn.valid(); err != nil {
+		return Network{}, err
+	}
+
+	cName := C.CString(name)
+	defer C.free(unsafe.Pointer(cName))
+
+	conn.log.Printf("looking up network with name = %v...\n", name)
+	cNet := C.virNetworkLookupByName(conn.virConnect, cName)
+
+	if cNet == nil {
+		err := LastError()
+		conn.log.Printf("an error occurred: %v\n", err)
+		return Network{}, err
+	}
+
+	conn.log.Println("network found")
+
+	net := Network{
+		log:        conn.log,
+		virNetwork: cNet,
+	}
+
+	return net, nil
+}
+
+// ListNWFilters collects the list of network filters, and allocates an array
+// to store those objects.
+func (conn Connection) ListNWFilters() ([]NWFilter, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := conn.valid(); err != nil {
+		return nil, err
+	}
+
+	var cFilters []C.virNWFilterPtr
+	filtersSH := (*reflect.SliceHeader)(unsafe.Pointer(&cFilters))
+
+	conn.log.Println("reading network filters...")
+	cRet := C.virConnectListAllNWFilters(conn.virConnect, (**C.virNWFilterPtr)(unsafe.Pointer(&filtersSH.Data)), 0)
+	ret := int32(cRet)
+
+	if ret == -1 {
+		err := LastError()
+		conn.log.Printf("an error occurred: %v\n", err)
+		return nil, err
+	}
+	defer C.free(unsafe.Pointer(filtersSH.Data))
+
+	filtersSH.Cap = int(ret)
+	filtersSH.Len = int(ret)
+
+	filters := make([]NWFilter, ret)
+	for i := range filters {
+		filters[i] = NWFilter{
+			log:         conn.log,
+			virNWFilter: cFilters[i],
+		}
+	}
+
+	conn.log.Printf("network filters count: %v\n", ret)
+
+	return filters, nil
+}
+
+// LookupNWFilterByName tries to lookup a network filter on the given
+// hypervisor based on its name.
+// "Free" should be used to free the resources after the network filter object
+// is no longer needed.
+func (conn Connection) LookupNWFilterByName(name string) (NWFilter, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := conn.valid(); err != nil {
+		return NWFilter{}, err
+	}
+
+	cName := C.CString(name)
+	defer C.free(unsafe.Pointer(cName))
+
+	conn.log.Printf("looking up network filter with name = %v...\n", name)
+	cFilter := C.virNWFilterLookupByName(conn.virConnect, cName)
+
+	if cFilter == nil {
+		err := LastError()
+		conn.log.Printf("an error occurred: %v\n", err)
+		return NWFilter{}, err
+	}
+
+	conn.log.Println("network filter found")
+
+	filter := NWFilter{
+		log:         conn.log,
+		virNWFilter: cFilter,
+	}
+
+	return filter, nil
+}
+
+// ListNodeDevices collects the list of node devices, and allocates an array to
+// store those objects.
+// Normally, all node devices are returned; however, "flags" can be used to
+// filter the results for a smaller list of targeted node devices, according
+// to their capabilities.
+func (conn Connection) ListNodeDevices(flags NodeDeviceListFlag) ([]NodeDevice, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := conn.valid(); err != nil {
+		return nil, err
+	}
+
+	var cDevices []C.virNodeDevicePtr
+	devicesSH := (*reflect.SliceHeader)(unsafe.Pointer(&cDevices))
+
+	conn.log.Printf("reading node devices (flags = %v)...\n", flags)
+	cRet := C.virConnectListAllNodeDevices(conn.virConnect, (**C.virNodeDevicePtr)(unsafe.Pointer(&devicesSH.Data)), C.uint(flags))
+	ret := int32(cRet)
+
+	if ret == -1 {
+		err := LastError()
+		conn.log.Printf("an error occurred: %v\n", err)
+		return nil, err
+	}
+	defer C.free(unsafe.Pointer(devicesSH.Data))
+
+	devicesSH.Cap = int(ret)
+	devicesSH.Len = int(ret)
+
+	devices := make([]NodeDevice, ret)
+	for i := range devices {
+		devices[i] = NodeDevice{
+			log:           conn.log,
+			virNodeDevice: cDevices[i],
+		}
+	}
+
+	conn.log.Printf("node devices count: %v\n", ret)
+
+	return devices, nil
+}
@@ -2,9 +2,14 @@ package libvirt
 
 import (
 	"bytes"
+	"encoding/json"
+	"errors"
 	"os"
+	"reflect"
+	"strings"
 	"testing"
 
+	"code.google.com/p/go-uuid/uuid"
 	"github.com/cd1/utils-golang"
 )
 
@@ -45,14 +50,16 @@ func TestConnectionOpenDefault(t *testing.T) {
 }
 
 func TestConnectionRef(t *testing.T) {
-	env := newTestEnvironment(t)
-	defer env.cleanUp()
+	conn, err := Open(testConnectionURI, ReadWrite, testLogOutput)
+	if err != nil {
+		t.Fatal(err)
+	}
 
-	if err := env.conn.Ref(); err != nil {
+	if err = conn.Ref(); err != nil {
 		t.Fatal(err)
 	}
 
-	ref, err := env.conn.Close()
+	ref, err := conn.Close()
 	if err != nil {
 		t.Error(err)
 	}
@@ -60,6 +67,309 @@ func TestConnectionRef(t *testing.T) {
 	if ref != 1 {
 		t.Errorf("unexpected connection reference count after closing connection for the first time; got=%v, want=1", ref)
 	}
+
+	ref, err = conn.Close()
+	if err != nil {
+		t.Error(err)
+	}
+
+	if ref != 0 {
+		t.Errorf("unexpected connection reference count after closing connection for the second time; got=%v, want=0", ref)
+	}
+}
+
+func TestConnectionNodeInfo(t *testing.T) {
+	env := newTestEnvironment(t)
+	defer env.cleanUp()
+
+	info, err := env.conn.NodeInfo()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.CPUs == 0 {
+		t.Error("the node should have at least one CPU")
+	}
+
+	if info.Memory == 0 {
+		t.Error("the node memory should not be zero")
+	}
+
+	if len(info.Model) == 0 {
+		t.Error("the node CPU model should not be empty")
+	}
+}
+
+func TestConnectionFreeMemory(t *testing.T) {
+	env := newTestEnvironment(t)
+	defer env.cleanUp()
+
+	memory, err := env.conn.FreeMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if memory == 0 {
+		t.Error("the node free memory should not be zero")
+	}
+}
+
+func TestConnectionCellsFreeMemory(t *testing.T) {
+	env := newTestEnvironment(t)
+	defer env.cleanUp()
+
+	if _, err := env.conn.CellsFreeMemory(0, 0); err != ErrInvalidCellCount {
+		t.Errorf("unexpected error when reading zero cells; got=%v, want=%v", err, ErrInvalidCellCount)
+	}
+
+	info, err := env.conn.NodeInfo()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for cell := 0; cell < int(info.Nodes); cell++ {
+		memories, err := env.conn.CellsFreeMemory(cell, 1)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		if len(memories) != 1 {
+			t.Errorf("unexpected number of cells read; got=%v, want=1", len(memories))
+		}
+	}
+
+	memories, err := env.conn.CellsFreeMemory(0, int(info.Nodes))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(memories) != int(info.Nodes) {
+		t.Errorf("unexpected number of cells read; got=%v, want=%v", len(memories), info.Nodes)
+	}
+
+	if _, err := env.conn.CellsFreeMemory(int(info.Nodes)+1, 1); err == nil {
+		t.Error("an error was not returned when reading an out of range cell")
+	}
+}
+
+func TestConnectionCPUStats(t *testing.T) {
+	env := newTestEnvironment(t)
+	defer env.cleanUp()
+
+	stats, err := env.conn.CPUStats(NodeCPUStatsAllCPUs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stats.Kernel == 0 {
+		t.Error("the node CPU kernel time should not be zero")
+	}
+
+	if stats.User == 0 {
+		t.Error("the node CPU user time should not be zero")
+	}
+
+	if stats.Idle == 0 {
+		t.Error("the node CPU idle time should not be zero")
+	}
+
+	if _, err := env.conn.CPUStats(0); err != nil {
+		t.Error(err)
+	}
+
+	info, err := env.conn.NodeInfo()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := env.conn.CPUStats(int(info.CPUs) + 1024); err == nil {
+		t.Error("an error was not returned when reading the statistics of an out of range CPU")
+	}
+}
+
+func TestConnectionMemoryStats(t *testing.T) {
+	env := newTestEnvironment(t)
+	defer env.cleanUp()
+
+	stats, err := env.conn.MemoryStats(NodeMemoryStatsAllCells)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stats.Total == 0 {
+		t.Error("the node total memory should not be zero")
+	}
+
+	if stats.Total < stats.Free {
+		t.Errorf("the node free memory is greater than the total memory; total=%v, free=%v", stats.Total, stats.Free)
+	}
+
+	if _, err := json.Marshal(stats); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestConnectionCPUMap(t *testing.T) {
+	env := newTestEnvironment(t)
+	defer env.cleanUp()
+
+	online, cpus, err := env.conn.CPUMap()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if online == 0 {
+		t.Error("the node should have at least one online CPU")
+	}
+
+	if online > uint(len(cpus)) {
+		t.Errorf("the number of online CPUs is greater than the number of CPUs; online=%v, total=%v", online, len(cpus))
+	}
+
+	var count uint
+	for _, cpu := range cpus {
+		if cpu {
+			count++
+		}
+	}
+
+	if count != online {
+		t.Errorf("unexpected number of online CPUs in the map; got=%v, want=%v", count, online)
+	}
+}
+
+func TestConnectionFreePages(t *testing.T) {
+	env := newTestEnvironment(t)
+	defer env.cleanUp()
+
+	if _, err := env.conn.FreePages(nil, 0, 1); err != ErrNoPageSizes {
+		t.Errorf("unexpected error when reading no page sizes; got=%v, want=%v", err, ErrNoPageSizes)
+	}
+
+	if _, err := env.conn.FreePages([]uint64{4}, 0, 0); err != ErrInvalidCellCount {
+		t.Errorf("unexpected error when reading zero cells; got=%v, want=%v", err, ErrInvalidCellCount)
+	}
+
+	pages, err := env.conn.FreePages([]uint64{4}, 0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := pages[0][4]; !ok {
+		t.Errorf("the number of free 4 kiB pages on cell 0 was not returned; got=%v", pages)
+	}
+}
+
+func TestConnectionAllocPages(t *testing.T) {
+	env := newTestEnvironment(t)
+	defer env.cleanUp()
+
+	if _, err := env.conn.AllocPages(nil, 0, 1, NodeAllocPagesAdd); err != ErrNoPageSizes {
+		t.Errorf("unexpected error when allocating no page sizes; got=%v, want=%v", err, ErrNoPageSizes)
+	}
+
+	if _, err := env.conn.AllocPages(map[uint64]uint64{2048: 1}, 0, 0, NodeAllocPagesAdd); err != ErrInvalidCellCount {
+		t.Errorf("unexpected error when allocating on zero cells; got=%v, want=%v", err, ErrInvalidCellCount)
+	}
+
+	// the session connection doesn't have the privileges to change the
+	// huge page pool
+	if _, err := env.conn.AllocPages(map[uint64]uint64{2048: 1}, 0, 1, NodeAllocPagesAdd); err == nil {
+		t.Error("an error was not returned when allocating pages without privileges")
+	}
+}
+
+func TestConnectionNodeMemoryParameters(t *testing.T) {
+	env := newTestEnvironment(t)
+	defer env.cleanUp()
+
+	if _, err := env.conn.NodeMemoryParameters(); err != nil {
+		t.Error(err)
+	}
+
+	if err := env.conn.SetNodeMemoryParameters(TypedParams{"shm_pages_to_scan": 1}, 0); err == nil {
+		t.Error("an error was not returned when setting a parameter with an unsupported type")
+	}
+
+	// the session connection doesn't have the privileges to change the KSM
+	// tunables
+	if err := env.conn.SetNodeMemoryParameters(TypedParams{"shm_pages_to_scan": uint32(100)}, 0); err == nil {
+		t.Error("an error was not returned when setting the node memory parameters without privileges")
+	}
+}
+
+func TestConnectionSetIdentity(t *testing.T) {
+	env := newTestEnvironment(t)
+	defer env.cleanUp()
+
+	identity := TypedParams{
+		"user-name":       "nobody",
+		"unix-user-id":    uint64(65534),
+		"process-id":      int64(os.Getpid()),
+		"selinux-context": "system_u:system_r:svirt_t:s0",
+		"foo-bar":         "unknown parameters are passed as is",
+	}
+
+	cParams, cNParams, err := identity.cTypedParams()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer freeCTypedParams(cParams, cNParams)
+
+	converted, err := newTypedParams(cParams, cNParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(converted, identity) {
+		t.Errorf("unexpected identity parameters after conversion; got=%v, want=%v", converted, identity)
+	}
+
+	if err := env.conn.SetIdentity(TypedParams{"unix-user-id": 65534}, 0); err == nil {
+		t.Error("an error was not returned when setting an identity parameter with an unsupported type")
+	}
+
+	// only root may change the client identity
+	if os.Geteuid() != 0 {
+		if err := env.conn.SetIdentity(identity, 0); err == nil {
+			t.Error("an error was not returned when setting the client identity without privileges")
+		}
+	}
+}
+
+func TestConnectionSecurityModel(t *testing.T) {
+	env := newTestEnvironment(t)
+	defer env.cleanUp()
+
+	model, err := env.conn.SecurityModel()
+	if err != nil {
+		if err == ErrSecurityModelUnavailable {
+			t.Skip(err)
+		}
+		t.Fatal(err)
+	}
+
+	switch model.Model {
+	case "selinux", "apparmor", "none":
+	default:
+		t.Errorf("unexpected security model; got=%v", model.Model)
+	}
+}
+
+func TestConnectionSetKeepAlive(t *testing.T) {
+	env := newTestEnvironment(t)
+	defer env.cleanUp()
+
+	err := env.conn.SetKeepAlive(5, 3)
+
+	if env.conn.eventLoop {
+		if err != nil {
+			t.Error(err)
+		}
+	} else if err != ErrNoEventLoop {
+		t.Errorf("unexpected error when setting keepalive without an event loop; got=%v, want=%v", err, ErrNoEventLoop)
+	}
 }
 
 func TestConnectionReadOnly(t *testing.T) {
@@ -125,6 +435,77 @@ func TestConnectionReadOnly(t *testing.T) {
 	}
 }
 
+func TestConnectionClosedStatus(t *testing.T) {
+	conn, err := Open(testConnectionURI, ReadWrite, testLogOutput)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = conn.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if alive, err := conn.IsAlive(); err != ErrInvalidConnection {
+		t.Errorf("unexpected error when checking whether a closed connection is alive; got=%v, want=%v", err, ErrInvalidConnection)
+	} else if alive {
+		t.Error("a closed connection should not be alive")
+	}
+
+	if _, err := conn.IsEncrypted(); err != ErrInvalidConnection {
+		t.Errorf("unexpected error when checking whether a closed connection is encrypted; got=%v, want=%v", err, ErrInvalidConnection)
+	}
+
+	if _, err := conn.IsSecure(); err != ErrInvalidConnection {
+		t.Errorf("unexpected error when checking whether a closed connection is secure; got=%v, want=%v", err, ErrInvalidConnection)
+	}
+}
+
+func TestConnectionInvalid(t *testing.T) {
+	var zero Connection
+
+	conn, err := Open(testConnectionURI, ReadWrite, testLogOutput)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a copy of the connection must also become invalid after closing it
+	closed := conn
+
+	if _, err = conn.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range []Connection{zero, closed} {
+		if _, err := c.Close(); err != ErrInvalidConnection {
+			t.Errorf("unexpected error when closing an invalid connection; got=%v, want=%v", err, ErrInvalidConnection)
+		}
+
+		if _, err := c.Version(); err != ErrInvalidConnection {
+			t.Errorf("unexpected error when reading the version of an invalid connection; got=%v, want=%v", err, ErrInvalidConnection)
+		}
+
+		if _, err := c.Hostname(); err != ErrInvalidConnection {
+			t.Errorf("unexpected error when reading the hostname of an invalid connection; got=%v, want=%v", err, ErrInvalidConnection)
+		}
+
+		if _, err := c.ListDomains(DomListAll); err != ErrInvalidConnection {
+			t.Errorf("unexpected error when listing the domains of an invalid connection; got=%v, want=%v", err, ErrInvalidConnection)
+		}
+
+		if _, err := c.LookupDomainByName(utils.RandomString()); err != ErrInvalidConnection {
+			t.Errorf("unexpected error when looking up a domain on an invalid connection; got=%v, want=%v", err, ErrInvalidConnection)
+		}
+
+		if err := c.SetKeepAlive(5, 3); err != ErrInvalidConnection {
+			t.Errorf("unexpected error when setting the keepalive of an invalid connection; got=%v, want=%v", err, ErrInvalidConnection)
+		}
+
+		if _, err := c.RegisterDomainLifecycleEvent(nil, func(dom Domain, event DomainEventType, detail int) {}); err != ErrInvalidConnection {
+			t.Errorf("unexpected error when registering an event on an invalid connection; got=%v, want=%v", err, ErrInvalidConnection)
+		}
+	}
+}
+
 func TestConnectionInit(t *testing.T) {
 	env := newTestEnvironment(t)
 	defer env.cleanUp()
@@ -161,6 +542,18 @@ func TestConnectionInit(t *testing.T) {
 		t.Error(err)
 	}
 
+	if version, err := env.conn.VersionInfo(); err != nil {
+		t.Error(err)
+	} else if rawVersion, _ := env.conn.Version(); newVersionNumber(rawVersion) != version {
+		t.Errorf("unexpected hypervisor version; got=%v, want=%v", version, newVersionNumber(rawVersion))
+	}
+
+	if libVersion, err := env.conn.LibVersionInfo(); err != nil {
+		t.Error(err)
+	} else if libVersion.Major == 0 {
+		t.Errorf("the libvirt major version should not be zero; got=%v", libVersion)
+	}
+
 	cap, err := env.conn.Capabilities()
 	if err != nil {
 		t.Error(err)
@@ -233,20 +626,295 @@ func TestConnectionInit(t *testing.T) {
 	}
 }
 
+// testHostCPUXML extracts the host CPU definition from the capabilities of
+// the connection.
+func testHostCPUXML(t *testing.T, conn *Connection) string {
+	caps, err := conn.Capabilities()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	begin := strings.Index(caps, "<cpu>")
+	end := strings.Index(caps, "</cpu>")
+	if begin == -1 || end < begin {
+		t.Fatal("the host CPU definition was not found in the capabilities")
+	}
+
+	return caps[begin : end+len("</cpu>")]
+}
+
+func TestConnectionCPUModelNames(t *testing.T) {
+	env := newTestEnvironment(t)
+	defer env.cleanUp()
+
+	if _, err := env.conn.CPUModelNames("foobar"); err == nil {
+		t.Error("an error was not returned when getting CPU model names from an unknown arch")
+	}
+
+	models, err := env.conn.CPUModelNames("x86_64")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var found bool
+	for _, m := range models {
+		if m == "qemu64" {
+			found = true
+			break
+		}
+	}
+
+	if !found {
+		t.Errorf("the CPU model \"qemu64\" was not found in the x86_64 models; got=%v", models)
+	}
+}
+
+func TestConnectionCompareCPU(t *testing.T) {
+	env := newTestEnvironment(t)
+	defer env.cleanUp()
+
+	result, err := env.conn.CompareCPU(testHostCPUXML(t, env.conn), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result != CPUCompareIdentical && result != CPUCompareSuperset {
+		t.Errorf("the host CPU should be compatible with itself; got=%v", result)
+	}
+
+	if _, err := env.conn.CompareCPU("<cpu><model>foobar</model></cpu>", CompareCPUFailIncompatible); err == nil {
+		t.Error("an error was not returned when comparing a CPU with an invalid model")
+	}
+}
+
+func TestConnectionBaselineCPU(t *testing.T) {
+	env := newTestEnvironment(t)
+	defer env.cleanUp()
+
+	if _, err := env.conn.BaselineCPU(nil, 0); err == nil {
+		t.Error("an error was not returned when computing the baseline of no CPUs")
+	}
+
+	hostCPU := testHostCPUXML(t, env.conn)
+
+	baseline, err := env.conn.BaselineCPU([]string{hostCPU, hostCPU}, BaselineCPUExpandFeatures)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(strings.TrimSpace(baseline), "<cpu") {
+		t.Errorf("the baseline CPU should be a <cpu> element; got=%v", baseline)
+	}
+}
+
+func TestConnectionDomainCapabilities(t *testing.T) {
+	env := newTestEnvironment(t)
+	defer env.cleanUp()
+
+	cap, err := env.conn.DomainCapabilities("", "", "", "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(cap, "<domainCapabilities>") {
+		t.Errorf("the domain capabilities should contain a <domainCapabilities> element; got=%v", cap)
+	}
+
+	if _, err := env.conn.DomainCapabilities("/"+utils.RandomString(), "", "", "", 0); err == nil {
+		t.Error("an error was not returned when reading the domain capabilities of an invalid emulator")
+	} else if virErr, ok := err.(*Error); !ok || len(virErr.Message) == 0 {
+		t.Errorf("the returned error should be a libvirt error with a message; got=%v", err)
+	}
+}
+
+func TestConnectionStoragePoolCapabilities(t *testing.T) {
+	env := newTestEnvironment(t)
+	defer env.cleanUp()
+
+	cap, err := env.conn.StoragePoolCapabilities(0)
+	if err != nil {
+		if errors.Is(err, &Error{Code: ErrNoSupport}) {
+			t.Skip("the storage pool capabilities are not supported by the daemon")
+		}
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(cap, "<pool type='dir'") {
+		t.Errorf("the storage pool capabilities should contain a \"dir\" pool; got=%v", cap)
+	}
+}
+
+func TestConnectionBaselineHypervisorCPU(t *testing.T) {
+	env := newTestEnvironment(t)
+	defer env.cleanUp()
+
+	hostCPU := testHostCPUXML(t, env.conn)
+
+	baseline, err := env.conn.BaselineHypervisorCPU("", "", "", "", []string{hostCPU, hostCPU}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(strings.TrimSpace(baseline), "<cpu") {
+		t.Errorf("the hypervisor baseline CPU should be a <cpu> element; got=%v", baseline)
+	}
+}
+
+func TestConnectionMaxVCPUs(t *testing.T) {
+	env := newTestEnvironment(t)
+	defer env.cleanUp()
+
+	vcpus, err := env.conn.MaxVCPUs("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if vcpus <= 0 {
+		t.Errorf("libvirt maximum VCPU count for the default type should be a positive number; got=%v", vcpus)
+	}
+
+	_, err = env.conn.MaxVCPUs("foobar")
+	if err == nil {
+		t.Fatal("an error was not returned when getting maximum VCPUs from an unsupported type")
+	}
+
+	if virErr, ok := err.(*Error); !ok || len(virErr.Message) == 0 {
+		t.Errorf("the libvirt error was not returned when getting maximum VCPUs from an unsupported type; got=%v", err)
+	}
+}
+
+func TestConnectionDomainXMLFromNative(t *testing.T) {
+	env := newTestEnvironment(t)
+	defer env.cleanUp()
+
+	if _, err := env.conn.DomainXMLFromNative("qemu-argv", utils.RandomString(), 0); err == nil {
+		t.Error("an error was not returned when converting an invalid command line")
+	}
+
+	xml, err := env.conn.DomainXMLFromNative("qemu-argv", "qemu-system-x86_64 -m 512", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(xml, "<memory") {
+		t.Errorf("the converted domain XML should contain the memory size; got=%v", xml)
+	}
+}
+
+func TestConnectionDomainXMLToNative(t *testing.T) {
+	env := newTestEnvironment(t).withDomain()
+	defer env.cleanUp()
+
+	if _, err := env.conn.DomainXMLToNative("qemu-argv", utils.RandomString(), 0); err == nil {
+		t.Error("an error was not returned when converting an invalid domain XML")
+	}
+
+	xml, err := env.dom.XML(DomXMLDefault)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := env.conn.DomainXMLToNative("qemu-argv", xml, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(config, "qemu") {
+		t.Errorf("the native configuration should mention the emulator binary; got=%v", config)
+	}
+}
+
 func TestConnectionListDomains(t *testing.T) {
 	env := newTestEnvironment(t).withDomain()
 	defer env.cleanUp()
 
-	domains, err := env.conn.ListDomains(DomListAll)
-	if err != nil {
-		t.Fatal(err)
-	}
+	domains, err := env.conn.ListDomains(DomListAll)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, d := range domains {
+		if err := d.Free(); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestConnectionNumOf(t *testing.T) {
+	env := newTestEnvironment(t).withDomain().withNetwork().withStoragePool()
+	defer env.cleanUp()
+
+	counts := []struct {
+		name  string
+		count func() (int, error)
+		list  func() (int, error)
+	}{
+		{"active domains", env.conn.NumOfDomains, func() (int, error) {
+			domains, err := env.conn.ListDomains(DomListActive)
+			for _, d := range domains {
+				d.Free()
+			}
+			return len(domains), err
+		}},
+		{"inactive domains", env.conn.NumOfDefinedDomains, func() (int, error) {
+			domains, err := env.conn.ListDomains(DomListInactive)
+			for _, d := range domains {
+				d.Free()
+			}
+			return len(domains), err
+		}},
+		{"active networks", env.conn.NumOfNetworks, func() (int, error) {
+			networks, err := env.conn.ListNetworks(NetListActive)
+			for _, n := range networks {
+				n.Free()
+			}
+			return len(networks), err
+		}},
+		{"inactive networks", env.conn.NumOfDefinedNetworks, func() (int, error) {
+			networks, err := env.conn.ListNetworks(NetListInactive)
+			for _, n := range networks {
+				n.Free()
+			}
+			return len(networks), err
+		}},
+		{"active storage pools", env.conn.NumOfStoragePools, func() (int, error) {
+			pools, err := env.conn.ListStoragePools(PoolListActive)
+			for _, p := range pools {
+				p.Free()
+			}
+			return len(pools), err
+		}},
+		{"inactive storage pools", env.conn.NumOfDefinedStoragePools, func() (int, error) {
+			pools, err := env.conn.ListStoragePools(PoolListInactive)
+			for _, p := range pools {
+				p.Free()
+			}
+			return len(pools), err
+		}},
+	}
+
+	for _, c := range counts {
+		count, err := c.count()
+		if err != nil {
+			t.Error(err)
+			continue
+		}
 
-	for _, d := range domains {
-		if err := d.Free(); err != nil {
+		length, err := c.list()
+		if err != nil {
 			t.Error(err)
+			continue
+		}
+
+		if count != length {
+			t.Errorf("unexpected number of %v; got=%v, want=%v", c.name, count, length)
 		}
 	}
+
+	var zero Connection
+	if _, err := zero.NumOfDomains(); err != ErrInvalidConnection {
+		t.Errorf("unexpected error when counting the domains of an invalid connection; got=%v, want=%v", err, ErrInvalidConnection)
+	}
 }
 
 func TestConnectionCreateDestroyDomain(t *testing.T) {
@@ -303,6 +971,90 @@ func TestConnectionCreateDestroyDomain(t *testing.T) {
 	}
 }
 
+func TestConnectionCreateDomainPaused(t *testing.T) {
+	env := newTestEnvironment(t)
+	defer env.cleanUp()
+
+	var xml bytes.Buffer
+	data, err := newTestDomainData(*env.conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer data.cleanUp(*env.conn)
+
+	if err = testDomainTmpl.Execute(&xml, data); err != nil {
+		t.Fatal(err)
+	}
+
+	invalidXML := strings.Replace(xml.String(), "</domain>", "<foo/></domain>", 1)
+	if _, err = env.conn.CreateDomain(invalidXML, DomCreateValidate); err == nil {
+		t.Error("an error was not returned when creating a domain with an invalid XML and validation")
+	}
+
+	dom, err := env.conn.CreateDomain(xml.String(), DomCreatePaused|DomCreateAutodestroy|DomCreateValidate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dom.Free()
+
+	state, _, err := dom.State()
+	if err != nil {
+		t.Error(err)
+	}
+	if state != DomStatePaused {
+		t.Errorf("unexpected domain state after being created paused; got=%v, want=%v", state, DomStatePaused)
+	}
+
+	if err = dom.Destroy(DomDestroyDefault); err != nil {
+		t.Fatal(err)
+	}
+
+	// the domain is transient, so it's gone after being destroyed
+	if _, err = env.conn.LookupDomainByName(data.Name); err == nil {
+		t.Error("a transient domain was found after being destroyed")
+	}
+}
+
+func TestConnectionCreateDomainWithFiles(t *testing.T) {
+	env := newTestEnvironment(t)
+	defer env.cleanUp()
+
+	var xml bytes.Buffer
+	data, err := newTestDomainData(*env.conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer data.cleanUp(*env.conn)
+
+	if err = testDomainTmpl.Execute(&xml, data); err != nil {
+		t.Fatal(err)
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+
+	if _, err = env.conn.CreateDomainWithFiles("", []*os.File{r}, DomCreateDefault); err == nil {
+		t.Error("an error was not returned when creating a domain with empty XML descriptor")
+	}
+
+	dom, err := env.conn.CreateDomainWithFiles(xml.String(), []*os.File{r}, DomCreateDefault)
+	if err != nil {
+		if errors.Is(err, &Error{Code: ErrNoSupport}) || errors.Is(err, &Error{Code: ErrOperationInvalid}) {
+			t.Skipf("passing files is not supported by the driver: %v", err)
+		}
+		t.Fatal(err)
+	}
+	defer dom.Free()
+
+	if err = dom.Destroy(DomDestroyDefault); err != nil {
+		t.Error(err)
+	}
+}
+
 func TestConnectionDefineUndefineDomain(t *testing.T) {
 	env := newTestEnvironment(t)
 	defer env.cleanUp()
@@ -322,7 +1074,18 @@ func TestConnectionDefineUndefineDomain(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	dom, err := env.conn.DefineDomain(xml.String())
+	invalidXML := strings.Replace(xml.String(), "</domain>", "<foo/></domain>", 1)
+	if _, err = env.conn.DefineDomainFlags(invalidXML, DomDefineValidate); err == nil {
+		t.Error("an error was not returned when defining a domain with an invalid XML and validation")
+	} else if !strings.Contains(err.Error(), "foo") {
+		t.Errorf("the validation error should mention the invalid element; got=%v", err)
+	}
+
+	if _, err = env.conn.DefineDomainFlags(xml.String(), DomainDefineFlag(99)); err == nil {
+		t.Error("an error was not returned when using an invalid define flag")
+	}
+
+	dom, err := env.conn.DefineDomainFlags(xml.String(), DomDefineValidate)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -443,6 +1206,8 @@ func TestConnectionLookupDomain(t *testing.T) {
 	// ByName
 	if _, err = env.conn.LookupDomainByName(utils.RandomString()); err == nil {
 		t.Error("an error was not returned when looking up a non-existing domain name")
+	} else if !errors.Is(err, &Error{Code: ErrNoDomain}) {
+		t.Errorf("unexpected error when looking up a non-existing domain name; got=%v, want code=%v", err, ErrNoDomain)
 	}
 
 	dom, err = env.conn.LookupDomainByName(data.Name)
@@ -460,24 +1225,82 @@ func TestConnectionLookupDomain(t *testing.T) {
 		t.Errorf("looked up domain with unexpected name; got=%v, want=%v", name, data.Name)
 	}
 
-	// ByUUID
-	if _, err = env.conn.LookupDomainByUUID(utils.RandomString()); err == nil {
+	// ByUUIDString
+	if _, err = env.conn.LookupDomainByUUIDString(utils.RandomString()); err != ErrInvalidUUID {
+		t.Errorf("unexpected error when looking up an invalid domain UUID; got=%v, want=%v", err, ErrInvalidUUID)
+	}
+
+	if _, err = env.conn.LookupDomainByUUIDString(uuid.New()); err == nil {
 		t.Error("an error was not returned when looking up a non-existing domain UUID")
 	}
 
-	dom, err = env.conn.LookupDomainByUUID(data.UUID)
+	for _, uuidStr := range []string{data.UUID, strings.Replace(data.UUID, "-", "", -1)} {
+		dom, err = env.conn.LookupDomainByUUIDString(uuidStr)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		defer dom.Free()
+
+		domUUID, err := dom.UUID()
+		if err != nil {
+			t.Error(err)
+		}
+
+		if domUUID != data.UUID {
+			t.Errorf("looked up domain with unexpected UUID; got=%v, want=%v", domUUID, data.UUID)
+		}
+	}
+
+	// ByUUID
+	var rawUUID [16]byte
+	copy(rawUUID[:], uuid.Parse(data.UUID))
+
+	dom, err = env.conn.LookupDomainByUUID(rawUUID)
 	if err != nil {
-		t.Error(err)
+		t.Fatal(err)
 	}
 	defer dom.Free()
 
-	uuid, err := dom.UUID()
-	if err != nil {
+	if name, err = dom.Name(); err != nil {
 		t.Error(err)
 	}
 
-	if uuid != data.UUID {
-		t.Errorf("looked up domain with unexpected UUID; got=%v, want=%v", uuid, data.UUID)
+	if name != data.Name {
+		t.Errorf("looked up domain with unexpected name; got=%v, want=%v", name, data.Name)
+	}
+}
+
+func TestConnectionParseUUID(t *testing.T) {
+	want := [16]byte{0x6f, 0x2e, 0x6b, 0xf0, 0x2b, 0xd8, 0x4b, 0x5e, 0x9a, 0x4d, 0x3f, 0x2c, 0x27, 0x1d, 0x3b, 0x10}
+
+	for _, str := range []string{
+		"6f2e6bf0-2bd8-4b5e-9a4d-3f2c271d3b10",
+		"6f2e6bf02bd84b5e9a4d3f2c271d3b10",
+		"  6F2E6BF0-2BD8-4B5E-9A4D-3F2C271D3B10  ",
+		"\t-6f2e6bf0--2bd8 4b5e - 9a4d-3f2c271d3b10\n",
+		"6f-2e-6b-f0-2b-d8-4b-5e-9a-4d-3f-2c-27-1d-3b-10",
+	} {
+		got, err := parseUUID(str)
+		if err != nil {
+			t.Errorf("unexpected error when parsing the UUID %q: %v", str, err)
+		} else if got != want {
+			t.Errorf("unexpected parsed UUID from %q; got=%x, want=%x", str, got, want)
+		}
+	}
+
+	for _, str := range []string{
+		"",
+		"6f2e6bf0-2bd8-4b5e-9a4d-3f2c271d3b1",
+		"6f2e6bf0-2bd8-4b5e-9a4d-3f2c271d3b100",
+		"6f2e6bf0-2bd8-4b5e-9a4d-3f2c271d3bxx",
+		"6f2e6bf0-2bd8-4b5e-9a4d-3f2c271d3b10-",
+		"6f2e6bf0-2bd8-4b5e-9a4d-3f2c271d3b1-0",
+		"6f2e6bf0\t2bd8-4b5e-9a4d-3f2c271d3b10",
+	} {
+		if _, err := parseUUID(str); err != ErrInvalidUUID {
+			t.Errorf("unexpected error when parsing the invalid UUID %q; got=%v, want=%v", str, err, ErrInvalidUUID)
+		}
 	}
 }
 
@@ -501,6 +1324,54 @@ func TestConnectionListSecrets(t *testing.T) {
 	}
 }
 
+func TestConnectionListSecretsFindsDefined(t *testing.T) {
+	env := newTestEnvironment(t).withSecret()
+	defer env.cleanUp()
+
+	secrets, err := env.conn.ListSecrets(SecListNoEphemeral | SecListNoPrivate)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var found bool
+	for _, s := range secrets {
+		uuid, err := s.UUID()
+		if err != nil {
+			t.Error(err)
+		}
+
+		if uuid == env.secData.UUID {
+			found = true
+
+			usageType, err := s.UsageType()
+			if err != nil {
+				t.Error(err)
+			}
+
+			if usageType != env.secData.UsageType {
+				t.Errorf("wrong listed secret usage type; got=%v, want=%v", usageType, env.secData.UsageType)
+			}
+
+			usageID, err := s.UsageID()
+			if err != nil {
+				t.Error(err)
+			}
+
+			if usageID != env.secData.UsageName {
+				t.Errorf("wrong listed secret usage ID; got=%v, want=%v", usageID, env.secData.UsageName)
+			}
+		}
+
+		if err = s.Free(); err != nil {
+			t.Error(err)
+		}
+	}
+
+	if !found {
+		t.Errorf("the test secret was not found in the list of secrets; want=%v", env.secData.UUID)
+	}
+}
+
 func TestConnectionDefineUndefineSecret(t *testing.T) {
 	env := newTestEnvironment(t)
 	defer env.cleanUp()
@@ -592,7 +1463,22 @@ func TestConnectionFindStoragePoolSources(t *testing.T) {
 		t.Error("an error was not returned when using an empty storage pool type")
 	}
 
-	// TODO: how to test this function on success?
+	if _, err := env.conn.FindStoragePoolSources("foobar", ""); err == nil {
+		t.Error("an error was not returned when using an invalid storage pool type")
+	}
+
+	// "logical" pools don't need a source specification
+	sources, err := env.conn.FindStoragePoolSources("logical", "")
+	if err != nil {
+		if virErr, ok := err.(*Error); ok && virErr.Code == ErrNoSupport {
+			t.Skip(err)
+		}
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(sources, "<sources") {
+		t.Errorf("the storage pool sources should be a <sources> document; got=%v", sources)
+	}
 }
 
 func TestConnectionListStoragePools(t *testing.T) {
@@ -615,6 +1501,49 @@ func TestConnectionListStoragePools(t *testing.T) {
 	}
 }
 
+func TestConnectionListStoragePoolsByType(t *testing.T) {
+	env := newTestEnvironment(t).withStoragePool()
+	defer env.cleanUp()
+
+	// containsTestPool checks whether the test pool is listed with "flags"
+	containsTestPool := func(flags StoragePoolListFlag) bool {
+		pools, err := env.conn.ListStoragePools(flags)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var found bool
+		for _, pool := range pools {
+			name, err := pool.Name()
+			if err != nil {
+				t.Error(err)
+			}
+
+			if name == env.poolData.Name {
+				found = true
+			}
+
+			if err = pool.Free(); err != nil {
+				t.Error(err)
+			}
+		}
+
+		return found
+	}
+
+	if !containsTestPool(PoolListDir) {
+		t.Error("the test storage pool was not listed with the filter for \"dir\" pools")
+	}
+
+	if !containsTestPool(PoolListInactive | PoolListDir) {
+		t.Error("the test storage pool was not listed with the filter for inactive \"dir\" pools")
+	}
+
+	if containsTestPool(PoolListISCSI) {
+		t.Error("the test storage pool was listed with the filter for \"iscsi\" pools")
+	}
+}
+
 func TestConnectionDefineUndefineStoragePool(t *testing.T) {
 	env := newTestEnvironment(t)
 	defer env.cleanUp()
@@ -868,6 +1797,139 @@ func TestConnectionListInterfaces(t *testing.T) {
 	}
 }
 
+func TestConnectionListNetworks(t *testing.T) {
+	env := newTestEnvironment(t).withNetwork()
+	defer env.cleanUp()
+
+	if _, err := env.conn.ListNetworks(NetworkListFlag(^uint32(0))); err == nil {
+		t.Error("an error was not returned when using an invalid flag")
+	}
+
+	networks, err := env.conn.ListNetworks(NetListInactive)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var found bool
+	for _, net := range networks {
+		name, err := net.Name()
+		if err != nil {
+			t.Error(err)
+		}
+
+		if name == env.netData.Name {
+			found = true
+		}
+
+		if err = net.Free(); err != nil {
+			t.Error(err)
+		}
+	}
+
+	if !found {
+		t.Errorf("the test network was not found in the list of inactive networks; want=%v", env.netData.Name)
+	}
+}
+
+func TestConnectionDefineUndefineNetwork(t *testing.T) {
+	env := newTestEnvironment(t)
+	defer env.cleanUp()
+
+	if _, err := env.conn.DefineNetwork(""); err == nil {
+		t.Error("an error was not returned when defining a network with an empty XML descriptor")
+	}
+
+	var xml bytes.Buffer
+
+	data := newTestNetworkData()
+
+	if err := testNetworkTmpl.Execute(&xml, data); err != nil {
+		t.Fatal(err)
+	}
+
+	net, err := env.conn.DefineNetwork(xml.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer net.Free()
+
+	if err = net.Undefine(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestConnectionLookupNetwork(t *testing.T) {
+	env := newTestEnvironment(t).withNetwork()
+	defer env.cleanUp()
+
+	if _, err := env.conn.LookupNetworkByName(utils.RandomString()); err == nil {
+		t.Error("an error was not returned when looking up a non-existing network")
+	}
+
+	net, err := env.conn.LookupNetworkByName(env.netData.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer net.Free()
+
+	uuid, err := net.UUID()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if uuid != env.netData.UUID {
+		t.Errorf("unexpected network UUID; got=%v, want=%v", uuid, env.netData.UUID)
+	}
+}
+
+func TestConnectionListNWFilters(t *testing.T) {
+	env := newTestEnvironment(t)
+	defer env.cleanUp()
+
+	if _, err := env.conn.LookupNWFilterByName(utils.RandomString()); err == nil {
+		t.Error("an error was not returned when looking up a non-existing network filter")
+	}
+
+	filters, err := env.conn.ListNWFilters()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, f := range filters {
+		if err = f.Free(); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestConnectionListNodeDevices(t *testing.T) {
+	env := newTestEnvironment(t)
+	defer env.cleanUp()
+
+	devices, err := env.conn.ListNodeDevices(NodeDevListAll)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, d := range devices {
+		if err = d.Free(); err != nil {
+			t.Error(err)
+		}
+	}
+
+	// s390 channel devices don't exist on most hosts, but that isn't an error
+	devices, err = env.conn.ListNodeDevices(NodeDevListCCWDev)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, d := range devices {
+		if err = d.Free(); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
 func BenchmarkConnectionOpenClose(b *testing.B) {
 	for n := 0; n < b.N; n++ {
 		conn, err := Open(testConnectionURI, ReadWrite, testLogOutput)
@@ -0,0 +1,113 @@
+package libvirt
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// The CPU maps are represented in Go as a []bool with one element per host
+// CPU, indexed by the CPU number, telling whether the CPU is used. libvirt
+// represents them as bitmaps, where the CPU "n" is the bit "n % 8" of the byte
+// "n / 8".
+
+// cpuMapLen returns how many bytes are needed by a libvirt CPU map with
+// "nCPUs" CPUs, like VIR_CPU_MAPLEN.
+func cpuMapLen(nCPUs int) int {
+	return (nCPUs + 7) / 8
+}
+
+// cpuMapToBytes converts "cpus" into a libvirt CPU map. The unused bits of the
+// last byte are unset.
+func cpuMapToBytes(cpus []bool) []byte {
+	cpuMap := make([]byte, cpuMapLen(len(cpus)))
+
+	for i, used := range cpus {
+		if used {
+			cpuMap[i/8] |= 1 << uint(i%8)
+		}
+	}
+
+	return cpuMap
+}
+
+// cpuMapFromBytes converts the first "nCPUs" CPUs of the libvirt CPU map
+// "cpuMap" into a []bool.
+func cpuMapFromBytes(cpuMap []byte, nCPUs int) []bool {
+	cpus := make([]bool, nCPUs)
+
+	for i := range cpus {
+		cpus[i] = cpuMap[i/8]&(1<<uint(i%8)) != 0
+	}
+
+	return cpus
+}
+
+// isEmptyCPUMap tells whether no CPU is used in "cpus".
+func isEmptyCPUMap(cpus []bool) bool {
+	for _, used := range cpus {
+		if used {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ParseCPUMapString parses a CPU map in libvirt's string syntax (e.g.
+// "0-3,^1"), which is a comma-separated list of CPU numbers ("n"), ranges of
+// CPU numbers ("n-m") and excluded CPU numbers ("^n"), applied in order. The
+// returned slice is indexed by the CPU number and is as long as needed to hold
+// the greatest CPU number listed. An empty string is an empty CPU map.
+func ParseCPUMapString(str string) ([]bool, error) {
+	var cpus []bool
+
+	if strings.TrimSpace(str) == "" {
+		return cpus, nil
+	}
+
+	set := func(cpu int, used bool) {
+		for len(cpus) <= cpu {
+			cpus = append(cpus, false)
+		}
+		cpus[cpu] = used
+	}
+
+	for _, entry := range strings.Split(str, ",") {
+		entry = strings.TrimSpace(entry)
+
+		if strings.HasPrefix(entry, "^") {
+			cpu, err := strconv.ParseUint(strings.TrimSpace(entry[1:]), 10, 16)
+			if err != nil {
+				return nil, fmt.Errorf("invalid CPU map %q: %v", str, err)
+			}
+
+			set(int(cpu), false)
+			continue
+		}
+
+		bounds := strings.SplitN(entry, "-", 2)
+
+		first, err := strconv.ParseUint(strings.TrimSpace(bounds[0]), 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CPU map %q: %v", str, err)
+		}
+
+		last := first
+		if len(bounds) == 2 {
+			if last, err = strconv.ParseUint(strings.TrimSpace(bounds[1]), 10, 16); err != nil {
+				return nil, fmt.Errorf("invalid CPU map %q: %v", str, err)
+			}
+
+			if last < first {
+				return nil, fmt.Errorf("invalid CPU map %q: range %v is reversed", str, entry)
+			}
+		}
+
+		for cpu := first; cpu <= last; cpu++ {
+			set(int(cpu), true)
+		}
+	}
+
+	return cpus, nil
+}
@@ -0,0 +1,71 @@
+package libvirt
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestCPUMapConversion(t *testing.T) {
+	// 10 CPUs, so the last byte is only partially used
+	cpus := []bool{true, false, false, true, false, false, false, false, false, true}
+
+	cpuMap := cpuMapToBytes(cpus)
+	if want := []byte{0x09, 0x02}; !bytes.Equal(cpuMap, want) {
+		t.Errorf("unexpected CPU map; got=%#v, want=%#v", cpuMap, want)
+	}
+
+	if converted := cpuMapFromBytes(cpuMap, len(cpus)); !reflect.DeepEqual(converted, cpus) {
+		t.Errorf("unexpected CPUs after converting them back; got=%v, want=%v", converted, cpus)
+	}
+
+	if l := cpuMapLen(8); l != 1 {
+		t.Errorf("unexpected CPU map length; got=%v, want=1", l)
+	}
+
+	if !isEmptyCPUMap(nil) || !isEmptyCPUMap(make([]bool, 4)) {
+		t.Error("a CPU map without CPUs in use should be empty")
+	}
+
+	if isEmptyCPUMap(cpus) {
+		t.Error("a CPU map with CPUs in use should not be empty")
+	}
+}
+
+func TestParseCPUMapString(t *testing.T) {
+	tests := []struct {
+		str  string
+		want []bool
+	}{
+		{"", nil},
+		{" ", nil},
+		{"0", []bool{true}},
+		{"2", []bool{false, false, true}},
+		{"0-3", []bool{true, true, true, true}},
+		{"0-3,^1", []bool{true, false, true, true}},
+		{"^1,0-3", []bool{true, true, true, true}},
+		{"0-3,^3", []bool{true, true, true, false}},
+		{"1,3", []bool{false, true, false, true}},
+		{" 0 - 1 , 3 ", []bool{true, true, false, true}},
+		{"2-2", []bool{false, false, true}},
+		{"0,9", []bool{true, false, false, false, false, false, false, false, false, true}},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseCPUMapString(tt.str)
+		if err != nil {
+			t.Errorf("unexpected error when parsing CPU map %q: %v", tt.str, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("unexpected CPUs when parsing CPU map %q; got=%v, want=%v", tt.str, got, tt.want)
+		}
+	}
+
+	for _, str := range []string{",", "0,", "a", "-1", "1-", "3-1", "^", "^1-2", "0-1-2", "99999999"} {
+		if _, err := ParseCPUMapString(str); err == nil {
+			t.Errorf("an error was not returned when parsing the invalid CPU map %q", str)
+		}
+	}
+}
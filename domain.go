@@ -4,9 +4,16 @@ package libvirt
 // #include <libvirt/libvirt.h>
 import "C"
 import (
+	"encoding/xml"
 	"errors"
+	"fmt"
 	"log"
+	"os"
 	"reflect"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 	"unicode/utf8"
 	"unsafe"
@@ -67,6 +74,15 @@ const (
 	DomXMLMigratable DomainXMLFlag = C.VIR_DOMAIN_XML_MIGRATABLE
 )
 
+// DomainDefineFlag defines how a domain should be defined.
+type DomainDefineFlag uint32
+
+// Possible values for DomainDefineFlag.
+const (
+	DomDefineDefault  DomainDefineFlag = 0
+	DomDefineValidate DomainDefineFlag = C.VIR_DOMAIN_DEFINE_VALIDATE
+)
+
 // DomainCreateFlag defines how a domain should be created.
 type DomainCreateFlag uint32
 
@@ -77,6 +93,7 @@ const (
 	DomCreateAutodestroy DomainCreateFlag = C.VIR_DOMAIN_START_AUTODESTROY
 	DomCreateBypassCache DomainCreateFlag = C.VIR_DOMAIN_START_BYPASS_CACHE
 	DomCreateForceBoot   DomainCreateFlag = C.VIR_DOMAIN_START_FORCE_BOOT
+	DomCreateValidate    DomainCreateFlag = C.VIR_DOMAIN_START_VALIDATE
 )
 
 // DomainDestroyFlag defines how a domain should be destroyed.
@@ -84,8 +101,9 @@ type DomainDestroyFlag uint32
 
 // Possible values for DomainDestroyFlag.
 const (
-	DomDestroyDefault  DomainDestroyFlag = C.VIR_DOMAIN_DESTROY_DEFAULT
-	DomDestroyGraceful DomainDestroyFlag = C.VIR_DOMAIN_DESTROY_GRACEFUL
+	DomDestroyDefault    DomainDestroyFlag = C.VIR_DOMAIN_DESTROY_DEFAULT
+	DomDestroyGraceful   DomainDestroyFlag = C.VIR_DOMAIN_DESTROY_GRACEFUL
+	DomDestroyRemoveLogs DomainDestroyFlag = C.VIR_DOMAIN_DESTROY_REMOVE_LOGS
 )
 
 // DomainUndefineFlag defines how a domain should be undefined.
@@ -93,13 +111,35 @@ type DomainUndefineFlag uint32
 
 // Possible values for DomainUndefineFlag.
 const (
-	DomUndefineDefault           DomainUndefineFlag = 0
-	DomUndefineManagedSave       DomainUndefineFlag = C.VIR_DOMAIN_UNDEFINE_MANAGED_SAVE
-	DomUndefineSnapshotsMetadata DomainUndefineFlag = C.VIR_DOMAIN_UNDEFINE_SNAPSHOTS_METADATA
-	DomUndefineNVRAM             DomainUndefineFlag = C.VIR_DOMAIN_UNDEFINE_NVRAM
+	DomUndefineDefault             DomainUndefineFlag = 0
+	DomUndefineManagedSave         DomainUndefineFlag = C.VIR_DOMAIN_UNDEFINE_MANAGED_SAVE
+	DomUndefineSnapshotsMetadata   DomainUndefineFlag = C.VIR_DOMAIN_UNDEFINE_SNAPSHOTS_METADATA
+	DomUndefineNVRAM               DomainUndefineFlag = C.VIR_DOMAIN_UNDEFINE_NVRAM
+	DomUndefineKeepNVRAM           DomainUndefineFlag = C.VIR_DOMAIN_UNDEFINE_KEEP_NVRAM
+	DomUndefineCheckpointsMetadata DomainUndefineFlag = C.VIR_DOMAIN_UNDEFINE_CHECKPOINTS_METADATA
+)
+
+// DomainShutdownFlag defines how a domain should be shut down. If more than
+// one method is set, each of them is tried in turn until one succeeds, and an
+// error is returned only if all of them fail; e.g. with
+// DomShutdownGuestAgent|DomShutdownACPIPowerBtn, QEMU tries the guest agent
+// first and falls back to the ACPI power button. The order in which the
+// methods are tried is up to the hypervisor. If no method is set, the
+// hypervisor chooses one.
+type DomainShutdownFlag uint32
+
+// Possible values for DomainShutdownFlag.
+const (
+	DomShutdownDefault      DomainShutdownFlag = C.VIR_DOMAIN_SHUTDOWN_DEFAULT
+	DomShutdownACPIPowerBtn DomainShutdownFlag = C.VIR_DOMAIN_SHUTDOWN_ACPI_POWER_BTN
+	DomShutdownGuestAgent   DomainShutdownFlag = C.VIR_DOMAIN_SHUTDOWN_GUEST_AGENT
+	DomShutdownInitctl      DomainShutdownFlag = C.VIR_DOMAIN_SHUTDOWN_INITCTL
+	DomShutdownSignal       DomainShutdownFlag = C.VIR_DOMAIN_SHUTDOWN_SIGNAL
+	DomShutdownParavirt     DomainShutdownFlag = C.VIR_DOMAIN_SHUTDOWN_PARAVIRT
 )
 
-// DomainRebootFlag defines how a domain should be rebooted.
+// DomainRebootFlag defines how a domain should be rebooted. The methods are
+// selected the same way as in DomainShutdownFlag.
 type DomainRebootFlag uint32
 
 // Possible values for DomainRebootFlag.
@@ -127,6 +167,30 @@ const (
 	DomStatePMSuspended DomainState = C.VIR_DOMAIN_PMSUSPENDED
 )
 
+// String returns a human-readable description of the domain state.
+func (state DomainState) String() string {
+	switch state {
+	case DomStateNone:
+		return "no state"
+	case DomStateRunning:
+		return "running"
+	case DomStateBlocked:
+		return "blocked"
+	case DomStatePaused:
+		return "paused"
+	case DomStateShutdown:
+		return "in shutdown"
+	case DomStateShutoff:
+		return "shut off"
+	case DomStateCrashed:
+		return "crashed"
+	case DomStatePMSuspended:
+		return "pmsuspended"
+	default:
+		return fmt.Sprintf("unknown state (%d)", uint32(state))
+	}
+}
+
 // DomainNostateReason describes the reason which led a domain to be on "DomStateNone".
 type DomainNostateReason uint32
 
@@ -135,6 +199,16 @@ const (
 	DomNostateReasonUnknown DomainNostateReason = C.VIR_DOMAIN_NOSTATE_UNKNOWN
 )
 
+// String returns a human-readable description of the reason.
+func (reason DomainNostateReason) String() string {
+	switch reason {
+	case DomNostateReasonUnknown:
+		return "unknown"
+	default:
+		return fmt.Sprintf("unknown reason (%d)", uint32(reason))
+	}
+}
+
 // DomainRunningReason describes the reason which led a domain to be on "DomStateRunning".
 type DomainRunningReason uint32
 
@@ -152,6 +226,34 @@ const (
 	DomRunningReasonCrashed            DomainRunningReason = C.VIR_DOMAIN_RUNNING_CRASHED
 )
 
+// String returns a human-readable description of the reason.
+func (reason DomainRunningReason) String() string {
+	switch reason {
+	case DomRunningReasonUnknown:
+		return "unknown"
+	case DomRunningReasonBooted:
+		return "booted"
+	case DomRunningReasonMigrated:
+		return "migrated"
+	case DomRunningReasonRestored:
+		return "restored"
+	case DomRunningReasonFromSnapshot:
+		return "from snapshot"
+	case DomRunningReasonUnpaused:
+		return "unpaused"
+	case DomRunningReasonMigrationCancelled:
+		return "migration canceled"
+	case DomRunningReasonSaveCancelled:
+		return "save canceled"
+	case DomRunningReasonWakeUp:
+		return "event wakeup"
+	case DomRunningReasonCrashed:
+		return "crashed"
+	default:
+		return fmt.Sprintf("unknown reason (%d)", uint32(reason))
+	}
+}
+
 // DomainBlockedReason describes the reason which led a domain to be on "DomStateBlocked".
 type DomainBlockedReason uint32
 
@@ -160,6 +262,16 @@ const (
 	DomBlockedReasonUnkwown DomainBlockedReason = C.VIR_DOMAIN_BLOCKED_UNKNOWN
 )
 
+// String returns a human-readable description of the reason.
+func (reason DomainBlockedReason) String() string {
+	switch reason {
+	case DomBlockedReasonUnkwown:
+		return "unknown"
+	default:
+		return fmt.Sprintf("unknown reason (%d)", uint32(reason))
+	}
+}
+
 // DomainPausedReason describes the reason which led a domain to be on "DomStatePaused".
 type DomainPausedReason uint32
 
@@ -178,6 +290,36 @@ const (
 	DomPausedReasonCrashed      DomainPausedReason = C.VIR_DOMAIN_PAUSED_CRASHED
 )
 
+// String returns a human-readable description of the reason.
+func (reason DomainPausedReason) String() string {
+	switch reason {
+	case DomPausedReasonUnknown:
+		return "unknown"
+	case DomPausedReasonUser:
+		return "user"
+	case DomPausedReasonMigration:
+		return "migrating"
+	case DomPausedReasonSave:
+		return "saving"
+	case DomPausedReasonDump:
+		return "dumping"
+	case DomPausedReasonIOError:
+		return "I/O error"
+	case DomPausedReasonWatchdog:
+		return "watchdog"
+	case DomPausedReasonFromSnapshot:
+		return "from snapshot"
+	case DomPausedReasonShuttingDown:
+		return "shutting down"
+	case DomPausedReasonSnapshot:
+		return "creating snapshot"
+	case DomPausedReasonCrashed:
+		return "crashed"
+	default:
+		return fmt.Sprintf("unknown reason (%d)", uint32(reason))
+	}
+}
+
 // DomainShutdownReason describes the reason which led a domain to be on "DomStateShutdown".
 type DomainShutdownReason uint32
 
@@ -187,6 +329,18 @@ const (
 	DomShutdownReasonUser    DomainShutdownReason = C.VIR_DOMAIN_SHUTDOWN_USER
 )
 
+// String returns a human-readable description of the reason.
+func (reason DomainShutdownReason) String() string {
+	switch reason {
+	case DomShutdownReasonUnknown:
+		return "unknown"
+	case DomShutdownReasonUser:
+		return "user"
+	default:
+		return fmt.Sprintf("unknown reason (%d)", uint32(reason))
+	}
+}
+
 // DomainShutoffReason describes the reason which led a domain to be on "DomStateShutoff".
 type DomainShutoffReason uint32
 
@@ -202,6 +356,30 @@ const (
 	DomShutoffReasonFromSnapshot DomainShutoffReason = C.VIR_DOMAIN_SHUTOFF_FROM_SNAPSHOT
 )
 
+// String returns a human-readable description of the reason.
+func (reason DomainShutoffReason) String() string {
+	switch reason {
+	case DomShutoffReasonUnknown:
+		return "unknown"
+	case DomShutoffReasonShutdown:
+		return "shutdown"
+	case DomShutoffReasonDestroyed:
+		return "destroyed"
+	case DomShutoffReasonCrashed:
+		return "crashed"
+	case DomShutoffReasonMigrated:
+		return "migrated"
+	case DomShutoffReasonSaved:
+		return "saved"
+	case DomShutoffReasonFailed:
+		return "failed"
+	case DomShutoffReasonFromSnapshot:
+		return "from snapshot"
+	default:
+		return fmt.Sprintf("unknown reason (%d)", uint32(reason))
+	}
+}
+
 // DomainCrashedReason describes the reason which led a domain to be on "DomStateCrashed".
 type DomainCrashedReason uint32
 
@@ -211,6 +389,18 @@ const (
 	DomCrashedReasonPanicked DomainCrashedReason = C.VIR_DOMAIN_CRASHED_PANICKED
 )
 
+// String returns a human-readable description of the reason.
+func (reason DomainCrashedReason) String() string {
+	switch reason {
+	case DomCrashedReasonUnknown:
+		return "unknown"
+	case DomCrashedReasonPanicked:
+		return "panicked"
+	default:
+		return fmt.Sprintf("unknown reason (%d)", uint32(reason))
+	}
+}
+
 // DomainPMSuspendedReason describes the reason which led a domain to be on "DomStatePMSuspended".
 type DomainPMSuspendedReason uint32
 
@@ -219,6 +409,41 @@ const (
 	DomPMSuspendedReasonUnknown DomainPMSuspendedReason = C.VIR_DOMAIN_PMSUSPENDED_UNKNOWN
 )
 
+// String returns a human-readable description of the reason.
+func (reason DomainPMSuspendedReason) String() string {
+	switch reason {
+	case DomPMSuspendedReasonUnknown:
+		return "unknown"
+	default:
+		return fmt.Sprintf("unknown reason (%d)", uint32(reason))
+	}
+}
+
+// stateReasonString returns a human-readable description of "reason", which
+// must have been returned along with "state".
+func stateReasonString(state DomainState, reason int32) string {
+	switch state {
+	case DomStateNone:
+		return DomainNostateReason(reason).String()
+	case DomStateRunning:
+		return DomainRunningReason(reason).String()
+	case DomStateBlocked:
+		return DomainBlockedReason(reason).String()
+	case DomStatePaused:
+		return DomainPausedReason(reason).String()
+	case DomStateShutdown:
+		return DomainShutdownReason(reason).String()
+	case DomStateShutoff:
+		return DomainShutoffReason(reason).String()
+	case DomStateCrashed:
+		return DomainCrashedReason(reason).String()
+	case DomStatePMSuspended:
+		return DomainPMSuspendedReason(reason).String()
+	default:
+		return fmt.Sprintf("unknown reason (%d)", reason)
+	}
+}
+
 // DomainDumpFlag defines how a domain coredump should be taken.
 type DomainDumpFlag uint32
 
@@ -241,6 +466,7 @@ const (
 	DomDumpFormatKdumpZlib   DomainDumpFormat = C.VIR_DOMAIN_CORE_DUMP_FORMAT_KDUMP_ZLIB
 	DomDumpFormatKdumpLzo    DomainDumpFormat = C.VIR_DOMAIN_CORE_DUMP_FORMAT_KDUMP_LZO
 	DomDumpFormatKdumpSnappy DomainDumpFormat = C.VIR_DOMAIN_CORE_DUMP_FORMAT_KDUMP_SNAPPY
+	DomDumpFormatWinDmp      DomainDumpFormat = C.VIR_DOMAIN_CORE_DUMP_FORMAT_WIN_DMP
 )
 
 // DomainVCPUsFlag defines how a domain VCPUs count should be handled.
@@ -248,13 +474,129 @@ type DomainVCPUsFlag uint32
 
 // Possible values for DomainVCPUsFlag.
 const (
-	DomVCPusConfig  DomainVCPUsFlag = C.VIR_DOMAIN_VCPU_CONFIG
-	DomVCPUsCurrent DomainVCPUsFlag = C.VIR_DOMAIN_VCPU_CURRENT
-	DomVCPUsLive    DomainVCPUsFlag = C.VIR_DOMAIN_VCPU_LIVE
-	DomVCPUsMaximum DomainVCPUsFlag = C.VIR_DOMAIN_VCPU_MAXIMUM
-	DomVCPUsGuest   DomainVCPUsFlag = C.VIR_DOMAIN_VCPU_GUEST
+	DomVCPusConfig       DomainVCPUsFlag = C.VIR_DOMAIN_VCPU_CONFIG
+	DomVCPUsCurrent      DomainVCPUsFlag = C.VIR_DOMAIN_VCPU_CURRENT
+	DomVCPUsLive         DomainVCPUsFlag = C.VIR_DOMAIN_VCPU_LIVE
+	DomVCPUsMaximum      DomainVCPUsFlag = C.VIR_DOMAIN_VCPU_MAXIMUM
+	DomVCPUsGuest        DomainVCPUsFlag = C.VIR_DOMAIN_VCPU_GUEST
+	DomVCPUsHotpluggable DomainVCPUsFlag = C.VIR_DOMAIN_VCPU_HOTPLUGGABLE
+)
+
+// DomainJobType represents the type of a domain job.
+type DomainJobType int32
+
+// Possible values for DomainJobType.
+const (
+	DomJobNone      DomainJobType = C.VIR_DOMAIN_JOB_NONE      // no job is active
+	DomJobBounded   DomainJobType = C.VIR_DOMAIN_JOB_BOUNDED   // job with a finite completion time
+	DomJobUnbounded DomainJobType = C.VIR_DOMAIN_JOB_UNBOUNDED // job without a finite completion time
+	DomJobCompleted DomainJobType = C.VIR_DOMAIN_JOB_COMPLETED // job has finished, but isn't cleaned up
+	DomJobFailed    DomainJobType = C.VIR_DOMAIN_JOB_FAILED    // job hit an error, but isn't cleaned up
+	DomJobCancelled DomainJobType = C.VIR_DOMAIN_JOB_CANCELLED // job was aborted, but isn't cleaned up
+)
+
+// String returns a human-readable description of the job type.
+func (typ DomainJobType) String() string {
+	switch typ {
+	case DomJobNone:
+		return "none"
+	case DomJobBounded:
+		return "bounded"
+	case DomJobUnbounded:
+		return "unbounded"
+	case DomJobCompleted:
+		return "completed"
+	case DomJobFailed:
+		return "failed"
+	case DomJobCancelled:
+		return "cancelled"
+	default:
+		return fmt.Sprintf("unknown job type (%d)", int32(typ))
+	}
+}
+
+// DomainJobStatsFlag defines which job statistics are returned by "JobStats".
+type DomainJobStatsFlag uint32
+
+// Possible values for DomainJobStatsFlag.
+const (
+	DomJobStatsDefault       DomainJobStatsFlag = 0
+	DomJobStatsCompleted     DomainJobStatsFlag = C.VIR_DOMAIN_JOB_STATS_COMPLETED      // statistics of the last completed job instead of the active one
+	DomJobStatsKeepCompleted DomainJobStatsFlag = C.VIR_DOMAIN_JOB_STATS_KEEP_COMPLETED // don't remove the statistics of the completed job after reading them
+)
+
+// DomainAbortJobFlag defines how the domain job is aborted by "AbortJobFlags".
+type DomainAbortJobFlag uint32
+
+// Possible values for DomainAbortJobFlag.
+const (
+	DomAbortJobDefault  DomainAbortJobFlag = 0
+	DomAbortJobPostcopy DomainAbortJobFlag = C.VIR_DOMAIN_ABORT_JOB_POSTCOPY // interrupt a migration in post-copy mode
+)
+
+// Names of the common typed parameters returned by "JobStats". The values are
+// uint64 unless noted otherwise; the times are in milliseconds and the sizes
+// are in bytes.
+const (
+	DomJobStatsOperation              = "operation" // int32
+	DomJobStatsSuccess                = "success"   // bool
+	DomJobStatsErrMsg                 = "errmsg"    // string
+	DomJobStatsTimeElapsed            = "time_elapsed"
+	DomJobStatsTimeElapsedNet         = "time_elapsed_net"
+	DomJobStatsTimeRemaining          = "time_remaining"
+	DomJobStatsDowntime               = "downtime"
+	DomJobStatsDowntimeNet            = "downtime_net"
+	DomJobStatsSetupTime              = "setup_time"
+	DomJobStatsDataTotal              = "data_total"
+	DomJobStatsDataProcessed          = "data_processed"
+	DomJobStatsDataRemaining          = "data_remaining"
+	DomJobStatsMemoryTotal            = "memory_total"
+	DomJobStatsMemoryProcessed        = "memory_processed"
+	DomJobStatsMemoryRemaining        = "memory_remaining"
+	DomJobStatsMemoryConstant         = "memory_constant"
+	DomJobStatsMemoryNormal           = "memory_normal"
+	DomJobStatsMemoryNormalBytes      = "memory_normal_bytes"
+	DomJobStatsMemoryBPS              = "memory_bps"
+	DomJobStatsMemoryDirtyRate        = "memory_dirty_rate"
+	DomJobStatsMemoryPageSize         = "memory_page_size"
+	DomJobStatsMemoryIteration        = "memory_iteration"
+	DomJobStatsMemoryPostcopyRequests = "memory_postcopy_requests"
+	DomJobStatsDiskTotal              = "disk_total"
+	DomJobStatsDiskProcessed          = "disk_processed"
+	DomJobStatsDiskRemaining          = "disk_remaining"
+	DomJobStatsDiskBPS                = "disk_bps"
+	DomJobStatsCompressionCache       = "compression_cache"
+	DomJobStatsCompressionBytes       = "compression_bytes"
+	DomJobStatsCompressionPages       = "compression_pages"
+	DomJobStatsCompressionCacheMisses = "compression_cache_misses"
+	DomJobStatsCompressionOverflow    = "compression_overflow"
+	DomJobStatsAutoConvergeThrottle   = "auto_converge_throttle" // int32
 )
 
+// DomainVCPUState represents the state of a domain VCPU.
+type DomainVCPUState int32
+
+// Possible values for DomainVCPUState.
+const (
+	DomVCPUStateOffline DomainVCPUState = C.VIR_VCPU_OFFLINE
+	DomVCPUStateRunning DomainVCPUState = C.VIR_VCPU_RUNNING
+	DomVCPUStateBlocked DomainVCPUState = C.VIR_VCPU_BLOCKED
+)
+
+// String returns a human-readable description of the VCPU state.
+func (state DomainVCPUState) String() string {
+	switch state {
+	case DomVCPUStateOffline:
+		return "offline"
+	case DomVCPUStateRunning:
+		return "running"
+	case DomVCPUStateBlocked:
+		return "blocked"
+	default:
+		return fmt.Sprintf("unknown state (%d)", int32(state))
+	}
+}
+
 // DomainSaveFlag defines how a domain should be saved/restored.
 type DomainSaveFlag uint32
 
@@ -288,6 +630,258 @@ const (
 	DomMemoryMaximum DomainMemoryModifyFlag = C.VIR_DOMAIN_MEM_MAXIMUM
 )
 
+// Names of the typed parameters used by "BlockIOTune" and "SetBlockIOTune".
+// The values are uint64, except for DomBlockIOTuneGroupName, which is a
+// string. The "max" parameters are the burst limits, which can be sustained
+// during the "max length" parameters, in seconds.
+const (
+	DomBlockIOTuneTotalBytesSec          = "total_bytes_sec"
+	DomBlockIOTuneReadBytesSec           = "read_bytes_sec"
+	DomBlockIOTuneWriteBytesSec          = "write_bytes_sec"
+	DomBlockIOTuneTotalIOPSSec           = "total_iops_sec"
+	DomBlockIOTuneReadIOPSSec            = "read_iops_sec"
+	DomBlockIOTuneWriteIOPSSec           = "write_iops_sec"
+	DomBlockIOTuneTotalBytesSecMax       = "total_bytes_sec_max"
+	DomBlockIOTuneReadBytesSecMax        = "read_bytes_sec_max"
+	DomBlockIOTuneWriteBytesSecMax       = "write_bytes_sec_max"
+	DomBlockIOTuneTotalIOPSSecMax        = "total_iops_sec_max"
+	DomBlockIOTuneReadIOPSSecMax         = "read_iops_sec_max"
+	DomBlockIOTuneWriteIOPSSecMax        = "write_iops_sec_max"
+	DomBlockIOTuneTotalBytesSecMaxLength = "total_bytes_sec_max_length"
+	DomBlockIOTuneReadBytesSecMaxLength  = "read_bytes_sec_max_length"
+	DomBlockIOTuneWriteBytesSecMaxLength = "write_bytes_sec_max_length"
+	DomBlockIOTuneTotalIOPSSecMaxLength  = "total_iops_sec_max_length"
+	DomBlockIOTuneReadIOPSSecMaxLength   = "read_iops_sec_max_length"
+	DomBlockIOTuneWriteIOPSSecMaxLength  = "write_iops_sec_max_length"
+	DomBlockIOTuneSizeIOPSSec            = "size_iops_sec"
+	DomBlockIOTuneGroupName              = "group_name"
+)
+
+// Names of the typed parameters used by "InterfaceParameters" and
+// "SetInterfaceParameters". The values are uint32; the averages, peaks and
+// floor are in kiB/s and the bursts are in kiB.
+const (
+	DomBandwidthInAverage  = "inbound.average"
+	DomBandwidthInPeak     = "inbound.peak"
+	DomBandwidthInBurst    = "inbound.burst"
+	DomBandwidthInFloor    = "inbound.floor"
+	DomBandwidthOutAverage = "outbound.average"
+	DomBandwidthOutPeak    = "outbound.peak"
+	DomBandwidthOutBurst   = "outbound.burst"
+)
+
+// Names of the typed parameters used by "SchedulerParameters" and
+// "SetSchedulerParameters". The shares and the periods (in microseconds) are
+// uint64; the quotas (in microseconds, negative meaning unlimited) are int64.
+const (
+	DomSchedulerCPUShares      = "cpu_shares"
+	DomSchedulerGlobalPeriod   = "global_period"
+	DomSchedulerGlobalQuota    = "global_quota"
+	DomSchedulerVCPUPeriod     = "vcpu_period"
+	DomSchedulerVCPUQuota      = "vcpu_quota"
+	DomSchedulerEmulatorPeriod = "emulator_period"
+	DomSchedulerEmulatorQuota  = "emulator_quota"
+	DomSchedulerIOThreadPeriod = "iothread_period"
+	DomSchedulerIOThreadQuota  = "iothread_quota"
+)
+
+// Names of the typed parameters used by "MemoryParameters" and
+// "SetMemoryParameters". The values are uint64, in KiB; DomMemoryParamUnlimited
+// means that there's no limit.
+const (
+	DomMemoryParamHardLimit     = "hard_limit"
+	DomMemoryParamSoftLimit     = "soft_limit"
+	DomMemoryParamMinGuarantee  = "min_guarantee"
+	DomMemoryParamSwapHardLimit = "swap_hard_limit"
+)
+
+// DomMemoryParamUnlimited is the value of a memory parameter without limit.
+const DomMemoryParamUnlimited uint64 = C.VIR_DOMAIN_MEMORY_PARAM_UNLIMITED
+
+// Names of the typed parameters used by "NUMAParameters" and
+// "SetNUMAParameters". DomNUMAMode is an int32 holding a DomainNUMATuneMode;
+// DomNUMANodeset is a string with the host NUMA nodes (e.g. "0-1,3").
+const (
+	DomNUMAMode    = "numa_mode"
+	DomNUMANodeset = "numa_nodeset"
+)
+
+// DomainNUMATuneMode defines how the memory of a domain is allocated from the
+// host NUMA nodes.
+type DomainNUMATuneMode int32
+
+// Possible values for DomainNUMATuneMode.
+const (
+	DomNUMATuneModeStrict      DomainNUMATuneMode = C.VIR_DOMAIN_NUMATUNE_MEM_STRICT
+	DomNUMATuneModePreferred   DomainNUMATuneMode = C.VIR_DOMAIN_NUMATUNE_MEM_PREFERRED
+	DomNUMATuneModeInterleave  DomainNUMATuneMode = C.VIR_DOMAIN_NUMATUNE_MEM_INTERLEAVE
+	DomNUMATuneModeRestrictive DomainNUMATuneMode = C.VIR_DOMAIN_NUMATUNE_MEM_RESTRICTIVE
+)
+
+// Names of the typed parameters used by "PerfEvents" and "SetPerfEvents". The
+// values are bool, telling whether the perf event is collected.
+const (
+	DomPerfCMT                   = "cmt"
+	DomPerfMBMT                  = "mbmt"
+	DomPerfMBML                  = "mbml"
+	DomPerfCPUCycles             = "cpu_cycles"
+	DomPerfInstructions          = "instructions"
+	DomPerfCacheReferences       = "cache_references"
+	DomPerfCacheMisses           = "cache_misses"
+	DomPerfBranchInstructions    = "branch_instructions"
+	DomPerfBranchMisses          = "branch_misses"
+	DomPerfBusCycles             = "bus_cycles"
+	DomPerfStalledCyclesFrontend = "stalled_cycles_frontend"
+	DomPerfStalledCyclesBackend  = "stalled_cycles_backend"
+	DomPerfRefCPUCycles          = "ref_cpu_cycles"
+	DomPerfCPUClock              = "cpu_clock"
+	DomPerfTaskClock             = "task_clock"
+	DomPerfPageFaults            = "page_faults"
+	DomPerfContextSwitches       = "context_switches"
+	DomPerfCPUMigrations         = "cpu_migrations"
+	DomPerfPageFaultsMin         = "page_faults_min"
+	DomPerfPageFaultsMaj         = "page_faults_maj"
+	DomPerfAlignmentFaults       = "alignment_faults"
+	DomPerfEmulationFaults       = "emulation_faults"
+)
+
+// Names of the typed parameters used by "LaunchSecurityInfo" and
+// "SetLaunchSecurityState" for AMD SEV domains. The measurement, the secret
+// and the secret header are base64-encoded strings, the API versions, the
+// build ID and the policy are uint32 and the secret address is a uint64.
+const (
+	DomLaunchSecuritySEVMeasurement   = "sev-measurement"
+	DomLaunchSecuritySEVAPIMajor      = "sev-api-major"
+	DomLaunchSecuritySEVAPIMinor      = "sev-api-minor"
+	DomLaunchSecuritySEVBuildID       = "sev-build-id"
+	DomLaunchSecuritySEVPolicy        = "sev-policy"
+	DomLaunchSecuritySEVSecret        = "sev-secret"
+	DomLaunchSecuritySEVSecretHeader  = "sev-secret-header"
+	DomLaunchSecuritySEVSecretSetAddr = "sev-secret-set-address"
+)
+
+// Names of the typed parameters used by "SetIOThreadParams". The polling
+// maximum time, in nanoseconds, is a uint64; the polling grow and shrink
+// factors are uint32; the thread pool limits are int32.
+const (
+	DomIOThreadPollMaxNS     = "poll_max_ns"
+	DomIOThreadPollGrow      = "poll_grow"
+	DomIOThreadPollShrink    = "poll_shrink"
+	DomIOThreadThreadPoolMin = "thread_pool_min"
+	DomIOThreadThreadPoolMax = "thread_pool_max"
+)
+
+// Names of the typed parameters returned by "GuestVCPUs". The values are CPU
+// maps in libvirt's string syntax, which can be parsed by ParseCPUMapString.
+const (
+	DomGuestVCPUsVCPUs      = "vcpus"      // VCPUs known by the guest
+	DomGuestVCPUsOnline     = "online"     // online VCPUs
+	DomGuestVCPUsOfflinable = "offlinable" // VCPUs which can be set offline
+)
+
+// Names of the typed parameters used by "BlkioParameters" and
+// "SetBlkioParameters". DomBlkioWeight is a uint32; the per-device parameters
+// are strings which can be built with DomainBlkioDeviceValues.
+const (
+	DomBlkioWeight              = "weight"
+	DomBlkioDeviceWeight        = "device_weight"
+	DomBlkioDeviceReadIOPSSec   = "device_read_iops_sec"
+	DomBlkioDeviceWriteIOPSSec  = "device_write_iops_sec"
+	DomBlkioDeviceReadBytesSec  = "device_read_bytes_sec"
+	DomBlkioDeviceWriteBytesSec = "device_write_bytes_sec"
+)
+
+// DomainBlkioDeviceValues holds the values of a per-device blkio parameter
+// (e.g. DomBlkioDeviceWeight), indexed by the host device path (e.g.
+// "/dev/sda").
+type DomainBlkioDeviceValues map[string]uint64
+
+// String formats the values as expected by libvirt, i.e. "path,value" pairs
+// separated by commas, sorted by the device path.
+func (values DomainBlkioDeviceValues) String() string {
+	paths := make([]string, 0, len(values))
+	for path := range values {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	fields := make([]string, 0, 2*len(paths))
+	for _, path := range paths {
+		fields = append(fields, path, strconv.FormatUint(values[path], 10))
+	}
+
+	return strings.Join(fields, ",")
+}
+
+// ParseDomainBlkioDeviceValues parses the value of a per-device blkio
+// parameter, as returned by "BlkioParameters".
+func ParseDomainBlkioDeviceValues(str string) (DomainBlkioDeviceValues, error) {
+	values := make(DomainBlkioDeviceValues)
+	if str == "" {
+		return values, nil
+	}
+
+	fields := strings.Split(str, ",")
+	if len(fields)%2 != 0 {
+		return nil, fmt.Errorf("invalid blkio device values: %q", str)
+	}
+
+	for i := 0; i < len(fields); i += 2 {
+		value, err := strconv.ParseUint(fields[i+1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid blkio device value of %v: %v", fields[i], err)
+		}
+
+		values[fields[i]] = value
+	}
+
+	return values, nil
+}
+
+// DomainLifecycle defines a lifecycle event of a domain whose action can be
+// changed by "SetLifecycleAction".
+type DomainLifecycle uint32
+
+// Possible values for DomainLifecycle.
+const (
+	DomLifecyclePoweroff DomainLifecycle = C.VIR_DOMAIN_LIFECYCLE_POWEROFF
+	DomLifecycleReboot   DomainLifecycle = C.VIR_DOMAIN_LIFECYCLE_REBOOT
+	DomLifecycleCrash    DomainLifecycle = C.VIR_DOMAIN_LIFECYCLE_CRASH
+)
+
+// DomainLifecycleAction defines the action taken on a lifecycle event of a
+// domain.
+type DomainLifecycleAction uint32
+
+// Possible values for DomainLifecycleAction.
+const (
+	DomLifecycleActionDestroy         DomainLifecycleAction = C.VIR_DOMAIN_LIFECYCLE_ACTION_DESTROY
+	DomLifecycleActionRestart         DomainLifecycleAction = C.VIR_DOMAIN_LIFECYCLE_ACTION_RESTART
+	DomLifecycleActionRestartRename   DomainLifecycleAction = C.VIR_DOMAIN_LIFECYCLE_ACTION_RESTART_RENAME
+	DomLifecycleActionPreserve        DomainLifecycleAction = C.VIR_DOMAIN_LIFECYCLE_ACTION_PRESERVE
+	DomLifecycleActionCoredumpDestroy DomainLifecycleAction = C.VIR_DOMAIN_LIFECYCLE_ACTION_COREDUMP_DESTROY
+	DomLifecycleActionCoredumpRestart DomainLifecycleAction = C.VIR_DOMAIN_LIFECYCLE_ACTION_COREDUMP_RESTART
+)
+
+// DomainSetUserPasswordFlag defines how a user password is set by
+// "SetUserPassword".
+type DomainSetUserPasswordFlag uint32
+
+// Possible values for DomainSetUserPasswordFlag.
+const (
+	DomSetUserPasswordDefault   DomainSetUserPasswordFlag = 0
+	DomSetUserPasswordEncrypted DomainSetUserPasswordFlag = C.VIR_DOMAIN_PASSWORD_ENCRYPTED // the password is already hashed
+)
+
+// DomainSetTimeFlag defines how the guest time is set by "SetTime".
+type DomainSetTimeFlag uint32
+
+// Possible values for DomainSetTimeFlag.
+const (
+	DomSetTimeDefault DomainSetTimeFlag = 0
+	DomSetTimeSync    DomainSetTimeFlag = C.VIR_DOMAIN_TIME_SYNC // resync the time from the domain's RTC, ignoring the given time
+)
+
 // DomainKeycodeSet defines a code set of keycodes.
 type DomainKeycodeSet uint32
 
@@ -303,8 +897,13 @@ const (
 	DomKeycodeSetUSB    DomainKeycodeSet = C.VIR_KEYCODE_SET_USB
 	DomKeycodeSetWin32  DomainKeycodeSet = C.VIR_KEYCODE_SET_WIN32
 	DomKeycodeSetRFB    DomainKeycodeSet = C.VIR_KEYCODE_SET_RFB
+	DomKeycodeSetQNUM   DomainKeycodeSet = C.VIR_KEYCODE_SET_QNUM
 )
 
+// DomSendKeyMaxKeys is the maximum number of keycodes which can be sent at
+// once by "SendKey".
+const DomSendKeyMaxKeys = C.VIR_DOMAIN_SEND_KEY_MAX_KEYS
+
 // DomainProcessSignal defines the valid signals which can be sent to a domain.
 type DomainProcessSignal uint32
 
@@ -377,6 +976,122 @@ const (
 	DomSIGRT32   DomainProcessSignal = C.VIR_DOMAIN_PROCESS_SIGNAL_RT32
 )
 
+// ErrDomainWithoutID is returned by "ID" when the domain isn't running, so it
+// doesn't have an ID.
+var ErrDomainWithoutID = errors.New("domain doesn't have an ID")
+
+// ErrInvalidKeycodeCount is returned by "SendKey" when no keycode or more than
+// DomSendKeyMaxKeys keycodes are specified.
+var ErrInvalidKeycodeCount = errors.New("invalid number of keycodes")
+
+// ErrEmptyCPUMap is returned by "PinVCPU", "PinEmulator" and "PinIOThread"
+// when no CPU is selected in the CPU map.
+var ErrEmptyCPUMap = errors.New("no CPU is selected in the CPU map")
+
+// ErrInvalidStartCPU is returned by "CPUStatsPerCPU" when the first host CPU
+// is negative.
+var ErrInvalidStartCPU = errors.New("the start CPU must not be negative")
+
+// DomainIOThreadInfo holds the information about an I/O thread of a domain.
+type DomainIOThreadInfo struct {
+	ID   uint32 // I/O thread ID
+	CPUs []bool // CPU affinity, indexed by the host CPU number
+}
+
+// DomainBlockStats holds the I/O statistics of a domain disk. The times are
+// cumulative, in nanoseconds. Any field reported by the driver which doesn't
+// have a corresponding struct field is stored in "Extra".
+type DomainBlockStats struct {
+	RdReq           int64 // number of read requests
+	RdBytes         int64 // number of read bytes
+	WrReq           int64 // number of write requests
+	WrBytes         int64 // number of written bytes
+	FlushReq        int64 // number of flush requests
+	RdTotalTimes    int64 // time spent reading
+	WrTotalTimes    int64 // time spent writing
+	FlushTotalTimes int64 // time spent flushing
+	Extra           map[string]int64
+}
+
+// DomainInterfaceStats holds the traffic statistics of a domain network
+// interface, from the guest's point of view. The fields which aren't provided
+// by the driver are nil.
+type DomainInterfaceStats struct {
+	RxBytes   *int64 // number of received bytes
+	RxPackets *int64 // number of received packets
+	RxErrs    *int64 // number of receive errors
+	RxDrop    *int64 // number of dropped received packets
+	TxBytes   *int64 // number of transmitted bytes
+	TxPackets *int64 // number of transmitted packets
+	TxErrs    *int64 // number of transmit errors
+	TxDrop    *int64 // number of dropped transmitted packets
+}
+
+// DomMemoryStatsAll can be used as the maximum number of statistics in
+// "MemoryStats" to read all the statistics known by libvirt.
+const DomMemoryStatsAll = C.VIR_DOMAIN_MEMORY_STAT_NR
+
+// DomainMemoryStats holds the memory statistics of a domain. The memory sizes
+// are in kiB. The statistics depend on the hypervisor, on the balloon driver
+// in the guest and on the collection period (see "SetMemoryStatsPeriod"); the
+// fields which aren't reported are nil.
+type DomainMemoryStats struct {
+	SwapIn         *uint64 // memory swapped in
+	SwapOut        *uint64 // memory swapped out
+	MajorFault     *uint64 // number of page faults which required disk I/O
+	MinorFault     *uint64 // number of page faults which didn't require disk I/O
+	Unused         *uint64 // memory left completely unused by the guest
+	Available      *uint64 // memory usable by the guest
+	ActualBalloon  *uint64 // current balloon size
+	RSS            *uint64 // resident set size of the hypervisor process
+	Usable         *uint64 // memory which can be reclaimed by the guest without swapping
+	LastUpdate     *uint64 // timestamp of the last update, in seconds
+	DiskCaches     *uint64 // memory which can be reclaimed by the guest from its disk caches
+	HugetlbPgAlloc *uint64 // number of successful huge page allocations in the guest
+	HugetlbPgFail  *uint64 // number of failed huge page allocations in the guest
+}
+
+// DomainCPUStats holds the CPU statistics of a domain, either in total or on a
+// single host CPU. All values are cumulative times, in nanoseconds. The user
+// and system times are only reported in total and the VCPU time is only
+// reported per host CPU. Any field reported by the driver which doesn't have a
+// corresponding struct field is stored in "Extra".
+type DomainCPUStats struct {
+	CPUTime    uint64 // CPU time used by the domain
+	UserTime   uint64 // CPU time used in user mode
+	SystemTime uint64 // CPU time used in kernel mode
+	VCPUTime   uint64 // CPU time used by the VCPUs, excluding the hypervisor
+	Extra      map[string]uint64
+}
+
+// DomainVCPUInfo holds the runtime information about a VCPU of a domain.
+type DomainVCPUInfo struct {
+	Number  uint32          // VCPU number
+	State   DomainVCPUState // VCPU state
+	CPUTime uint64          // CPU time used, in nanoseconds
+	CPU     int32           // host CPU on which the VCPU last ran
+	CPUs    []bool          // CPU affinity, indexed by the host CPU number
+}
+
+// DomainJobInfo holds the progress of the active domain job. The times are in
+// milliseconds and the sizes are in bytes. The data fields are the sum of the
+// memory and file fields. All fields but "Type" are zero when no job is
+// active.
+type DomainJobInfo struct {
+	Type          DomainJobType // job type
+	TimeElapsed   uint64        // time since the job started
+	TimeRemaining uint64        // estimated time until the job finishes, only for bounded jobs
+	DataTotal     uint64        // total amount of data
+	DataProcessed uint64        // amount of data already processed
+	DataRemaining uint64        // amount of data still to be processed
+	MemTotal      uint64        // total amount of memory
+	MemProcessed  uint64        // amount of memory already processed
+	MemRemaining  uint64        // amount of memory still to be processed
+	FileTotal     uint64        // total amount of file data
+	FileProcessed uint64        // amount of file data already processed
+	FileRemaining uint64        // amount of file data still to be processed
+}
+
 // Domain holds a libvirt domain. There are no exported fields.
 type Domain struct {
 	log       *log.Logger
@@ -386,6 +1101,9 @@ type Domain struct {
 // Free frees the domain object. The running instance is kept alive. The data
 // structure is freed and should not be used thereafter.
 func (dom Domain) Free() error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	dom.log.Println("freeing domain object...")
 	cRet := C.virDomainFree(dom.virDomain)
 	ret := int32(cRet)
@@ -401,9 +1119,12 @@ func (dom Domain) Free() error {
 	return nil
 }
 
-// Autostart provides a boolean value indicating whether the domain configured
-// to be automatically started when the host machine boots.
+// Autostart provides a boolean value indicating whether the domain is
+// configured to be automatically started when the host machine boots.
 func (dom Domain) Autostart() (bool, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	var cAutostart C.int
 	dom.log.Println("checking whether domain autostarts...")
 	cRet := C.virDomainGetAutostart(dom.virDomain, &cAutostart)
@@ -428,6 +1149,9 @@ func (dom Domain) Autostart() (bool, error) {
 
 // HasCurrentSnapshot determines if the domain has a current snapshot.
 func (dom Domain) HasCurrentSnapshot() (bool, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	dom.log.Println("checking whether domain has current snapshot...")
 	cRet := C.virDomainHasCurrentSnapshot(dom.virDomain, 0)
 	ret := int32(cRet)
@@ -453,6 +1177,9 @@ func (dom Domain) HasCurrentSnapshot() (bool, error) {
 // by ManagedSave(). Note that any running domain should not have such an
 // image, as it should have been removed on restart.
 func (dom Domain) HasManagedSaveImage() (bool, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	dom.log.Println("checking whether domain has managed save...")
 	cRet := C.virDomainHasManagedSaveImage(dom.virDomain, 0)
 	ret := int32(cRet)
@@ -476,6 +1203,9 @@ func (dom Domain) HasManagedSaveImage() (bool, error) {
 
 // IsActive determines if the domain is currently running.
 func (dom Domain) IsActive() (bool, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	dom.log.Println("checking whether domain is active...")
 	cRet := C.virDomainIsActive(dom.virDomain)
 	ret := int32(cRet)
@@ -498,8 +1228,11 @@ func (dom Domain) IsActive() (bool, error) {
 }
 
 // IsPersistent determines if the domain has a persistent configuration which
-// means it will still exist after shutting down
+// means it will still exist after shutting down.
 func (dom Domain) IsPersistent() (bool, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	dom.log.Println("checking whether domain is persistent...")
 	cRet := C.virDomainIsPersistent(dom.virDomain)
 	ret := int32(cRet)
@@ -521,8 +1254,13 @@ func (dom Domain) IsPersistent() (bool, error) {
 	return persistent, nil
 }
 
-// IsUpdated determines if the domain has been updated.
+// IsUpdated determines if the domain has been updated, i.e. if the live
+// configuration of a running domain differs from its persistent configuration
+// (e.g. after a device has been attached only with DomDeviceModifyLive).
 func (dom Domain) IsUpdated() (bool, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	dom.log.Println("checking whether domain is updated...")
 	cRet := C.virDomainIsUpdated(dom.virDomain)
 	ret := int32(cRet)
@@ -546,6 +1284,9 @@ func (dom Domain) IsUpdated() (bool, error) {
 
 // OSType gets the type of domain operation system.
 func (dom Domain) OSType() (string, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	dom.log.Println("reading domain OS type...")
 	cOS := C.virDomainGetOSType(dom.virDomain)
 	if cOS == nil {
@@ -563,6 +1304,9 @@ func (dom Domain) OSType() (string, error) {
 
 // Name gets the public name for that domain.
 func (dom Domain) Name() (string, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	dom.log.Println("reading domain name...")
 	cName := C.virDomainGetName(dom.virDomain)
 
@@ -578,8 +1322,37 @@ func (dom Domain) Name() (string, error) {
 	return name, nil
 }
 
+// Rename changes the name of a defined domain, which must be inactive. If the
+// domain is running or if there's already another domain with the new name, a
+// libvirt error is returned. "flags" is currently unused by libvirt and should
+// be 0.
+func (dom Domain) Rename(newName string, flags uint32) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	cNewName := C.CString(newName)
+	defer C.free(unsafe.Pointer(cNewName))
+
+	dom.log.Printf("renaming domain to %v (flags = %v)...\n", newName, flags)
+	cRet := C.virDomainRename(dom.virDomain, cNewName, C.uint(flags))
+	ret := int32(cRet)
+
+	if ret == -1 {
+		err := LastError()
+		dom.log.Printf("an error occurred: %v\n", err)
+		return err
+	}
+
+	dom.log.Println("domain renamed")
+
+	return nil
+}
+
 // Hostname gets the hostname for that domain.
 func (dom Domain) Hostname() (string, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	dom.log.Println("reading domain hostname...")
 	cHostname := C.virDomainGetHostname(dom.virDomain, 0)
 	if cHostname == nil {
@@ -595,14 +1368,15 @@ func (dom Domain) Hostname() (string, error) {
 	return hostname, nil
 }
 
-// ID gets the hypervisor ID number for the domain.
+// ID gets the hypervisor ID number for the domain. Only running domains have
+// an ID; for the other ones, ErrDomainWithoutID is returned.
 func (dom Domain) ID() (uint32, error) {
 	dom.log.Println("reading domain ID...")
 	cID := C.virDomainGetID(dom.virDomain)
 	id := uint32(cID)
 
 	if id == ^uint32(0) { // Go: ^uint32(0) == C: (unsigned int) -1
-		err := errors.New("domain doesn't have an ID")
+		err := ErrDomainWithoutID
 		dom.log.Printf("an error occurred: %v\n", err)
 		return 0, err
 	}
@@ -615,6 +1389,9 @@ func (dom Domain) ID() (uint32, error) {
 // UUID gets the UUID for a domain as string. For more information about UUID
 // see RFC4122.
 func (dom Domain) UUID() (string, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	cUUID := (*C.char)(C.malloc(C.size_t(C.VIR_UUID_STRING_BUFLEN)))
 	defer C.free(unsafe.Pointer(cUUID))
 
@@ -637,6 +1414,9 @@ func (dom Domain) UUID() (string, error) {
 // XML provides an XML description of the domain. The description may be reused
 // later to relaunch the domain with CreateXML().
 func (dom Domain) XML(typ DomainXMLFlag) (string, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	dom.log.Printf("reading domain XML (flags = %v)...\n", typ)
 	cXML := C.virDomainGetXMLDesc(dom.virDomain, C.uint(typ))
 	if cXML == nil {
@@ -652,10 +1432,17 @@ func (dom Domain) XML(typ DomainXMLFlag) (string, error) {
 	return xml, nil
 }
 
-// Metadata retrieves the appropriate domain element given by "type".
+// Metadata retrieves the appropriate domain element given by "type". For
+// DomMetaDescription and DomMetaTitle, "xmlns" is irrelevant and must be empty.
 func (dom Domain) Metadata(typ DomainMetadataType, xmlns string, impact DomainModificationImpact) (string, error) {
-	cXMLNS := C.CString(xmlns)
-	defer C.free(unsafe.Pointer(cXMLNS))
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	var cXMLNS *C.char
+	if xmlns != "" {
+		cXMLNS = C.CString(xmlns)
+		defer C.free(unsafe.Pointer(cXMLNS))
+	}
 
 	dom.log.Printf("reading domain metadata (type = %v, namespace = %v, impact = %v)...\n", typ, xmlns, impact)
 	cMetadata := C.virDomainGetMetadata(dom.virDomain, C.int(typ), cXMLNS, C.uint(impact))
@@ -672,11 +1459,52 @@ func (dom Domain) Metadata(typ DomainMetadataType, xmlns string, impact DomainMo
 	return metadata, nil
 }
 
+// Title returns the short title of the domain, or an empty string if it
+// doesn't have one.
+func (dom Domain) Title(impact DomainModificationImpact) (string, error) {
+	return dom.optionalMetadata(DomMetaTitle, impact)
+}
+
+// SetTitle changes the short title of the domain, which can't contain
+// newlines. An empty title removes it.
+func (dom Domain) SetTitle(title string, impact DomainModificationImpact) error {
+	return dom.SetMetadata(DomMetaTitle, title, "", "", impact)
+}
+
+// Description returns the free-form description of the domain, or an empty
+// string if it doesn't have one.
+func (dom Domain) Description(impact DomainModificationImpact) (string, error) {
+	return dom.optionalMetadata(DomMetaDescription, impact)
+}
+
+// SetDescription changes the free-form description of the domain. An empty
+// description removes it.
+func (dom Domain) SetDescription(description string, impact DomainModificationImpact) error {
+	return dom.SetMetadata(DomMetaDescription, description, "", "", impact)
+}
+
+// optionalMetadata retrieves the metadata "typ", returning an empty string
+// instead of an error if it isn't present.
+func (dom Domain) optionalMetadata(typ DomainMetadataType, impact DomainModificationImpact) (string, error) {
+	metadata, err := dom.Metadata(typ, "", impact)
+	if virErr, ok := err.(*Error); ok && virErr.Code == ErrNoDomainMetadata {
+		return "", nil
+	}
+
+	return metadata, err
+}
+
 // Destroy destroys the domain object. The running instance is shutdown if not
 // down already and all resources used by it are given back to the hypervisor.
 // This does not free the associated virDomainPtr object. This function may
-// require privileged access.
+// require privileged access. By default, the hypervisor process is killed
+// immediately; with DomDestroyGraceful, it's asked to terminate first, giving
+// it the chance to flush its disk caches. If the domain isn't running, the
+// returned error has the code ErrOperationInvalid.
 func (dom Domain) Destroy(flags DomainDestroyFlag) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	dom.log.Printf("destroying domain (flags = %v)...\n", flags)
 	cRet := C.virDomainDestroyFlags(dom.virDomain, C.uint(flags))
 	ret := int32(cRet)
@@ -693,8 +1521,16 @@ func (dom Domain) Destroy(flags DomainDestroyFlag) error {
 }
 
 // Create launches a defined domain. If the call succeeds the domain moves from
-// the defined to the running domains pools.
+// the defined to the running domains pools. The domain may be started paused
+// (DomCreatePaused), destroyed when the connection is closed
+// (DomCreateAutodestroy), without using the file system cache
+// (DomCreateBypassCache) or with a fresh boot even if it has a managed save
+// image (DomCreateForceBoot). If the domain is already running, the returned
+// error has the code ErrOperationInvalid.
 func (dom Domain) Create(flags DomainCreateFlag) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	dom.log.Printf("starting domain (flags = %v)...\n", flags)
 	cRet := C.virDomainCreateWithFlags(dom.virDomain, C.uint(flags))
 	ret := int32(cRet)
@@ -710,10 +1546,60 @@ func (dom Domain) Create(flags DomainCreateFlag) error {
 	return nil
 }
 
+// CreateWithFiles launches a defined domain, passing "files" to its init
+// process; the files will be available as the file descriptors 3, 4, etc. in
+// the same order. Not every driver supports passing files (e.g. only the LXC
+// driver does); in that case, an error is returned.
+func (dom Domain) CreateWithFiles(files []*os.File, flags DomainCreateFlag) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	cFDs := cFileDescriptors(files)
+
+	var cFDsPtr *C.int
+	if len(cFDs) > 0 {
+		cFDsPtr = &cFDs[0]
+	}
+
+	dom.log.Printf("starting domain with %v files (flags = %v)...\n", len(files), flags)
+	cRet := C.virDomainCreateWithFiles(dom.virDomain, C.uint(len(cFDs)), cFDsPtr, C.uint(flags))
+	ret := int32(cRet)
+
+	// the files must not be closed by the garbage collector before libvirt
+	// has used their descriptors
+	runtime.KeepAlive(files)
+
+	if ret == -1 {
+		err := LastError()
+		dom.log.Printf("an error occurred: %v\n", err)
+		return err
+	}
+
+	dom.log.Println("domain started")
+
+	return nil
+}
+
+// cFileDescriptors converts "files" into an array of C file descriptors. The
+// files must be kept alive while the descriptors are used.
+func cFileDescriptors(files []*os.File) []C.int {
+	cFDs := make([]C.int, len(files))
+	for i, file := range files {
+		cFDs[i] = C.int(file.Fd())
+	}
+
+	return cFDs
+}
+
 // Undefine undefines a domain. If the domain is running, it's converted to
 // transient domain, without stopping it. If the domain is inactive, the domain
-// configuration is removed.
+// configuration is removed. Undefining a domain which has a managed save
+// image, snapshot or checkpoint metadata or an NVRAM file fails unless the
+// corresponding flag is used; the returned error tells which one.
 func (dom Domain) Undefine(flags DomainUndefineFlag) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	dom.log.Printf("undefining domain (flags = %v)...\n", flags)
 	cRet := C.virDomainUndefineFlags(dom.virDomain, C.uint(flags))
 	ret := int32(cRet)
@@ -735,6 +1621,9 @@ func (dom Domain) Undefine(flags DomainUndefineFlag) error {
 // domain 'on_reboot' XML setting resulting in a domain that shuts down instead
 // of rebooting.
 func (dom Domain) Reboot(flags DomainRebootFlag) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	dom.log.Printf("rebooting domain (flags = %v)...\n", flags)
 	cRet := C.virDomainReboot(dom.virDomain, C.uint(flags))
 	ret := int32(cRet)
@@ -754,10 +1643,14 @@ func (dom Domain) Reboot(flags DomainRebootFlag) error {
 // emulates the power reset button on a machine, where all hardware sees the
 // RST line set and reinitializes internal state.
 // Note that there is a risk of data loss caused by reset without any guest
-// OS shutdown.
-func (dom Domain) Reset() error {
-	dom.log.Println("resetting domain...")
-	cRet := C.virDomainReset(dom.virDomain, 0)
+// OS shutdown. If the domain isn't running, the returned error has the code
+// ErrOperationInvalid. "flags" is currently unused by libvirt and should be 0.
+func (dom Domain) Reset(flags uint32) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	dom.log.Printf("resetting domain (flags = %v)...\n", flags)
+	cRet := C.virDomainReset(dom.virDomain, C.uint(flags))
 	ret := int32(cRet)
 
 	if ret == -1 {
@@ -781,6 +1674,9 @@ func (dom Domain) Reset() error {
 // as soon as the shutdown request is issued rather than blocking until the
 // guest is no longer running.
 func (dom Domain) Shutdown() error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	dom.log.Println("shutting down domain...")
 	cRet := C.virDomainShutdown(dom.virDomain)
 	ret := int32(cRet)
@@ -796,9 +1692,36 @@ func (dom Domain) Shutdown() error {
 	return nil
 }
 
+// ShutdownFlags shuts down a domain like Shutdown, using the methods selected
+// by "flags". If a method isn't available (e.g. DomShutdownGuestAgent without
+// a guest agent), an error is returned instead of falling back to another
+// method.
+func (dom Domain) ShutdownFlags(flags DomainShutdownFlag) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	dom.log.Printf("shutting down domain (flags = %v)...\n", flags)
+	cRet := C.virDomainShutdownFlags(dom.virDomain, C.uint(flags))
+	ret := int32(cRet)
+
+	if ret == -1 {
+		err := LastError()
+		dom.log.Printf("an error occurred: %v\n", err)
+		return err
+	}
+
+	dom.log.Println("domain shut down")
+
+	return nil
+}
+
 // State extracts domain state. Each state can be accompanied with a reason
-// (if known) which led to the state.
+// (if known) which led to the state; the reason must be converted to the type
+// matching the returned state (e.g. DomainPausedReason for DomStatePaused).
 func (dom Domain) State() (DomainState, int32, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	var cState, cReason C.int
 	dom.log.Println("reading domain state...")
 	cRet := C.virDomainGetState(dom.virDomain, &cState, &cReason, 0)
@@ -812,7 +1735,7 @@ func (dom Domain) State() (DomainState, int32, error) {
 
 	state := DomainState(cState)
 	reason := int32(cReason)
-	dom.log.Printf("state: %v (reason = %v)\n", state, reason)
+	dom.log.Printf("state: %v (reason = %v)\n", state, stateReasonString(state, reason))
 
 	return state, reason, nil
 }
@@ -823,6 +1746,9 @@ func (dom Domain) State() (DomainState, int32, error) {
 // This function may require privileged access. Moreover, suspend may not be
 // supported if domain is in some special state like DomStatePMSuspended.
 func (dom Domain) Suspend() error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	dom.log.Println("suspending domain...")
 	cRet := C.virDomainSuspend(dom.virDomain)
 	ret := int32(cRet)
@@ -843,6 +1769,9 @@ func (dom Domain) Suspend() error {
 // privileged access. Moreover, resume may not be supported if domain is in
 // some special state like DomStatePMSuspended.
 func (dom Domain) Resume() error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	dom.log.Println("resuming domain...")
 	cRet := C.virDomainResume(dom.virDomain)
 	ret := int32(cRet)
@@ -858,27 +1787,17 @@ func (dom Domain) Resume() error {
 	return nil
 }
 
-// CoreDump dumps the core of a domain on a given file for analysis. Note that
-// for remote Xen Daemon the file path will be interpreted in the remote host.
-// Hypervisors may require the user to manually ensure proper permissions on the
-// file named by "to".
-// "dumpformat" controls which format the dump will have. Not all hypervisors
-// are able to support all formats.
-// If "flags" includes DomDumpCrash, then leave the guest shut off with a
-// crashed state after the dump completes. If "flags" includes DomDumpLive, then
-// make the core dump while continuing to allow the guest to run; otherwise, the
-// guest is suspended during the dump. DomDumpReset flag forces reset of the
-// guest after dump. The above three flags are mutually exclusive.
-// Additionally, if "flags" includes DomDumpBypassCache, then libvirt will
-// attempt to bypass the file system cache while creating the file, or fail if
-// it cannot do so for the given system; this can allow less pressure on file
-// system cache, but also risks slowing saves to NFS.
-func (dom Domain) CoreDump(file string, format DomainDumpFormat, flags DomainDumpFlag) error {
-	cFile := C.CString(file)
-	defer C.free(unsafe.Pointer(cFile))
-
-	dom.log.Printf("dumping domain's core to file %v (format = %v, flags = %v)...", file, format, flags)
-	cRet := C.virDomainCoreDumpWithFormat(dom.virDomain, cFile, C.uint(format), C.uint(flags))
+// PMSuspendForDuration suspends the guest system to the power state "target",
+// for "duration" seconds (or until it's woken up, if zero). The guest agent
+// is required; if it's not available, a libvirt error is returned. After
+// being suspended, the domain has the state DomStatePMSuspended. "flags" is
+// currently unused by libvirt and should be 0.
+func (dom Domain) PMSuspendForDuration(target NodeSuspendTarget, duration uint64, flags uint32) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	dom.log.Printf("suspending domain's guest system (target = %v, duration = %v, flags = %v)...\n", target, duration, flags)
+	cRet := C.virDomainPMSuspendForDuration(dom.virDomain, C.uint(target), C.ulonglong(duration), C.uint(flags))
 	ret := int32(cRet)
 
 	if ret == -1 {
@@ -887,16 +1806,78 @@ func (dom Domain) CoreDump(file string, format DomainDumpFormat, flags DomainDum
 		return err
 	}
 
-	dom.log.Println("core dump saved")
+	dom.log.Println("domain's guest system suspended")
 
 	return nil
 }
 
-// Ref increments the reference count on the domain. For each additional call
-// to this method, there shall be a corresponding call to virDomainFree to
-// release the reference count, once the caller no longer needs the reference
+// PMWakeup wakes up a guest system suspended by PMSuspendForDuration. "flags"
+// is currently unused by libvirt and should be 0.
+func (dom Domain) PMWakeup(flags uint32) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	dom.log.Printf("waking up domain's guest system (flags = %v)...\n", flags)
+	cRet := C.virDomainPMWakeup(dom.virDomain, C.uint(flags))
+	ret := int32(cRet)
+
+	if ret == -1 {
+		err := LastError()
+		dom.log.Printf("an error occurred: %v\n", err)
+		return err
+	}
+
+	dom.log.Println("domain's guest system woken up")
+
+	return nil
+}
+
+// CoreDump dumps the core of a domain on a given file for analysis. Note that
+// the file path is interpreted on the hypervisor host; if the hypervisor
+// can't write to it, a libvirt error is returned.
+// Hypervisors may require the user to manually ensure proper permissions on the
+// file named by "to".
+// "dumpformat" controls which format the dump will have. Not all hypervisors
+// are able to support all formats.
+// If "flags" includes DomDumpCrash, then leave the guest shut off with a
+// crashed state after the dump completes. If "flags" includes DomDumpLive, then
+// make the core dump while continuing to allow the guest to run; otherwise, the
+// guest is suspended during the dump. DomDumpReset flag forces reset of the
+// guest after dump. The above three flags are mutually exclusive.
+// Additionally, if "flags" includes DomDumpBypassCache, then libvirt will
+// attempt to bypass the file system cache while creating the file, or fail if
+// it cannot do so for the given system; this can allow less pressure on file
+// system cache, but also risks slowing saves to NFS.
+func (dom Domain) CoreDump(file string, format DomainDumpFormat, flags DomainDumpFlag) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	cFile := C.CString(file)
+	defer C.free(unsafe.Pointer(cFile))
+
+	dom.log.Printf("dumping domain's core to file %v (format = %v, flags = %v)...\n", file, format, flags)
+	cRet := C.virDomainCoreDumpWithFormat(dom.virDomain, cFile, C.uint(format), C.uint(flags))
+	ret := int32(cRet)
+
+	if ret == -1 {
+		err := LastError()
+		dom.log.Printf("an error occurred: %v\n", err)
+		return err
+	}
+
+	dom.log.Println("core dump saved")
+
+	return nil
+}
+
+// Ref increments the reference count on the domain. For each additional call
+// to this method, there shall be a corresponding call to virDomainFree to
+// release the reference count, once the caller no longer needs the reference
 // to this object.
 func (dom Domain) Ref() error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	dom.log.Println("incrementing domain's reference count...")
 	cRet := C.virDomainRef(dom.virDomain)
 	ret := int32(cRet)
@@ -907,131 +1888,1891 @@ func (dom Domain) Ref() error {
 		return err
 	}
 
-	dom.log.Println("reference count incremented")
+	dom.log.Println("reference count incremented")
+
+	return nil
+}
+
+// MaxMemory retrieves the maximum amount of physical memory allocated to
+// a domain, in KiB.
+func (dom Domain) MaxMemory() (uint64, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	dom.log.Println("reading domain maximum memory...")
+	cRet := C.virDomainGetMaxMemory(dom.virDomain)
+	ret := uint64(cRet)
+
+	if ret == 0 {
+		err := LastError()
+		dom.log.Printf("an error occurred: %v\n", err)
+		return 0, err
+	}
+
+	dom.log.Printf("max memory: %v kiB\n", ret)
+
+	return ret, nil
+}
+
+// VCPUs queries the number of virtual CPUs used by the domain. Note that this
+// call may fail if the underlying virtualization hypervisor does not support
+// it. This function may require privileged access to the hypervisor.
+func (dom Domain) VCPUs(flags DomainVCPUsFlag) (int32, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	dom.log.Println("reading domain VCPUs count...")
+	cRet := C.virDomainGetVcpusFlags(dom.virDomain, C.uint(flags))
+	ret := int32(cRet)
+
+	if ret == -1 {
+		err := LastError()
+		dom.log.Printf("an error occurred: %v\n", err)
+		return 0, err
+	}
+
+	dom.log.Printf("VCPUs count: %v\n", ret)
+
+	return ret, nil
+}
+
+// MaxVCPUs provides the maximum number of virtual CPUs supported for the
+// domain. If the domain is inactive, this is the same as
+// VCPUs(DomVCPUsMaximum); otherwise, it's the limit of the running hypervisor.
+func (dom Domain) MaxVCPUs() (int32, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	dom.log.Println("reading domain maximum VCPUs count...")
+	cRet := C.virDomainGetMaxVcpus(dom.virDomain)
+	ret := int32(cRet)
+
+	if ret == -1 {
+		err := LastError()
+		dom.log.Printf("an error occurred: %v\n", err)
+		return 0, err
+	}
+
+	dom.log.Printf("maximum VCPUs count: %v\n", ret)
+
+	return ret, nil
+}
+
+// nodeCPUCount returns the number of CPUs of the domain's host, which is the
+// size of the CPU maps. The OS thread must be locked by the caller.
+func (dom Domain) nodeCPUCount() (int, error) {
+	cRet := C.virNodeGetCPUMap(C.virDomainGetConnect(dom.virDomain), nil, nil, 0)
+	ret := int32(cRet)
+
+	if ret == -1 {
+		return 0, LastError()
+	}
+
+	return int(ret), nil
+}
+
+// VCPUInfo provides the runtime information about the online VCPUs of the
+// running domain.
+func (dom Domain) VCPUInfo() ([]DomainVCPUInfo, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	dom.log.Println("reading domain VCPUs information...")
+	nCPUs, err := dom.nodeCPUCount()
+	if err != nil {
+		dom.log.Printf("an error occurred: %v\n", err)
+		return nil, err
+	}
+
+	cNVCPUs := C.virDomainGetVcpusFlags(dom.virDomain, C.VIR_DOMAIN_VCPU_LIVE)
+	if int32(cNVCPUs) == -1 {
+		err = LastError()
+		dom.log.Printf("an error occurred: %v\n", err)
+		return nil, err
+	}
+
+	if cNVCPUs == 0 {
+		dom.log.Println("no VCPUs available")
+		return []DomainVCPUInfo{}, nil
+	}
+
+	mapLen := cpuMapLen(nCPUs)
+	cInfo := make([]C.virVcpuInfo, cNVCPUs)
+	cpuMaps := make([]byte, int(cNVCPUs)*mapLen)
+
+	cRet := C.virDomainGetVcpus(dom.virDomain, &cInfo[0], cNVCPUs, (*C.uchar)(unsafe.Pointer(&cpuMaps[0])), C.int(mapLen))
+	ret := int32(cRet)
+
+	if ret == -1 {
+		err = LastError()
+		dom.log.Printf("an error occurred: %v\n", err)
+		return nil, err
+	}
+
+	info := make([]DomainVCPUInfo, ret)
+	for i := range info {
+		info[i] = DomainVCPUInfo{
+			Number:  uint32(cInfo[i].number),
+			State:   DomainVCPUState(cInfo[i].state),
+			CPUTime: uint64(cInfo[i].cpuTime),
+			CPU:     int32(cInfo[i].cpu),
+			CPUs:    cpuMapFromBytes(cpuMaps[i*mapLen:(i+1)*mapLen], nCPUs),
+		}
+	}
+
+	dom.log.Printf("VCPUs information: %+v\n", info)
+
+	return info, nil
+}
+
+// PinVCPU pins the virtual CPU "vcpu" of the domain to the host CPUs selected
+// in "cpus", which has one element per host CPU, indexed by the CPU number.
+// The missing CPUs at the end of "cpus" aren't selected. At least one CPU must
+// be selected, otherwise ErrEmptyCPUMap is returned.
+func (dom Domain) PinVCPU(vcpu uint32, cpus []bool, impact DomainModificationImpact) error {
+	if isEmptyCPUMap(cpus) {
+		return ErrEmptyCPUMap
+	}
+
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	cpuMap := cpuMapToBytes(cpus)
+
+	dom.log.Printf("pinning domain VCPU %v to CPUs %v (impact = %v)...\n", vcpu, cpus, impact)
+	cRet := C.virDomainPinVcpuFlags(dom.virDomain, C.uint(vcpu), (*C.uchar)(unsafe.Pointer(&cpuMap[0])), C.int(len(cpuMap)), C.uint(impact))
+	ret := int32(cRet)
+
+	if ret == -1 {
+		err := LastError()
+		dom.log.Printf("an error occurred: %v\n", err)
+		return err
+	}
+
+	dom.log.Println("VCPU pinned")
+
+	return nil
+}
+
+// VCPUPinInfo queries the CPU affinity of every virtual CPU of the domain. The
+// returned slice has one CPU map per VCPU, indexed by the VCPU number; each
+// CPU map has one element per host CPU, indexed by the CPU number.
+func (dom Domain) VCPUPinInfo(impact DomainModificationImpact) ([][]bool, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	dom.log.Printf("reading domain VCPUs pinning (impact = %v)...\n", impact)
+	nCPUs, err := dom.nodeCPUCount()
+	if err != nil {
+		dom.log.Printf("an error occurred: %v\n", err)
+		return nil, err
+	}
+
+	cNVCPUs := C.virDomainGetVcpusFlags(dom.virDomain, C.uint(impact)|C.VIR_DOMAIN_VCPU_MAXIMUM)
+	if int32(cNVCPUs) == -1 {
+		err = LastError()
+		dom.log.Printf("an error occurred: %v\n", err)
+		return nil, err
+	}
+
+	if cNVCPUs == 0 {
+		dom.log.Println("no VCPUs available")
+		return [][]bool{}, nil
+	}
+
+	mapLen := cpuMapLen(nCPUs)
+	cpuMaps := make([]byte, int(cNVCPUs)*mapLen)
+
+	cRet := C.virDomainGetVcpuPinInfo(dom.virDomain, cNVCPUs, (*C.uchar)(unsafe.Pointer(&cpuMaps[0])), C.int(mapLen), C.uint(impact))
+	ret := int32(cRet)
+
+	if ret == -1 {
+		err = LastError()
+		dom.log.Printf("an error occurred: %v\n", err)
+		return nil, err
+	}
+
+	pinning := make([][]bool, ret)
+	for i := range pinning {
+		pinning[i] = cpuMapFromBytes(cpuMaps[i*mapLen:(i+1)*mapLen], nCPUs)
+	}
+
+	dom.log.Printf("VCPUs pinning: %v\n", pinning)
+
+	return pinning, nil
+}
+
+// PinEmulator pins the emulator threads of the domain (i.e. the threads which
+// aren't VCPUs nor I/O threads, like QEMU's main loop) to the host CPUs
+// selected in "cpus", which has one element per host CPU, indexed by the CPU
+// number. At least one CPU must be selected, otherwise ErrEmptyCPUMap is
+// returned.
+func (dom Domain) PinEmulator(cpus []bool, impact DomainModificationImpact) error {
+	if isEmptyCPUMap(cpus) {
+		return ErrEmptyCPUMap
+	}
+
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	cpuMap := cpuMapToBytes(cpus)
+
+	dom.log.Printf("pinning domain emulator threads to CPUs %v (impact = %v)...\n", cpus, impact)
+	cRet := C.virDomainPinEmulator(dom.virDomain, (*C.uchar)(unsafe.Pointer(&cpuMap[0])), C.int(len(cpuMap)), C.uint(impact))
+	ret := int32(cRet)
+
+	if ret == -1 {
+		err := LastError()
+		dom.log.Printf("an error occurred: %v\n", err)
+		return err
+	}
+
+	dom.log.Println("emulator threads pinned")
+
+	return nil
+}
+
+// EmulatorPinInfo queries the CPU affinity of the emulator threads of the
+// domain. The returned CPU map has one element per host CPU, indexed by the
+// CPU number.
+func (dom Domain) EmulatorPinInfo(impact DomainModificationImpact) ([]bool, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	dom.log.Printf("reading domain emulator threads pinning (impact = %v)...\n", impact)
+	nCPUs, err := dom.nodeCPUCount()
+	if err != nil {
+		dom.log.Printf("an error occurred: %v\n", err)
+		return nil, err
+	}
+
+	cpuMap := make([]byte, cpuMapLen(nCPUs))
+
+	cRet := C.virDomainGetEmulatorPinInfo(dom.virDomain, (*C.uchar)(unsafe.Pointer(&cpuMap[0])), C.int(len(cpuMap)), C.uint(impact))
+	ret := int32(cRet)
+
+	if ret == -1 {
+		err = LastError()
+		dom.log.Printf("an error occurred: %v\n", err)
+		return nil, err
+	}
+
+	cpus := cpuMapFromBytes(cpuMap, nCPUs)
+
+	dom.log.Printf("emulator threads pinning: %v\n", cpus)
+
+	return cpus, nil
+}
+
+// IOThreadInfo queries the I/O threads of the domain, along with their CPU
+// affinity.
+func (dom Domain) IOThreadInfo(impact DomainModificationImpact) ([]DomainIOThreadInfo, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	dom.log.Printf("reading domain I/O threads (impact = %v)...\n", impact)
+	nCPUs, err := dom.nodeCPUCount()
+	if err != nil {
+		dom.log.Printf("an error occurred: %v\n", err)
+		return nil, err
+	}
+
+	var cInfo *C.virDomainIOThreadInfoPtr
+	cRet := C.virDomainGetIOThreadInfo(dom.virDomain, &cInfo, C.uint(impact))
+	ret := int32(cRet)
+
+	if ret == -1 {
+		err = LastError()
+		dom.log.Printf("an error occurred: %v\n", err)
+		return nil, err
+	}
+	defer C.free(unsafe.Pointer(cInfo))
+
+	var cInfoSlice []C.virDomainIOThreadInfoPtr
+	infoSH := (*reflect.SliceHeader)(unsafe.Pointer(&cInfoSlice))
+	infoSH.Data = uintptr(unsafe.Pointer(cInfo))
+	infoSH.Len = int(ret)
+	infoSH.Cap = int(ret)
+
+	threads := make([]DomainIOThreadInfo, len(cInfoSlice))
+	for i, cThread := range cInfoSlice {
+		cpuMap := C.GoBytes(unsafe.Pointer(cThread.cpumap), cThread.cpumaplen)
+
+		threadCPUs := nCPUs
+		if max := len(cpuMap) * 8; threadCPUs > max {
+			threadCPUs = max
+		}
+
+		threads[i] = DomainIOThreadInfo{
+			ID:   uint32(cThread.iothread_id),
+			CPUs: cpuMapFromBytes(cpuMap, threadCPUs),
+		}
+
+		C.virDomainIOThreadInfoFree(cThread)
+	}
+
+	dom.log.Printf("I/O threads: %+v\n", threads)
+
+	return threads, nil
+}
+
+// PinIOThread pins the I/O thread "id" of the domain to the host CPUs selected
+// in "cpus", which has one element per host CPU, indexed by the CPU number.
+// At least one CPU must be selected, otherwise ErrEmptyCPUMap is returned.
+func (dom Domain) PinIOThread(id uint32, cpus []bool, impact DomainModificationImpact) error {
+	if isEmptyCPUMap(cpus) {
+		return ErrEmptyCPUMap
+	}
+
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	cpuMap := cpuMapToBytes(cpus)
+
+	dom.log.Printf("pinning domain I/O thread %v to CPUs %v (impact = %v)...\n", id, cpus, impact)
+	cRet := C.virDomainPinIOThread(dom.virDomain, C.uint(id), (*C.uchar)(unsafe.Pointer(&cpuMap[0])), C.int(len(cpuMap)), C.uint(impact))
+	ret := int32(cRet)
+
+	if ret == -1 {
+		err := LastError()
+		dom.log.Printf("an error occurred: %v\n", err)
+		return err
+	}
+
+	dom.log.Println("I/O thread pinned")
+
+	return nil
+}
+
+// AddIOThread adds the I/O thread "id" to the domain.
+func (dom Domain) AddIOThread(id uint32, impact DomainModificationImpact) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	dom.log.Printf("adding I/O thread %v to domain (impact = %v)...\n", id, impact)
+	cRet := C.virDomainAddIOThread(dom.virDomain, C.uint(id), C.uint(impact))
+	ret := int32(cRet)
+
+	if ret == -1 {
+		err := LastError()
+		dom.log.Printf("an error occurred: %v\n", err)
+		return err
+	}
+
+	dom.log.Println("I/O thread added")
+
+	return nil
+}
+
+// DelIOThread deletes the I/O thread "id" from the domain. An I/O thread which
+// is still used by a disk can't be deleted.
+func (dom Domain) DelIOThread(id uint32, impact DomainModificationImpact) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	dom.log.Printf("deleting I/O thread %v from domain (impact = %v)...\n", id, impact)
+	cRet := C.virDomainDelIOThread(dom.virDomain, C.uint(id), C.uint(impact))
+	ret := int32(cRet)
+
+	if ret == -1 {
+		err := LastError()
+		dom.log.Printf("an error occurred: %v\n", err)
+		return err
+	}
+
+	dom.log.Println("I/O thread deleted")
+
+	return nil
+}
+
+// SetIOThreadParams changes the parameters of the I/O thread "id", e.g. how long
+// it polls for new events before sleeping. If the I/O thread doesn't exist, an
+// error is returned.
+func (dom Domain) SetIOThreadParams(id uint32, params TypedParams, impact DomainModificationImpact) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	cParams, cNParams, err := params.cTypedParams()
+	if err != nil {
+		dom.log.Printf("an error occurred: %v\n", err)
+		return err
+	}
+	defer freeCTypedParams(cParams, cNParams)
+
+	dom.log.Printf("changing parameters of I/O thread %v to %v (impact = %v)...\n", id, params, impact)
+	cRet := C.virDomainSetIOThreadParams(dom.virDomain, C.uint(id), cParams, cNParams, C.uint(impact))
+	ret := int32(cRet)
+
+	if ret == -1 {
+		err := LastError()
+		dom.log.Printf("an error occurred: %v\n", err)
+		return err
+	}
+
+	dom.log.Println("I/O thread parameters changed")
+
+	return nil
+}
+
+// InfoState extracts the state of the domain.
+func (dom Domain) InfoState() (DomainState, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	var cInfo C.virDomainInfo
+	cRet := C.virDomainGetInfo(dom.virDomain, &cInfo)
+	ret := int32(cRet)
+
+	if ret == -1 {
+		return 0, LastError()
+	}
+
+	return DomainState(cInfo.state), nil
+}
+
+// InfoMaxMemory extracts the maximum memory in KiB allowed in the domain.
+func (dom Domain) InfoMaxMemory() (uint64, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	var cInfo C.virDomainInfo
+	cRet := C.virDomainGetInfo(dom.virDomain, &cInfo)
+	ret := int32(cRet)
+
+	if ret == -1 {
+		return 0, LastError()
+	}
+
+	return uint64(cInfo.maxMem), nil
+}
+
+// InfoMemory extracts the memory in KiB used by the domain.
+func (dom Domain) InfoMemory() (uint64, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	var cInfo C.virDomainInfo
+	cRet := C.virDomainGetInfo(dom.virDomain, &cInfo)
+	ret := int32(cRet)
+
+	if ret == -1 {
+		return 0, LastError()
+	}
+
+	return uint64(cInfo.memory), nil
+}
+
+// InfoVCPUs extracts the number of virtual CPUs for the domain.
+func (dom Domain) InfoVCPUs() (uint16, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	var cInfo C.virDomainInfo
+	cRet := C.virDomainGetInfo(dom.virDomain, &cInfo)
+	ret := int32(cRet)
+
+	if ret == -1 {
+		return 0, LastError()
+	}
+
+	return uint16(cInfo.nrVirtCpu), nil
+}
+
+// InfoCPUTime extracts the CPU time used in nanoseconds.
+func (dom Domain) InfoCPUTime() (uint64, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	var cInfo C.virDomainInfo
+	cRet := C.virDomainGetInfo(dom.virDomain, &cInfo)
+	ret := int32(cRet)
+
+	if ret == -1 {
+		return 0, LastError()
+	}
+
+	return uint64(cInfo.cpuTime), nil
+}
+
+// Save suspends a domain and save its memory contents to a file on disk. After
+// the call, if successful, the domain is not listed as running anymore (this
+// ends the life of a transient domain). Use Restore() to restore a domain
+// after saving. The file path is interpreted on the hypervisor host. If "xml"
+// isn't empty, it replaces the domain XML stored in the file, which will be
+// used when restoring; only host-specific details, such as disk paths, may be
+// changed. The flags DomSaveRunning and DomSavePaused set the state in which
+// the domain will be restored, and DomSaveBypassCache avoids the file system
+// cache.
+func (dom Domain) Save(to string, xml string, flags DomainSaveFlag) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	cTo := C.CString(to)
+	defer C.free(unsafe.Pointer(cTo))
+
+	var cXML *C.char
+	if xml != "" {
+		cXML = C.CString(xml)
+		defer C.free(unsafe.Pointer(cXML))
+	} else {
+		cXML = nil
+	}
+
+	dom.log.Printf("saving domain's memory to file %v (flags = %v)...\n", to, flags)
+	cRet := C.virDomainSaveFlags(dom.virDomain, cTo, cXML, C.uint(flags))
+	ret := int32(cRet)
+
+	if ret == -1 {
+		err := LastError()
+		dom.log.Printf("an error occurred: %v\n", err)
+		return err
+	}
+
+	dom.log.Println("domain saved")
+
+	return nil
+}
+
+// AttachDevice attaches a virtual device to a domain, using the flags
+// parameter to control how the device is attached. DomDeviceModifyCurrent
+// specifies that the device allocation is made based on current domain state.
+// DomDeviceModifyLive specifies that the device shall be allocated to the
+// active domain instance only and is not added to the persisted domain
+// configuration. DomDeviceModifyConfig specifies that the device shall be
+// allocated to the persisted domain configuration only. Note that the target
+// hypervisor must return an error if unable to satisfy flags. E.g. the
+// hypervisor driver will return failure if DomDeviceModifyLive is specified
+// but it only supports modifying the persisted device allocation. An invalid
+// device XML makes libvirt's parse error be returned.
+func (dom Domain) AttachDevice(deviceXML string, flags DomainDeviceModifyFlag) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	cXML := C.CString(deviceXML)
+	defer C.free(unsafe.Pointer(cXML))
+
+	dom.log.Printf("attaching a virtual device to domain (flags = %v)...\n", flags)
+	cRet := C.virDomainAttachDeviceFlags(dom.virDomain, cXML, C.uint(flags))
+	ret := int32(cRet)
+
+	if ret == -1 {
+		err := LastError()
+		dom.log.Printf("an error occurred: %v\n", err)
+		return err
+	}
+
+	dom.log.Println("device attached")
+
+	return nil
+}
+
+// DetachDevice detaches a virtual device from a domain, using the flags
+// parameter to control how the device is detached. DomDeviceModifyCurrent
+// specifies that the device allocation is removed based on current domain
+// state. DomDeviceModifyLive specifies that the device shall be deallocated
+// from the active domain instance only and is not from the persisted domain
+// configuration. DomDeviceModifyConfig specifies that the device shall be
+// deallocated from the persisted domain configuration only. Note that the
+// target hypervisor must return an error if unable to satisfy flags. E.g. the
+// hypervisor driver will return failure if DomDeviceModifyLive is specified
+// but it only supports removing the persisted device allocation.
+// Some hypervisors (e.g. QEMU) need the guest to cooperate in order to detach
+// a device from the active domain, so the device may still be present when
+// this function returns; the completion is signalled by the event registered
+// with Connection.RegisterDomainDeviceRemovedEvent.
+func (dom Domain) DetachDevice(deviceXML string, flags DomainDeviceModifyFlag) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	cXML := C.CString(deviceXML)
+	defer C.free(unsafe.Pointer(cXML))
+
+	dom.log.Printf("detaching a virtual device from domain (flags = %v)...\n", flags)
+	cRet := C.virDomainDetachDeviceFlags(dom.virDomain, cXML, C.uint(flags))
+	ret := int32(cRet)
+
+	if ret == -1 {
+		err := LastError()
+		dom.log.Printf("an error occurred: %v\n", err)
+		return err
+	}
+
+	dom.log.Println("device detached")
+
+	return nil
+}
+
+// DetachDeviceAlias detaches the virtual device identified by "alias" (the
+// name in the element <alias/> of the live domain XML) from a domain. It's
+// more reliable than DetachDevice, which needs an XML matching the device. The
+// flags and the asynchronous completion are the same as in DetachDevice.
+func (dom Domain) DetachDeviceAlias(alias string, flags DomainDeviceModifyFlag) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	cAlias := C.CString(alias)
+	defer C.free(unsafe.Pointer(cAlias))
+
+	dom.log.Printf("detaching a virtual device from domain (alias = %v, flags = %v)...\n", alias, flags)
+	cRet := C.virDomainDetachDeviceAlias(dom.virDomain, cAlias, C.uint(flags))
+	ret := int32(cRet)
+
+	if ret == -1 {
+		err := LastError()
+		dom.log.Printf("an error occurred: %v\n", err)
+		return err
+	}
+
+	dom.log.Println("device detached")
+
+	return nil
+}
+
+// UpdateDevice changes a virtual device on a domain, using the flags parameter
+// to control how the device is changed. DomDeviceModifyCurrent specifies that
+// the device change is made based on current domain state. DomDeviceModifyLive
+// specifies that the device shall be changed on the active domain instance
+// only and is not added to the persisted domain configuration.
+// DomDeviceModifyConfig specifies that the device shall be changed on the
+// persisted domain configuration only. Note that the target hypervisor must
+// return an error if unable to satisfy flags. E.g. the hypervisor driver will
+// return failure if DomDeviceModifyLive is specified but it only supports
+// modifying the persisted device allocation. DomDeviceModifyForce forces the
+// change, e.g. ejecting a CD-ROM media even if the guest has locked the tray.
+// Typical changes are swapping the media of a CD-ROM (<source/>) and changing
+// the link state of an interface (<link state="down"/>).
+func (dom Domain) UpdateDevice(deviceXML string, flags DomainDeviceModifyFlag) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	cXML := C.CString(deviceXML)
+	defer C.free(unsafe.Pointer(cXML))
+
+	dom.log.Printf("updating a virtual device on domain (flags = %v)...\n", flags)
+	cRet := C.virDomainUpdateDeviceFlags(dom.virDomain, cXML, C.uint(flags))
+	ret := int32(cRet)
+
+	if ret == -1 {
+		err := LastError()
+		dom.log.Printf("an error occurred: %v\n", err)
+		return err
+	}
+
+	dom.log.Println("device updated")
+
+	return nil
+}
+
+// SetAutostart configures the domain to be automatically started when the host
+// machine boots. Only persistent domains may be autostarted; an error is
+// returned for a transient domain.
+func (dom Domain) SetAutostart(autostart bool) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	var cAutostart C.int
+	if autostart {
+		dom.log.Println("enabling domain autostart...")
+		cAutostart = 1
+	} else {
+		dom.log.Println("disabling domain autostart...")
+		cAutostart = 0
+	}
+
+	cRet := C.virDomainSetAutostart(dom.virDomain, cAutostart)
+	ret := int32(cRet)
+
+	if ret == -1 {
+		err := LastError()
+		dom.log.Printf("an error occurred: %v\n", err)
+		return err
+	}
+
+	if autostart {
+		dom.log.Println("autostart enabled")
+	} else {
+		dom.log.Println("autostart disabled")
+	}
+
+	return nil
+}
+
+// SetMemory dynamically changes the target amount of physical memory allocated
+// to a domain, in KiB (not bytes, like every memory size of a domain). With
+// DomMemoryLive, the balloon driver of the running guest is asked to release
+// or claim memory, so the change may not be immediate; with DomMemoryConfig,
+// the persistent configuration is changed. DomMemoryMaximum changes the
+// maximum memory instead, which usually can't be done on a running domain.
+// Setting the memory to a value greater than the maximum memory returns an
+// error. This function may require privileged access to the hypervisor.
+func (dom Domain) SetMemory(memory uint64, flags DomainMemoryModifyFlag) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	dom.log.Printf("changing domain memory to %v kiB (flags = %v)...\n", memory, flags)
+	cRet := C.virDomainSetMemoryFlags(dom.virDomain, C.ulong(memory), C.uint(flags))
+	ret := int32(cRet)
+
+	if ret == -1 {
+		err := LastError()
+		dom.log.Printf("an error occurred: %v\n", err)
+		return err
+	}
+
+	dom.log.Println("memory changed")
+
+	return nil
+}
+
+// SetMaxMemory changes the maximum amount of physical memory allocated to the
+// domain, in KiB. Whether the live or the persistent configuration is changed
+// depends on the hypervisor; SetMemory with DomMemoryMaximum gives more
+// control. This function may require privileged access to the hypervisor.
+func (dom Domain) SetMaxMemory(memory uint64) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	dom.log.Printf("changing domain maximum memory to %v kiB...\n", memory)
+	cRet := C.virDomainSetMaxMemory(dom.virDomain, C.ulong(memory))
+	ret := int32(cRet)
+
+	if ret == -1 {
+		err := LastError()
+		dom.log.Printf("an error occurred: %v\n", err)
+		return err
+	}
+
+	dom.log.Println("maximum memory changed")
+
+	return nil
+}
+
+// MemoryStats provides up to "maxStats" memory statistics of the running
+// domain; DomMemoryStatsAll reads all of them. The statistics unknown to this
+// package are ignored. "flags" is currently unused by libvirt and should be 0.
+func (dom Domain) MemoryStats(maxStats uint, flags uint32) (DomainMemoryStats, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if maxStats == 0 {
+		dom.log.Println("no memory statistics requested")
+		return DomainMemoryStats{}, nil
+	}
+
+	cStats := make([]C.virDomainMemoryStatStruct, maxStats)
+
+	dom.log.Printf("reading memory statistics (max = %v, flags = %v)...\n", maxStats, flags)
+	cRet := C.virDomainMemoryStats(dom.virDomain, &cStats[0], C.uint(len(cStats)), C.uint(flags))
+	ret := int32(cRet)
+
+	if ret == -1 {
+		err := LastError()
+		dom.log.Printf("an error occurred: %v\n", err)
+		return DomainMemoryStats{}, err
+	}
+
+	var stats DomainMemoryStats
+
+	for _, cStat := range cStats[:ret] {
+		value := uint64(cStat.val)
+
+		switch cStat.tag {
+		case C.VIR_DOMAIN_MEMORY_STAT_SWAP_IN:
+			stats.SwapIn = &value
+		case C.VIR_DOMAIN_MEMORY_STAT_SWAP_OUT:
+			stats.SwapOut = &value
+		case C.VIR_DOMAIN_MEMORY_STAT_MAJOR_FAULT:
+			stats.MajorFault = &value
+		case C.VIR_DOMAIN_MEMORY_STAT_MINOR_FAULT:
+			stats.MinorFault = &value
+		case C.VIR_DOMAIN_MEMORY_STAT_UNUSED:
+			stats.Unused = &value
+		case C.VIR_DOMAIN_MEMORY_STAT_AVAILABLE:
+			stats.Available = &value
+		case C.VIR_DOMAIN_MEMORY_STAT_ACTUAL_BALLOON:
+			stats.ActualBalloon = &value
+		case C.VIR_DOMAIN_MEMORY_STAT_RSS:
+			stats.RSS = &value
+		case C.VIR_DOMAIN_MEMORY_STAT_USABLE:
+			stats.Usable = &value
+		case C.VIR_DOMAIN_MEMORY_STAT_LAST_UPDATE:
+			stats.LastUpdate = &value
+		case C.VIR_DOMAIN_MEMORY_STAT_DISK_CACHES:
+			stats.DiskCaches = &value
+		case C.VIR_DOMAIN_MEMORY_STAT_HUGETLB_PGALLOC:
+			stats.HugetlbPgAlloc = &value
+		case C.VIR_DOMAIN_MEMORY_STAT_HUGETLB_PGFAIL:
+			stats.HugetlbPgFail = &value
+		}
+	}
+
+	dom.log.Printf("memory statistics: %+v\n", stats)
+
+	return stats, nil
+}
+
+// CPUStatsTotal provides the CPU statistics of the running domain, summed over
+// all host CPUs. "flags" is currently unused by libvirt and should be 0.
+func (dom Domain) CPUStatsTotal(flags uint32) (DomainCPUStats, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	dom.log.Println("querying number of total CPU statistics...")
+	cRet := C.virDomainGetCPUStats(dom.virDomain, nil, 0, -1, 1, C.uint(flags))
+	ret := int32(cRet)
+
+	if ret == -1 {
+		err := LastError()
+		dom.log.Printf("an error occurred: %v\n", err)
+		return DomainCPUStats{}, err
+	}
+
+	if ret == 0 {
+		dom.log.Println("no CPU statistics available")
+		return newDomainCPUStats(TypedParams{}), nil
+	}
+
+	cNParams := C.uint(ret)
+	cParams := make([]C.virTypedParameter, cNParams)
+
+	dom.log.Printf("reading %v total CPU statistics...\n", cNParams)
+	cRet = C.virDomainGetCPUStats(dom.virDomain, &cParams[0], cNParams, -1, 1, C.uint(flags))
+	ret = int32(cRet)
+
+	if ret == -1 {
+		err := LastError()
+		dom.log.Printf("an error occurred: %v\n", err)
+		return DomainCPUStats{}, err
+	}
+	defer C.virTypedParamsClear(&cParams[0], C.int(cNParams))
+
+	params, err := newTypedParams(&cParams[0], C.int(ret))
+	if err != nil {
+		dom.log.Printf("an error occurred: %v\n", err)
+		return DomainCPUStats{}, err
+	}
+
+	stats := newDomainCPUStats(params)
+	dom.log.Printf("total CPU statistics: %+v\n", stats)
+
+	return stats, nil
+}
+
+// CPUStatsPerCPU provides the CPU statistics of the running domain on each of
+// "nCPUs" host CPUs, starting at the host CPU "startCPU". Fewer statistics are
+// returned if the host has fewer CPUs than requested, and the statistics of
+// the host CPUs which are offline are empty. libvirt doesn't accept more than
+// 128 CPUs in a single call. "flags" is currently unused by libvirt and should
+// be 0.
+func (dom Domain) CPUStatsPerCPU(startCPU int, nCPUs int, flags uint32) ([]DomainCPUStats, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if startCPU < 0 {
+		return nil, ErrInvalidStartCPU
+	}
+
+	dom.log.Println("querying number of host CPUs...")
+	cRet := C.virDomainGetCPUStats(dom.virDomain, nil, 0, 0, 0, C.uint(flags))
+	ret := int32(cRet)
+
+	if ret == -1 {
+		err := LastError()
+		dom.log.Printf("an error occurred: %v\n", err)
+		return nil, err
+	}
+
+	if maxCPUs := int(ret) - startCPU; nCPUs > maxCPUs {
+		nCPUs = maxCPUs
+	}
+
+	if nCPUs <= 0 {
+		dom.log.Println("no host CPU selected")
+		return []DomainCPUStats{}, nil
+	}
+
+	dom.log.Println("querying number of CPU statistics per CPU...")
+	cRet = C.virDomainGetCPUStats(dom.virDomain, nil, 0, C.int(startCPU), 1, C.uint(flags))
+	ret = int32(cRet)
+
+	if ret == -1 {
+		err := LastError()
+		dom.log.Printf("an error occurred: %v\n", err)
+		return nil, err
+	}
+
+	if ret == 0 {
+		dom.log.Println("no CPU statistics available")
+		return []DomainCPUStats{}, nil
+	}
+
+	cNParams := C.uint(ret)
+	cParams := make([]C.virTypedParameter, int(cNParams)*nCPUs)
+
+	dom.log.Printf("reading %v CPU statistics of %v CPUs starting at CPU %v...\n", cNParams, nCPUs, startCPU)
+	cRet = C.virDomainGetCPUStats(dom.virDomain, &cParams[0], cNParams, C.int(startCPU), C.uint(nCPUs), C.uint(flags))
+	ret = int32(cRet)
+
+	if ret == -1 {
+		err := LastError()
+		dom.log.Printf("an error occurred: %v\n", err)
+		return nil, err
+	}
+	defer C.virTypedParamsClear(&cParams[0], C.int(len(cParams)))
+
+	// the statistics of each CPU start every "cNParams" parameters, and up to
+	// "ret" of them are populated; libvirt leaves the parameters of the
+	// offline CPUs empty
+	stats := make([]DomainCPUStats, nCPUs)
+	for i := range stats {
+		cCPUParams := cParams[i*int(cNParams) : i*int(cNParams)+int(ret)]
+
+		n := 0
+		for n < len(cCPUParams) && cCPUParams[n]._type != 0 {
+			n++
+		}
+
+		if n == 0 {
+			stats[i] = newDomainCPUStats(TypedParams{})
+			continue
+		}
+
+		params, err := newTypedParams(&cCPUParams[0], C.int(n))
+		if err != nil {
+			dom.log.Printf("an error occurred: %v\n", err)
+			return nil, err
+		}
+
+		stats[i] = newDomainCPUStats(params)
+	}
+
+	dom.log.Printf("CPU statistics per CPU: %+v\n", stats)
+
+	return stats, nil
+}
+
+// newDomainCPUStats converts the typed parameters returned by
+// virDomainGetCPUStats into DomainCPUStats.
+func newDomainCPUStats(params TypedParams) DomainCPUStats {
+	stats := DomainCPUStats{
+		Extra: make(map[string]uint64),
+	}
+
+	for field, param := range params {
+		value, ok := param.(uint64)
+		if !ok {
+			continue
+		}
+
+		switch field {
+		case "cpu_time":
+			stats.CPUTime = value
+		case "user_time":
+			stats.UserTime = value
+		case "system_time":
+			stats.SystemTime = value
+		case "vcpu_time":
+			stats.VCPUTime = value
+		default:
+			stats.Extra[field] = value
+		}
+	}
+
+	return stats
+}
+
+// JobInfo provides the progress of the active job of the domain, e.g. a
+// migration, a save or a core dump. If no job is active, the job type is
+// DomJobNone.
+func (dom Domain) JobInfo() (DomainJobInfo, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	var cInfo C.virDomainJobInfo
+
+	dom.log.Println("reading domain job information...")
+	cRet := C.virDomainGetJobInfo(dom.virDomain, &cInfo)
+	ret := int32(cRet)
+
+	if ret == -1 {
+		err := LastError()
+		dom.log.Printf("an error occurred: %v\n", err)
+		return DomainJobInfo{}, err
+	}
+
+	info := DomainJobInfo{
+		Type:          DomainJobType(cInfo._type),
+		TimeElapsed:   uint64(cInfo.timeElapsed),
+		TimeRemaining: uint64(cInfo.timeRemaining),
+		DataTotal:     uint64(cInfo.dataTotal),
+		DataProcessed: uint64(cInfo.dataProcessed),
+		DataRemaining: uint64(cInfo.dataRemaining),
+		MemTotal:      uint64(cInfo.memTotal),
+		MemProcessed:  uint64(cInfo.memProcessed),
+		MemRemaining:  uint64(cInfo.memRemaining),
+		FileTotal:     uint64(cInfo.fileTotal),
+		FileProcessed: uint64(cInfo.fileProcessed),
+		FileRemaining: uint64(cInfo.fileRemaining),
+	}
+
+	dom.log.Printf("job information: %+v\n", info)
+
+	return info, nil
+}
+
+// JobStats provides the detailed statistics of the active job of the domain or,
+// with DomJobStatsCompleted, of its last completed job (e.g. the downtime of a
+// finished migration). All the statistics reported by the driver are returned,
+// including the ones without a DomJobStats* name. If there is no such job, the
+// job type is DomJobNone.
+func (dom Domain) JobStats(flags DomainJobStatsFlag) (DomainJobType, TypedParams, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	var cType C.int
+	var cParams C.virTypedParameterPtr
+	var cNParams C.int
+
+	dom.log.Printf("reading domain job statistics (flags = %v)...\n", flags)
+	cRet := C.virDomainGetJobStats(dom.virDomain, &cType, &cParams, &cNParams, C.uint(flags))
+	ret := int32(cRet)
+
+	if ret == -1 {
+		err := LastError()
+		dom.log.Printf("an error occurred: %v\n", err)
+		return 0, nil, err
+	}
+	defer C.virTypedParamsFree(cParams, cNParams)
+
+	typ := DomainJobType(cType)
+
+	params, err := newTypedParams(cParams, cNParams)
+	if err != nil {
+		dom.log.Printf("an error occurred: %v\n", err)
+		return 0, nil, err
+	}
+
+	dom.log.Printf("job statistics (type = %v): %v\n", typ, params)
+
+	return typ, params, nil
+}
+
+// AbortJob aborts the active job of the domain, e.g. a migration or a save,
+// which then fails. If no job is active, the returned error has the code
+// ErrOperationInvalid.
+func (dom Domain) AbortJob() error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	dom.log.Println("aborting domain job...")
+	cRet := C.virDomainAbortJob(dom.virDomain)
+	ret := int32(cRet)
+
+	if ret == -1 {
+		err := LastError()
+		dom.log.Printf("an error occurred: %v\n", err)
+		return err
+	}
+
+	dom.log.Println("job aborted")
+
+	return nil
+}
+
+// AbortJobFlags aborts the active job of the domain like AbortJob. With
+// DomAbortJobPostcopy, a migration in post-copy mode is interrupted, which
+// AbortJob refuses to do. This requires libvirt 8.5.0 or newer.
+func (dom Domain) AbortJobFlags(flags DomainAbortJobFlag) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	dom.log.Printf("aborting domain job (flags = %v)...\n", flags)
+	cRet := C.virDomainAbortJobFlags(dom.virDomain, C.uint(flags))
+	ret := int32(cRet)
+
+	if ret == -1 {
+		err := LastError()
+		dom.log.Printf("an error occurred: %v\n", err)
+		return err
+	}
+
+	dom.log.Println("job aborted")
+
+	return nil
+}
+
+// SetMemoryStatsPeriod changes the period, in seconds, in which the balloon
+// driver collects the guest memory statistics. The balloon driver only reports
+// the detailed statistics (e.g. unused, available) after a period has been
+// set; a period of 0 disables the collection. An error is returned if the
+// domain doesn't have a balloon device. DomMemoryMaximum can't be used here.
+func (dom Domain) SetMemoryStatsPeriod(period int, flags DomainMemoryModifyFlag) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	dom.log.Printf("changing memory statistics period to %v s (flags = %v)...\n", period, flags)
+	cRet := C.virDomainSetMemoryStatsPeriod(dom.virDomain, C.int(period), C.uint(flags))
+	ret := int32(cRet)
+
+	if ret == -1 {
+		err := LastError()
+		dom.log.Printf("an error occurred: %v\n", err)
+		return err
+	}
+
+	dom.log.Println("memory statistics period changed")
+
+	return nil
+}
+
+// SetMetadata sets the appropriate domain element given by "type" to the value
+// of "description". A "type" of DomMetaDescription is free-form text;
+// DomMetaTitle is free-form, but no newlines are permitted, and should be
+// short (although the length is not enforced). For these two options "key" and
+// "uri" are irrelevant and must be empty. An empty "metadata" removes the
+// element.
+func (dom Domain) SetMetadata(typ DomainMetadataType, metadata string, key string, uri string, impact DomainModificationImpact) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	cMetadata := C.CString(metadata)
+	defer C.free(unsafe.Pointer(cMetadata))
+
+	var cKey, cURI *C.char
+	if key != "" {
+		cKey = C.CString(key)
+		defer C.free(unsafe.Pointer(cKey))
+	}
+
+	if uri != "" {
+		cURI = C.CString(uri)
+		defer C.free(unsafe.Pointer(cURI))
+	}
+
+	dom.log.Printf("changing domain metadata key '<%v:%v>' (type = %v, impact = %v)...\n", key, uri, typ, impact)
+	cRet := C.virDomainSetMetadata(dom.virDomain, C.int(typ), cMetadata, cKey, cURI, C.uint(impact))
+	ret := int32(cRet)
+
+	if ret == -1 {
+		err := LastError()
+		dom.log.Printf("an error occurred: %v\n", err)
+		return err
+	}
+
+	dom.log.Println("metadata changed")
+
+	return nil
+}
+
+// SetVCPUs dynamically changes the number of virtual CPUs used by the domain.
+// Note that this call may fail if the underlying virtualization hypervisor
+// does not support it or if growing the number is arbitrary limited, e.g.
+// above the maximum number of VCPUs. DomVCPUsGuest changes the number of VCPUs
+// used by the guest system instead, which requires the guest agent.
+// DomVCPUsHotpluggable makes the new VCPUs hot(un)pluggable when they're added
+// to the persistent configuration. This function may require privileged
+// access to the hypervisor.
+func (dom Domain) SetVCPUs(vcpus uint32, flags DomainVCPUsFlag) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	dom.log.Printf("changing domain VCPUs count to %v (flags = %v)...\n", vcpus, flags)
+	cRet := C.virDomainSetVcpusFlags(dom.virDomain, C.uint(vcpus), C.uint(flags))
+	ret := int32(cRet)
+
+	if ret == -1 {
+		err := LastError()
+		dom.log.Printf("an error occurred: %v\n", err)
+		return err
+	}
+
+	dom.log.Println("VCPUs count changed")
+
+	return nil
+}
+
+// SetVCPU enables or disables the individual virtual CPUs of the domain listed
+// in "vcpuMap", which uses libvirt's syntax (e.g. "1-3,^2"). Unlike SetVCPUs,
+// which only adds or removes VCPUs from the end, any hotpluggable VCPU can be
+// changed; VCPU 0 can't be disabled.
+func (dom Domain) SetVCPU(vcpuMap string, enable bool, impact DomainModificationImpact) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	cVCPUMap := C.CString(vcpuMap)
+	defer C.free(unsafe.Pointer(cVCPUMap))
+
+	var cState C.int
+	if enable {
+		dom.log.Printf("enabling domain VCPUs %v (impact = %v)...\n", vcpuMap, impact)
+		cState = 1
+	} else {
+		dom.log.Printf("disabling domain VCPUs %v (impact = %v)...\n", vcpuMap, impact)
+		cState = 0
+	}
+
+	cRet := C.virDomainSetVcpu(dom.virDomain, cVCPUMap, cState, C.uint(impact))
+	ret := int32(cRet)
+
+	if ret == -1 {
+		err := LastError()
+		dom.log.Printf("an error occurred: %v\n", err)
+		return err
+	}
+
+	if enable {
+		dom.log.Println("VCPUs enabled")
+	} else {
+		dom.log.Println("VCPUs disabled")
+	}
+
+	return nil
+}
+
+// GuestVCPUs queries the state of the virtual CPUs from the guest system's
+// point of view, which requires the guest agent. If the guest agent isn't
+// configured or isn't connected, the returned error has the code
+// ErrArgumentUnsupported or ErrAgentUnresponsive, respectively.
+func (dom Domain) GuestVCPUs() (TypedParams, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	var cParams C.virTypedParameterPtr
+	var cNParams C.uint
+
+	dom.log.Println("reading guest VCPUs...")
+	cRet := C.virDomainGetGuestVcpus(dom.virDomain, &cParams, &cNParams, 0)
+	ret := int32(cRet)
+
+	if ret == -1 {
+		err := LastError()
+		dom.log.Printf("an error occurred: %v\n", err)
+		return nil, err
+	}
+	defer C.virTypedParamsFree(cParams, C.int(cNParams))
+
+	params, err := newTypedParams(cParams, C.int(cNParams))
+	if err != nil {
+		dom.log.Printf("an error occurred: %v\n", err)
+		return nil, err
+	}
+
+	dom.log.Printf("guest VCPUs: %v\n", params)
+
+	return params, nil
+}
+
+// SetGuestVCPUs sets the virtual CPUs listed in "vcpuMap" (in libvirt's
+// syntax, e.g. "1-3,^2") online or offline in the guest system, which
+// requires the guest agent. The guest agent errors are the same as in
+// GuestVCPUs.
+func (dom Domain) SetGuestVCPUs(vcpuMap string, online bool) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	cVCPUMap := C.CString(vcpuMap)
+	defer C.free(unsafe.Pointer(cVCPUMap))
+
+	var cState C.int
+	if online {
+		dom.log.Printf("setting guest VCPUs %v online...\n", vcpuMap)
+		cState = 1
+	} else {
+		dom.log.Printf("setting guest VCPUs %v offline...\n", vcpuMap)
+		cState = 0
+	}
+
+	cRet := C.virDomainSetGuestVcpus(dom.virDomain, cVCPUMap, cState, 0)
+	ret := int32(cRet)
+
+	if ret == -1 {
+		err := LastError()
+		dom.log.Printf("an error occurred: %v\n", err)
+		return err
+	}
+
+	dom.log.Println("guest VCPUs changed")
+
+	return nil
+}
+
+// ManagedSave suspends a domain and save its memory contents to a file on
+// disk. After the call, if successful, the domain is not listed as running
+// anymore. The difference from Save() is that libvirt is keeping track of the
+// saved state itself, and will reuse it once the domain is being restarted
+// (automatically or via an explicit libvirt call). As a result any running
+// domain is sure to not have a managed saved image. This also implies that
+// managed save only works on persistent domains, since the domain must still
+// exist in order to use Create() to restart it.
+func (dom Domain) ManagedSave(flags DomainSaveFlag) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	dom.log.Printf("saving domain's memory to a libvirt-managed location (flags = %v)...\n", flags)
+	cRet := C.virDomainManagedSave(dom.virDomain, C.uint(flags))
+	ret := int32(cRet)
+
+	if ret == -1 {
+		err := LastError()
+		dom.log.Printf("an error occurred: %v\n", err)
+		return err
+	}
+
+	dom.log.Println("domain saved")
+
+	return nil
+}
+
+// ManagedSaveRemove removes any managed save image for this domain.
+func (dom Domain) ManagedSaveRemove() error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	dom.log.Println("removing libvirt-managed domain save image...")
+	cRet := C.virDomainManagedSaveRemove(dom.virDomain, 0)
+	ret := int32(cRet)
+
+	if ret == -1 {
+		err := LastError()
+		dom.log.Printf("an error occurred: %v\n", err)
+		return err
+	}
+
+	dom.log.Println("save image removed")
+
+	return nil
+}
+
+// ManagedSaveXML provides an XML description of the domain stored in its
+// managed save image. The only flag which may be used is DomXMLSecure. If the
+// domain doesn't have a managed save image, an error is returned.
+func (dom Domain) ManagedSaveXML(flags DomainXMLFlag) (string, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	dom.log.Printf("reading XML of libvirt-managed domain save image (flags = %v)...\n", flags)
+	cXML := C.virDomainManagedSaveGetXMLDesc(dom.virDomain, C.uint(flags))
+
+	if cXML == nil {
+		err := LastError()
+		dom.log.Printf("an error occurred: %v\n", err)
+		return "", err
+	}
+	defer C.free(unsafe.Pointer(cXML))
+
+	xml := C.GoString(cXML)
+
+	dom.log.Printf("XML length: %v runes\n", utf8.RuneCountInString(xml))
+
+	return xml, nil
+}
+
+// ManagedSaveDefineXML replaces the domain XML stored in its managed save
+// image. Only host-specific details, such as disk paths, may be changed. The
+// flags DomSaveRunning and DomSavePaused change the state in which the domain
+// will be restored. If the domain doesn't have a managed save image, an error
+// is returned.
+func (dom Domain) ManagedSaveDefineXML(xml string, flags DomainSaveFlag) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	cXML := C.CString(xml)
+	defer C.free(unsafe.Pointer(cXML))
+
+	dom.log.Printf("updating XML of libvirt-managed domain save image (length = %v, flags = %v)...\n", utf8.RuneCountInString(xml), flags)
+	cRet := C.virDomainManagedSaveDefineXML(dom.virDomain, cXML, C.uint(flags))
+	ret := int32(cRet)
+
+	if ret == -1 {
+		err := LastError()
+		dom.log.Printf("an error occurred: %v\n", err)
+		return err
+	}
+
+	dom.log.Println("managed save image XML updated")
+
+	return nil
+}
+
+// SchedulerType gets the scheduler type of the domain (e.g. "posix") and the
+// number of its scheduler parameters.
+func (dom Domain) SchedulerType() (string, int, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	var cNParams C.int
+
+	dom.log.Println("reading domain scheduler type...")
+	cType := C.virDomainGetSchedulerType(dom.virDomain, &cNParams)
+
+	if cType == nil {
+		err := LastError()
+		dom.log.Printf("an error occurred: %v\n", err)
+		return "", 0, err
+	}
+	defer C.free(unsafe.Pointer(cType))
+
+	typ := C.GoString(cType)
+	nParams := int(cNParams)
+
+	dom.log.Printf("scheduler type: %v (%v parameters)\n", typ, nParams)
+
+	return typ, nParams, nil
+}
+
+// SchedulerParameters gets the scheduler parameters of the domain (e.g. the
+// CPU shares and the CFS quotas).
+func (dom Domain) SchedulerParameters(impact DomainModificationImpact) (TypedParams, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	// the number of parameters is provided by virDomainGetSchedulerType
+	dom.log.Printf("reading domain scheduler parameters (impact = %v)...\n", impact)
+	params, err := readTypedParams(func(cParams *C.virTypedParameter, cNParams *C.int) C.int {
+		if cParams == nil {
+			cType := C.virDomainGetSchedulerType(dom.virDomain, cNParams)
+			if cType == nil {
+				return -1
+			}
+			C.free(unsafe.Pointer(cType))
+
+			return 0
+		}
+
+		return C.virDomainGetSchedulerParametersFlags(dom.virDomain, cParams, cNParams, C.uint(impact))
+	})
+	if err != nil {
+		dom.log.Printf("an error occurred: %v\n", err)
+		return nil, err
+	}
+
+	dom.log.Printf("domain scheduler parameters: %v\n", params)
+
+	return params, nil
+}
+
+// SetSchedulerParameters changes the scheduler parameters of the domain. Only
+// the parameters in "params" are changed.
+func (dom Domain) SetSchedulerParameters(params TypedParams, impact DomainModificationImpact) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	cParams, cNParams, err := params.cTypedParams()
+	if err != nil {
+		dom.log.Printf("an error occurred: %v\n", err)
+		return err
+	}
+	defer freeCTypedParams(cParams, cNParams)
+
+	dom.log.Printf("changing domain scheduler parameters to %v (impact = %v)...\n", params, impact)
+	cRet := C.virDomainSetSchedulerParametersFlags(dom.virDomain, cParams, cNParams, C.uint(impact))
+	ret := int32(cRet)
+
+	if ret == -1 {
+		err := LastError()
+		dom.log.Printf("an error occurred: %v\n", err)
+		return err
+	}
+
+	dom.log.Println("domain scheduler parameters changed")
+
+	return nil
+}
+
+// MemoryParameters gets the memory tuning parameters of the domain (e.g. its
+// hard limit).
+func (dom Domain) MemoryParameters(impact DomainModificationImpact) (TypedParams, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	dom.log.Printf("reading domain memory parameters (impact = %v)...\n", impact)
+	params, err := readTypedParams(func(cParams *C.virTypedParameter, cNParams *C.int) C.int {
+		return C.virDomainGetMemoryParameters(dom.virDomain, cParams, cNParams, C.uint(impact))
+	})
+	if err != nil {
+		dom.log.Printf("an error occurred: %v\n", err)
+		return nil, err
+	}
+
+	dom.log.Printf("domain memory parameters: %v\n", params)
+
+	return params, nil
+}
+
+// SetMemoryParameters changes the memory tuning parameters of the domain. Only
+// the parameters in "params" are changed; DomMemoryParamUnlimited removes a
+// limit.
+func (dom Domain) SetMemoryParameters(params TypedParams, impact DomainModificationImpact) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	cParams, cNParams, err := params.cTypedParams()
+	if err != nil {
+		dom.log.Printf("an error occurred: %v\n", err)
+		return err
+	}
+	defer freeCTypedParams(cParams, cNParams)
+
+	dom.log.Printf("changing domain memory parameters to %v (impact = %v)...\n", params, impact)
+	cRet := C.virDomainSetMemoryParameters(dom.virDomain, cParams, cNParams, C.uint(impact))
+	ret := int32(cRet)
+
+	if ret == -1 {
+		err := LastError()
+		dom.log.Printf("an error occurred: %v\n", err)
+		return err
+	}
+
+	dom.log.Println("domain memory parameters changed")
+
+	return nil
+}
+
+// NUMAParameters gets the NUMA tuning parameters of the domain. The mode
+// should be converted with DomainNUMATuneMode(params[DomNUMAMode].(int32)).
+func (dom Domain) NUMAParameters(impact DomainModificationImpact) (TypedParams, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	dom.log.Printf("reading domain NUMA parameters (impact = %v)...\n", impact)
+	params, err := readTypedParams(func(cParams *C.virTypedParameter, cNParams *C.int) C.int {
+		return C.virDomainGetNumaParameters(dom.virDomain, cParams, cNParams, C.uint(impact))
+	})
+	if err != nil {
+		dom.log.Printf("an error occurred: %v\n", err)
+		return nil, err
+	}
+
+	dom.log.Printf("domain NUMA parameters: %v\n", params)
+
+	return params, nil
+}
+
+// SetNUMAParameters changes the NUMA tuning parameters of the domain. Only the
+// parameters in "params" are changed; the mode must be an int32 (e.g.
+// int32(DomNUMATuneModeStrict)). The mode of a running domain can't be
+// changed.
+func (dom Domain) SetNUMAParameters(params TypedParams, impact DomainModificationImpact) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	cParams, cNParams, err := params.cTypedParams()
+	if err != nil {
+		dom.log.Printf("an error occurred: %v\n", err)
+		return err
+	}
+	defer freeCTypedParams(cParams, cNParams)
+
+	dom.log.Printf("changing domain NUMA parameters to %v (impact = %v)...\n", params, impact)
+	cRet := C.virDomainSetNumaParameters(dom.virDomain, cParams, cNParams, C.uint(impact))
+	ret := int32(cRet)
+
+	if ret == -1 {
+		err := LastError()
+		dom.log.Printf("an error occurred: %v\n", err)
+		return err
+	}
+
+	dom.log.Println("domain NUMA parameters changed")
+
+	return nil
+}
+
+// PerfEvents gets which perf events are collected for the domain. The
+// counters of the enabled events of a running domain are reported by the
+// domain statistics.
+func (dom Domain) PerfEvents(impact DomainModificationImpact) (TypedParams, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	var cParams C.virTypedParameterPtr
+	var cNParams C.int
+
+	dom.log.Printf("reading domain perf events (impact = %v)...\n", impact)
+	cRet := C.virDomainGetPerfEvents(dom.virDomain, &cParams, &cNParams, C.uint(impact))
+	ret := int32(cRet)
+
+	if ret == -1 {
+		err := LastError()
+		dom.log.Printf("an error occurred: %v\n", err)
+		return nil, err
+	}
+	defer C.virTypedParamsFree(cParams, cNParams)
+
+	params, err := newTypedParams(cParams, cNParams)
+	if err != nil {
+		dom.log.Printf("an error occurred: %v\n", err)
+		return nil, err
+	}
+
+	dom.log.Printf("domain perf events: %v\n", params)
+
+	return params, nil
+}
+
+// SetPerfEvents enables or disables the collection of perf events for the
+// domain. Only the events in "params" are changed. Enabling an event on a
+// host without support for it makes libvirt's error be returned.
+func (dom Domain) SetPerfEvents(params TypedParams, impact DomainModificationImpact) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	cParams, cNParams, err := params.cTypedParams()
+	if err != nil {
+		dom.log.Printf("an error occurred: %v\n", err)
+		return err
+	}
+	defer freeCTypedParams(cParams, cNParams)
+
+	dom.log.Printf("changing domain perf events to %v (impact = %v)...\n", params, impact)
+	cRet := C.virDomainSetPerfEvents(dom.virDomain, cParams, cNParams, C.uint(impact))
+	ret := int32(cRet)
+
+	if ret == -1 {
+		err := LastError()
+		dom.log.Printf("an error occurred: %v\n", err)
+		return err
+	}
+
+	dom.log.Println("domain perf events changed")
+
+	return nil
+}
+
+// BlkioParameters gets the blkio cgroup parameters of the domain (e.g. its I/O
+// weight).
+func (dom Domain) BlkioParameters(impact DomainModificationImpact) (TypedParams, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	dom.log.Printf("reading domain blkio parameters (impact = %v)...\n", impact)
+	params, err := readTypedParams(func(cParams *C.virTypedParameter, cNParams *C.int) C.int {
+		return C.virDomainGetBlkioParameters(dom.virDomain, cParams, cNParams, C.uint(impact))
+	})
+	if err != nil {
+		dom.log.Printf("an error occurred: %v\n", err)
+		return nil, err
+	}
+
+	dom.log.Printf("domain blkio parameters: %v\n", params)
+
+	return params, nil
+}
+
+// SetBlkioParameters changes the blkio cgroup parameters of the domain. Only
+// the parameters in "params" are changed. Some hosts (e.g. with cgroups v2 and
+// no I/O weight support) reject some parameters, which makes libvirt's error
+// be returned.
+func (dom Domain) SetBlkioParameters(params TypedParams, impact DomainModificationImpact) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	cParams, cNParams, err := params.cTypedParams()
+	if err != nil {
+		dom.log.Printf("an error occurred: %v\n", err)
+		return err
+	}
+	defer freeCTypedParams(cParams, cNParams)
+
+	dom.log.Printf("changing domain blkio parameters to %v (impact = %v)...\n", params, impact)
+	cRet := C.virDomainSetBlkioParameters(dom.virDomain, cParams, cNParams, C.uint(impact))
+	ret := int32(cRet)
+
+	if ret == -1 {
+		err := LastError()
+		dom.log.Printf("an error occurred: %v\n", err)
+		return err
+	}
+
+	dom.log.Println("domain blkio parameters changed")
 
 	return nil
 }
 
-// MaxMemory retrieves the maximum amount of physical memory allocated to
-// a domain.
-func (dom Domain) MaxMemory() (uint64, error) {
-	dom.log.Println("reading domain maximum memory...")
-	cRet := C.virDomainGetMaxMemory(dom.virDomain)
-	ret := uint64(cRet)
-
-	if ret == 0 {
-		err := LastError()
+// BlockIOTune gets the I/O throttling parameters of the disk "disk" (either
+// its target name, e.g. "vda", or its source path). If the disk doesn't
+// exist, the returned error has the code ErrInvalidArg.
+func (dom Domain) BlockIOTune(disk string, impact DomainModificationImpact) (TypedParams, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	cDisk := C.CString(disk)
+	defer C.free(unsafe.Pointer(cDisk))
+
+	dom.log.Printf("reading block I/O tuning parameters (disk = %v, impact = %v)...\n", disk, impact)
+	params, err := readTypedParams(func(cParams *C.virTypedParameter, cNParams *C.int) C.int {
+		return C.virDomainGetBlockIoTune(dom.virDomain, cDisk, cParams, cNParams, C.uint(impact))
+	})
+	if err != nil {
 		dom.log.Printf("an error occurred: %v\n", err)
-		return 0, err
+		return nil, err
 	}
 
-	dom.log.Printf("max memory: %v kiB\n", ret)
+	dom.log.Printf("block I/O tuning parameters: %v\n", params)
 
-	return ret, nil
+	return params, nil
 }
 
-// VCPUs queries the number of virtual CPUs used by the domain. Note that this
-// call may fail if the underlying virtualization hypervisor does not support
-// it. This function may require privileged access to the hypervisor.
-func (dom Domain) VCPUs(flags DomainVCPUsFlag) (int32, error) {
-	dom.log.Println("reading domain VCPUs count...")
-	cRet := C.virDomainGetVcpusFlags(dom.virDomain, C.uint(flags))
-	ret := int32(cRet)
-
-	if ret == -1 {
-		err := LastError()
+// BlockStats provides the I/O statistics of the disk "disk" (either its target
+// name, e.g. "vda", or its source path). If "disk" is empty, the statistics of
+// all disks are summed. If the disk doesn't exist, the returned error has the
+// code ErrInvalidArg.
+func (dom Domain) BlockStats(disk string) (DomainBlockStats, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	cDisk := C.CString(disk)
+	defer C.free(unsafe.Pointer(cDisk))
+
+	dom.log.Printf("reading block statistics (disk = %v)...\n", disk)
+	params, err := readTypedParams(func(cParams *C.virTypedParameter, cNParams *C.int) C.int {
+		return C.virDomainBlockStatsFlags(dom.virDomain, cDisk, cParams, cNParams, 0)
+	})
+	if err != nil {
 		dom.log.Printf("an error occurred: %v\n", err)
-		return 0, err
+		return DomainBlockStats{}, err
 	}
 
-	dom.log.Printf("VCPUs count: %v\n", ret)
-
-	return ret, nil
-}
+	stats := DomainBlockStats{
+		Extra: make(map[string]int64),
+	}
 
-// InfoState extracts the state of the domain.
-func (dom Domain) InfoState() (DomainState, error) {
-	var cInfo C.virDomainInfo
-	cRet := C.virDomainGetInfo(dom.virDomain, &cInfo)
-	ret := int32(cRet)
+	for field, param := range params {
+		value, ok := param.(int64)
+		if !ok {
+			continue
+		}
 
-	if ret == -1 {
-		return 0, LastError()
+		switch field {
+		case "rd_operations":
+			stats.RdReq = value
+		case "rd_bytes":
+			stats.RdBytes = value
+		case "wr_operations":
+			stats.WrReq = value
+		case "wr_bytes":
+			stats.WrBytes = value
+		case "flush_operations":
+			stats.FlushReq = value
+		case "rd_total_times":
+			stats.RdTotalTimes = value
+		case "wr_total_times":
+			stats.WrTotalTimes = value
+		case "flush_total_times":
+			stats.FlushTotalTimes = value
+		default:
+			stats.Extra[field] = value
+		}
 	}
 
-	return DomainState(cInfo.state), nil
+	dom.log.Printf("block statistics: %+v\n", stats)
+
+	return stats, nil
 }
 
-// InfoMaxMemory extracts the maximum memory in KBytes allowed in the domain.
-func (dom Domain) InfoMaxMemory() (uint64, error) {
-	var cInfo C.virDomainInfo
-	cRet := C.virDomainGetInfo(dom.virDomain, &cInfo)
-	ret := int32(cRet)
+// InterfaceStats provides the traffic statistics of the network interface
+// "device" (either its host-side name, e.g. "vnet0", or its MAC address). The
+// host-side names can be found with InterfaceNames. If the interface doesn't
+// exist, an error is returned.
+func (dom Domain) InterfaceStats(device string) (DomainInterfaceStats, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
 
-	if ret == -1 {
-		return 0, LastError()
-	}
+	cDevice := C.CString(device)
+	defer C.free(unsafe.Pointer(cDevice))
 
-	return uint64(cInfo.maxMem), nil
-}
+	var cStats C.virDomainInterfaceStatsStruct
 
-// InfoMemory extracts the memory in KBytes used by the domain.
-func (dom Domain) InfoMemory() (uint64, error) {
-	var cInfo C.virDomainInfo
-	cRet := C.virDomainGetInfo(dom.virDomain, &cInfo)
+	dom.log.Printf("reading interface statistics (device = %v)...\n", device)
+	cRet := C.virDomainInterfaceStats(dom.virDomain, cDevice, &cStats, C.size_t(unsafe.Sizeof(cStats)))
 	ret := int32(cRet)
 
 	if ret == -1 {
-		return 0, LastError()
+		err := LastError()
+		dom.log.Printf("an error occurred: %v\n", err)
+		return DomainInterfaceStats{}, err
 	}
 
-	return uint64(cInfo.memory), nil
-}
+	// libvirt uses -1 for the values which aren't provided
+	value := func(cValue C.longlong) *int64 {
+		if cValue == -1 {
+			return nil
+		}
 
-// InfoVCPUs extracts the number of virtual CPUs for the domain.
-func (dom Domain) InfoVCPUs() (uint16, error) {
-	var cInfo C.virDomainInfo
-	cRet := C.virDomainGetInfo(dom.virDomain, &cInfo)
-	ret := int32(cRet)
+		v := int64(cValue)
+		return &v
+	}
 
-	if ret == -1 {
-		return 0, LastError()
+	stats := DomainInterfaceStats{
+		RxBytes:   value(cStats.rx_bytes),
+		RxPackets: value(cStats.rx_packets),
+		RxErrs:    value(cStats.rx_errs),
+		RxDrop:    value(cStats.rx_drop),
+		TxBytes:   value(cStats.tx_bytes),
+		TxPackets: value(cStats.tx_packets),
+		TxErrs:    value(cStats.tx_errs),
+		TxDrop:    value(cStats.tx_drop),
 	}
 
-	return uint16(cInfo.nrVirtCpu), nil
+	dom.log.Println("interface statistics read")
+
+	return stats, nil
 }
 
-// InfoCPUTime extracts the CPU time used in nanoseconds.
-func (dom Domain) InfoCPUTime() (uint64, error) {
-	var cInfo C.virDomainInfo
-	cRet := C.virDomainGetInfo(dom.virDomain, &cInfo)
-	ret := int32(cRet)
+// InterfaceNames returns the host-side names of the network interfaces of the
+// running domain (the "dev" attribute of their "target" element), which can
+// be used in InterfaceStats. The interfaces without a host-side name (e.g. the
+// ones with user-mode networking) are ignored.
+func (dom Domain) InterfaceNames() ([]string, error) {
+	domXML, err := dom.XML(DomXMLDefault)
+	if err != nil {
+		return nil, err
+	}
 
-	if ret == -1 {
-		return 0, LastError()
+	var desc struct {
+		Interfaces []struct {
+			Target struct {
+				Dev string `xml:"dev,attr"`
+			} `xml:"target"`
+		} `xml:"devices>interface"`
 	}
 
-	return uint64(cInfo.cpuTime), nil
+	if err = xml.Unmarshal([]byte(domXML), &desc); err != nil {
+		dom.log.Printf("an error occurred: %v\n", err)
+		return nil, err
+	}
+
+	names := make([]string, 0, len(desc.Interfaces))
+	for _, iface := range desc.Interfaces {
+		if iface.Target.Dev != "" {
+			names = append(names, iface.Target.Dev)
+		}
+	}
+
+	dom.log.Printf("interface names: %v\n", names)
+
+	return names, nil
 }
 
-// Save suspends a domain and save its memory contents to a file on disk. After
-// the call, if successful, the domain is not listed as running anymore (this
-// ends the life of a transient domain). Use Restore() to restore a domain
-// after saving.
-func (dom Domain) Save(to string, xml string, flags DomainSaveFlag) error {
-	cTo := C.CString(to)
-	defer C.free(unsafe.Pointer(cTo))
+// SetBlockIOTune changes the I/O throttling parameters of the disk "disk"
+// (either its target name, e.g. "vda", or its source path). Only the
+// parameters in "params" are changed; a zero value removes the limit.
+func (dom Domain) SetBlockIOTune(disk string, params TypedParams, impact DomainModificationImpact) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
 
-	var cXML *C.char
-	if xml != "" {
-		cXML = C.CString(xml)
-		defer C.free(unsafe.Pointer(cXML))
-	} else {
-		cXML = nil
+	cDisk := C.CString(disk)
+	defer C.free(unsafe.Pointer(cDisk))
+
+	cParams, cNParams, err := params.cTypedParams()
+	if err != nil {
+		dom.log.Printf("an error occurred: %v\n", err)
+		return err
 	}
+	defer freeCTypedParams(cParams, cNParams)
 
-	dom.log.Printf("saving domain's memory to file %v (flags = %v)...\n", to, flags)
-	cRet := C.virDomainSaveFlags(dom.virDomain, cTo, cXML, C.uint(flags))
+	dom.log.Printf("changing block I/O tuning parameters to %v (disk = %v, impact = %v)...\n", params, disk, impact)
+	cRet := C.virDomainSetBlockIoTune(dom.virDomain, cDisk, cParams, cNParams, C.uint(impact))
 	ret := int32(cRet)
 
 	if ret == -1 {
@@ -1040,56 +3781,54 @@ func (dom Domain) Save(to string, xml string, flags DomainSaveFlag) error {
 		return err
 	}
 
-	dom.log.Println("domain saved")
+	dom.log.Println("block I/O tuning parameters changed")
 
 	return nil
 }
 
-// AttachDevice attaches a virtual device to a domain, using the flags
-// parameter to control how the device is attached. DomDeviceModifyCurrent
-// specifies that the device allocation is made based on current domain state.
-// DomDeviceModifyLive specifies that the device shall be allocated to the
-// active domain instance only and is not added to the persisted domain
-// configuration. DomDeviceModifyConfig specifies that the device shall be
-// allocated to the persisted domain configuration only. Note that the target
-// hypervisor must return an error if unable to satisfy flags. E.g. the
-// hypervisor driver will return failure if DomDeviceModifyLive is specified
-// but it only supports modifying the persisted device allocation.
-func (dom Domain) AttachDevice(deviceXML string, flags DomainDeviceModifyFlag) error {
-	cXML := C.CString(deviceXML)
-	defer C.free(unsafe.Pointer(cXML))
+// InterfaceParameters gets the bandwidth parameters of the network interface
+// "device" (either its target name, e.g. "vnet0", or its MAC address).
+func (dom Domain) InterfaceParameters(device string, impact DomainModificationImpact) (TypedParams, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
 
-	dom.log.Printf("attaching a virtual device to domain (flags = %v)...\n", flags)
-	cRet := C.virDomainAttachDeviceFlags(dom.virDomain, cXML, C.uint(flags))
-	ret := int32(cRet)
+	cDevice := C.CString(device)
+	defer C.free(unsafe.Pointer(cDevice))
 
-	if ret == -1 {
-		err := LastError()
+	dom.log.Printf("reading interface parameters (device = %v, impact = %v)...\n", device, impact)
+	params, err := readTypedParams(func(cParams *C.virTypedParameter, cNParams *C.int) C.int {
+		return C.virDomainGetInterfaceParameters(dom.virDomain, cDevice, cParams, cNParams, C.uint(impact))
+	})
+	if err != nil {
 		dom.log.Printf("an error occurred: %v\n", err)
-		return err
+		return nil, err
 	}
 
-	dom.log.Println("device attached")
+	dom.log.Printf("interface parameters: %v\n", params)
 
-	return nil
+	return params, nil
 }
 
-// DetachDevice detaches a virtual device from a domain, using the flags
-// parameter to control how the device is detached. DomDeviceModifyCurrent
-// specifies that the device allocation is removed based on current domain
-// state. DomDeviceModifyLive specifies that the device shall be deallocated
-// from the active domain instance only and is not from the persisted domain
-// configuration. DomDeviceModifyConfig specifies that the device shall be
-// deallocated from the persisted domain configuration only. Note that the
-// target hypervisor must return an error if unable to satisfy flags. E.g. the
-// hypervisor driver will return failure if DomDeviceModifyLive is specified
-// but it only supports removing the persisted device allocation.
-func (dom Domain) DetachDevice(deviceXML string, flags DomainDeviceModifyFlag) error {
-	cXML := C.CString(deviceXML)
-	defer C.free(unsafe.Pointer(cXML))
+// SetInterfaceParameters changes the bandwidth parameters of the network
+// interface "device" (either its target name, e.g. "vnet0", or its MAC
+// address). Only the parameters in "params" are changed; a zero average
+// removes the limit of that direction.
+func (dom Domain) SetInterfaceParameters(device string, params TypedParams, impact DomainModificationImpact) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
 
-	dom.log.Printf("detaching a virtual device from domain (flags = %v)...\n", flags)
-	cRet := C.virDomainDetachDeviceFlags(dom.virDomain, cXML, C.uint(flags))
+	cDevice := C.CString(device)
+	defer C.free(unsafe.Pointer(cDevice))
+
+	cParams, cNParams, err := params.cTypedParams()
+	if err != nil {
+		dom.log.Printf("an error occurred: %v\n", err)
+		return err
+	}
+	defer freeCTypedParams(cParams, cNParams)
+
+	dom.log.Printf("changing interface parameters to %v (device = %v, impact = %v)...\n", params, device, impact)
+	cRet := C.virDomainSetInterfaceParameters(dom.virDomain, cDevice, cParams, cNParams, C.uint(impact))
 	ret := int32(cRet)
 
 	if ret == -1 {
@@ -1098,53 +3837,62 @@ func (dom Domain) DetachDevice(deviceXML string, flags DomainDeviceModifyFlag) e
 		return err
 	}
 
-	dom.log.Println("device detached")
+	dom.log.Println("interface parameters changed")
 
 	return nil
 }
 
-// UpdateDevice changes a virtual device on a domain, using the flags parameter
-// to control how the device is changed. DomDeviceModifyCurrent specifies that
-// the device change is made based on current domain state. DomDeviceModifyLive
-// specifies that the device shall be changed on the active domain instance
-// only and is not added to the persisted domain configuration.
-// DomDeviceModifyConfig specifies that the device shall be changed on the
-// persisted domain configuration only. Note that the target hypervisor must
-// return an error if unable to satisfy flags. E.g. the hypervisor driver will
-// return failure if DomDeviceModifyLive is specified but it only supports
-// modifying the persisted device allocation.
-func (dom Domain) UpdateDevice(deviceXML string, flags DomainDeviceModifyFlag) error {
-	cXML := C.CString(deviceXML)
-	defer C.free(unsafe.Pointer(cXML))
+// Time extracts the time of the guest system, which requires the guest agent,
+// as the seconds since the Unix epoch and the nanoseconds within that second.
+// "flags" is currently unused by libvirt and should be 0. If the guest agent
+// isn't configured or isn't connected, the returned error has the code
+// ErrArgumentUnsupported or ErrAgentUnresponsive, respectively.
+func (dom Domain) Time(flags uint32) (seconds int64, nanoseconds uint, err error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
 
-	dom.log.Printf("updating a virtual device on domain (flags = %v)...\n", flags)
-	cRet := C.virDomainUpdateDeviceFlags(dom.virDomain, cXML, C.uint(flags))
+	var cSeconds C.longlong
+	var cNSeconds C.uint
+
+	dom.log.Printf("reading guest time (flags = %v)...\n", flags)
+	cRet := C.virDomainGetTime(dom.virDomain, &cSeconds, &cNSeconds, C.uint(flags))
 	ret := int32(cRet)
 
 	if ret == -1 {
-		err := LastError()
+		err = LastError()
 		dom.log.Printf("an error occurred: %v\n", err)
-		return err
+		return 0, 0, err
 	}
 
-	dom.log.Println("device updated")
+	seconds = int64(cSeconds)
+	nanoseconds = uint(cNSeconds)
 
-	return nil
+	dom.log.Printf("guest time: %v s, %v ns\n", seconds, nanoseconds)
+
+	return seconds, nanoseconds, nil
 }
 
-// SetAutostart configures the domain to be automatically started when the host
-// machine boots.
-func (dom Domain) SetAutostart(autostart bool) error {
-	var cAutostart C.int
-	if autostart {
-		dom.log.Println("enabling domain autostart...")
-		cAutostart = 1
-	} else {
-		dom.log.Println("disabling domain autostart...")
-		cAutostart = 0
+// GuestTime is like Time, but returns the time of the guest system as a
+// time.Time.
+func (dom Domain) GuestTime() (time.Time, error) {
+	seconds, nanoseconds, err := dom.Time(0)
+	if err != nil {
+		return time.Time{}, err
 	}
 
-	cRet := C.virDomainSetAutostart(dom.virDomain, cAutostart)
+	return time.Unix(seconds, int64(nanoseconds)), nil
+}
+
+// SetTime changes the time of the guest system to "seconds" since the Unix
+// epoch plus "nanoseconds", which requires the guest agent. With
+// DomSetTimeSync, the given time is ignored and the time is resynced from the
+// domain's RTC instead. The guest agent errors are the same as in Time.
+func (dom Domain) SetTime(seconds int64, nanoseconds uint, flags DomainSetTimeFlag) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	dom.log.Printf("changing guest time to %v s, %v ns (flags = %v)...\n", seconds, nanoseconds, flags)
+	cRet := C.virDomainSetTime(dom.virDomain, C.longlong(seconds), C.uint(nanoseconds), C.uint(flags))
 	ret := int32(cRet)
 
 	if ret == -1 {
@@ -1153,20 +3901,28 @@ func (dom Domain) SetAutostart(autostart bool) error {
 		return err
 	}
 
-	if autostart {
-		dom.log.Println("autostart enabled")
-	} else {
-		dom.log.Println("autostart disabled")
-	}
+	dom.log.Println("guest time changed")
 
 	return nil
 }
 
-// SetMemory dynamically changes the target amount of physical memory allocated
-// to a domain. This function may require privileged access to the hypervisor.
-func (dom Domain) SetMemory(memory uint64, flags DomainMemoryModifyFlag) error {
-	dom.log.Printf("changing domain memory to %v kiB (flags = %v)...\n", memory, flags)
-	cRet := C.virDomainSetMemoryFlags(dom.virDomain, C.ulong(memory), C.uint(flags))
+// SetUserPassword changes the password of "user" in the guest system, which
+// requires the guest agent. With DomSetUserPasswordEncrypted, "password" must
+// already be hashed in the format used by the guest (e.g. crypt(3) on Linux).
+// If the guest agent isn't configured or isn't connected, the returned error
+// has the code ErrArgumentUnsupported or ErrAgentUnresponsive, respectively.
+func (dom Domain) SetUserPassword(user string, password string, flags DomainSetUserPasswordFlag) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	cUser := C.CString(user)
+	defer C.free(unsafe.Pointer(cUser))
+
+	cPassword := C.CString(password)
+	defer C.free(unsafe.Pointer(cPassword))
+
+	dom.log.Printf("changing the password of guest user %v (flags = %v)...\n", user, flags)
+	cRet := C.virDomainSetUserPassword(dom.virDomain, cUser, cPassword, C.uint(flags))
 	ret := int32(cRet)
 
 	if ret == -1 {
@@ -1175,28 +3931,22 @@ func (dom Domain) SetMemory(memory uint64, flags DomainMemoryModifyFlag) error {
 		return err
 	}
 
-	dom.log.Println("memory changed")
+	dom.log.Println("password changed")
 
 	return nil
 }
 
-// SetMetadata sets the appropriate domain element given by "type" to the value
-// of "description". A "type" of DomMetaDescription is free-form text;
-// DomMetaTitle is free-form, but no newlines are permitted, and should be
-// short (although the length is not enforced). For these two options "key" and
-// "uri" are irrelevant and must be set to NULL.
-func (dom Domain) SetMetadata(typ DomainMetadataType, metadata string, key string, uri string, impact DomainModificationImpact) error {
-	cMetadata := C.CString(metadata)
-	defer C.free(unsafe.Pointer(cMetadata))
-
-	cKey := C.CString(key)
-	defer C.free(unsafe.Pointer(cKey))
-
-	cURI := C.CString(uri)
-	defer C.free(unsafe.Pointer(cURI))
-
-	dom.log.Printf("changing domain metadata key '<%v:%v>' (type = %v, impact = %v)...\n", key, uri, typ, impact)
-	cRet := C.virDomainSetMetadata(dom.virDomain, C.int(typ), cMetadata, cKey, cURI, C.uint(impact))
+// SetLifecycleAction changes the action taken when the lifecycle event "typ"
+// happens on the domain (i.e. the elements <on_poweroff/>, <on_reboot/> and
+// <on_crash/> of the domain XML). Some combinations are invalid (e.g. the
+// coredump actions are only valid for DomLifecycleCrash), which makes
+// libvirt's error be returned.
+func (dom Domain) SetLifecycleAction(typ DomainLifecycle, action DomainLifecycleAction, impact DomainModificationImpact) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	dom.log.Printf("changing domain lifecycle action (type = %v, action = %v, impact = %v)...\n", typ, action, impact)
+	cRet := C.virDomainSetLifecycleAction(dom.virDomain, C.uint(typ), C.uint(action), C.uint(impact))
 	ret := int32(cRet)
 
 	if ret == -1 {
@@ -1205,42 +3955,61 @@ func (dom Domain) SetMetadata(typ DomainMetadataType, metadata string, key strin
 		return err
 	}
 
-	dom.log.Println("metadata changed")
+	dom.log.Println("lifecycle action changed")
 
 	return nil
 }
 
-// SetVCPUs dynamically changes the number of virtual CPUs used by the domain.
-// Note that this call may fail if the underlying virtualization hypervisor
-// does not support it or if growing the number is arbitrary limited. This
-// function may require privileged access to the hypervisor.
-func (dom Domain) SetVCPUs(vcpus uint32, flags DomainVCPUsFlag) error {
-	dom.log.Printf("changing domain VCPUs count to %v (flags = %v)...\n", vcpus, flags)
-	cRet := C.virDomainSetVcpusFlags(dom.virDomain, C.uint(vcpus), C.uint(flags))
+// LaunchSecurityInfo gets the launch security information of the domain
+// (e.g. the SEV measurement used for attestation). A domain without launch
+// security doesn't have any information. "flags" is currently unused by libvirt
+// and should be 0.
+func (dom Domain) LaunchSecurityInfo(flags uint32) (TypedParams, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	var cParams C.virTypedParameterPtr
+	var cNParams C.int
+
+	dom.log.Printf("reading domain launch security information (flags = %v)...\n", flags)
+	cRet := C.virDomainGetLaunchSecurityInfo(dom.virDomain, &cParams, &cNParams, C.uint(flags))
 	ret := int32(cRet)
 
 	if ret == -1 {
 		err := LastError()
 		dom.log.Printf("an error occurred: %v\n", err)
-		return err
+		return nil, err
 	}
+	defer C.virTypedParamsFree(cParams, cNParams)
 
-	dom.log.Println("VCPUs count changed")
+	params, err := newTypedParams(cParams, cNParams)
+	if err != nil {
+		dom.log.Printf("an error occurred: %v\n", err)
+		return nil, err
+	}
 
-	return nil
+	dom.log.Printf("launch security information: %v\n", params)
+
+	return params, nil
 }
 
-// ManagedSave suspends a domain and save its memory contents to a file on
-// disk. After the call, if successful, the domain is not listed as running
-// anymore. The difference from Save() is that libvirt is keeping track of the
-// saved state itself, and will reuse it once the domain is being restarted
-// (automatically or via an explicit libvirt call). As a result any running
-// domain is sure to not have a managed saved image. This also implies that
-// managed save only works on persistent domains, since the domain must still
-// exist in order to use Create() to restart it.
-func (dom Domain) ManagedSave(flags DomainSaveFlag) error {
-	dom.log.Printf("saving domain's memory to a libvirt-managed location (flags = %v)...\n", flags)
-	cRet := C.virDomainManagedSave(dom.virDomain, C.uint(flags))
+// SetLaunchSecurityState changes the launch security state of the domain,
+// e.g. injecting a secret into an SEV domain after the attestation. It returns
+// libvirt's error for a domain without launch security. "flags" is currently
+// unused by libvirt and should be 0.
+func (dom Domain) SetLaunchSecurityState(params TypedParams, flags uint32) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	cParams, cNParams, err := params.cTypedParams()
+	if err != nil {
+		dom.log.Printf("an error occurred: %v\n", err)
+		return err
+	}
+	defer freeCTypedParams(cParams, cNParams)
+
+	dom.log.Printf("changing domain launch security state (flags = %v)...\n", flags)
+	cRet := C.virDomainSetLaunchSecurityState(dom.virDomain, cParams, cNParams, C.uint(flags))
 	ret := int32(cRet)
 
 	if ret == -1 {
@@ -1249,15 +4018,21 @@ func (dom Domain) ManagedSave(flags DomainSaveFlag) error {
 		return err
 	}
 
-	dom.log.Println("domain saved")
+	dom.log.Println("launch security state changed")
 
 	return nil
 }
 
-// ManagedSaveRemove removes any managed save image for this domain.
-func (dom Domain) ManagedSaveRemove() error {
-	dom.log.Println("removing libvirt-managed domain save image...")
-	cRet := C.virDomainManagedSaveRemove(dom.virDomain, 0)
+// InjectNMI sends a non-maskable interrupt to the guest, e.g. to trigger a
+// crash dump inside a hung guest system. If the domain isn't running, the
+// returned error has the code ErrOperationInvalid. "flags" is currently unused
+// by libvirt and should be 0.
+func (dom Domain) InjectNMI(flags uint32) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	dom.log.Printf("injecting NMI into domain (flags = %v)...\n", flags)
+	cRet := C.virDomainInjectNMI(dom.virDomain, C.uint(flags))
 	ret := int32(cRet)
 
 	if ret == -1 {
@@ -1266,15 +4041,24 @@ func (dom Domain) ManagedSaveRemove() error {
 		return err
 	}
 
-	dom.log.Println("save image removed")
+	dom.log.Println("NMI injected")
 
 	return nil
 }
 
-// SendKey send key(s) to the guest.
+// SendKey sends key(s) to the guest, holding them down during "hold". At
+// least one and at most DomSendKeyMaxKeys keycodes must be specified,
+// otherwise ErrInvalidKeycodeCount is returned.
 func (dom Domain) SendKey(codeSet DomainKeycodeSet, hold time.Duration, keycodes []uint32) error {
-	dom.log.Printf("sending keys %v (keycode set = %v) to domain during %v...\n", keycodes, codeSet, time.Duration(hold))
-	cRet := C.virDomainSendKey(dom.virDomain, C.uint(codeSet), C.uint(hold*time.Millisecond), (*C.uint)(unsafe.Pointer(&keycodes[0])), C.int(len(keycodes)), 0)
+	if len(keycodes) == 0 || len(keycodes) > DomSendKeyMaxKeys {
+		return ErrInvalidKeycodeCount
+	}
+
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	dom.log.Printf("sending keys %v (keycode set = %v) to domain during %v...\n", keycodes, codeSet, hold)
+	cRet := C.virDomainSendKey(dom.virDomain, C.uint(codeSet), C.uint(hold/time.Millisecond), (*C.uint)(unsafe.Pointer(&keycodes[0])), C.int(len(keycodes)), 0)
 	ret := int32(cRet)
 
 	if ret == -1 {
@@ -1290,6 +4074,9 @@ func (dom Domain) SendKey(codeSet DomainKeycodeSet, hold time.Duration, keycodes
 
 // SendProcessSignal sends a signal to the designated process in the guest.
 func (dom Domain) SendProcessSignal(pid int64, signal DomainProcessSignal) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	dom.log.Printf("sending signal %v to domain's process %v...\n", signal, pid)
 	cRet := C.virDomainSendProcessSignal(dom.virDomain, C.longlong(pid), C.uint(signal), 0)
 	ret := int32(cRet)
@@ -1308,6 +4095,9 @@ func (dom Domain) SendProcessSignal(pid int64, signal DomainProcessSignal) error
 // ListSnapshots collects the list of domain snapshots for the given domain, and
 // allocate an array to store those objects.
 func (dom Domain) ListSnapshots(flags SnapshotListFlag) ([]Snapshot, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	var cSnaps []C.virDomainSnapshotPtr
 	snapsSH := (*reflect.SliceHeader)(unsafe.Pointer(&cSnaps))
 
@@ -1341,6 +4131,9 @@ func (dom Domain) ListSnapshots(flags SnapshotListFlag) ([]Snapshot, error) {
 
 // CreateSnapshot creates a new snapshot of a domain based on a snapshot XML.
 func (dom Domain) CreateSnapshot(xml string, flags SnapshotCreateFlag) (Snapshot, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	cXML := C.CString(xml)
 	defer C.free(unsafe.Pointer(cXML))
 
@@ -1364,6 +4157,9 @@ func (dom Domain) CreateSnapshot(xml string, flags SnapshotCreateFlag) (Snapshot
 
 // LookupSnapshotByName tries to lookup a domain snapshot based on its name.
 func (dom Domain) LookupSnapshotByName(name string) (Snapshot, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	cName := C.CString(name)
 	defer C.free(unsafe.Pointer(cName))
 
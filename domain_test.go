@@ -2,10 +2,15 @@ package libvirt
 
 import (
 	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"math/rand"
 	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -82,37 +87,147 @@ func TestDomainAutostart(t *testing.T) {
 	if !autostart {
 		t.Error("test domain should have autostart enabled after setting")
 	}
+
+	if err := env.dom.SetAutostart(false); err != nil {
+		t.Fatal(err)
+	}
+
+	autostart, err = env.dom.Autostart()
+	if err != nil {
+		t.Error(err)
+	}
+	if autostart {
+		t.Error("test domain should have autostart disabled after unsetting")
+	}
+
+	var xml bytes.Buffer
+	data, err := newTestDomainData(*env.conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer data.cleanUp(*env.conn)
+
+	if err = testDomainTmpl.Execute(&xml, data); err != nil {
+		t.Fatal(err)
+	}
+
+	transientDom, err := env.conn.CreateDomain(xml.String(), DomCreateAutodestroy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer transientDom.Free()
+	defer transientDom.Destroy(DomDestroyDefault)
+
+	if err = transientDom.SetAutostart(true); err == nil {
+		t.Error("an error was not returned when enabling autostart on a transient domain")
+	}
 }
 
 func TestDomainID(t *testing.T) {
-	// TODO: if a domain is created with "<Domain>.Create" after
-	// "<Connection>.Define", it doesn't see to get an ID. as a workaround, we
-	// create it directly with "<Connection>.CreateDomain" because then it works.
-	env := newTestEnvironment(t)
+	env := newTestEnvironment(t).withDomain()
 	defer env.cleanUp()
 
-	data, err := newTestDomainData(*env.conn)
+	if _, err := env.dom.ID(); err != ErrDomainWithoutID {
+		t.Errorf("unexpected error when reading the ID of a shut off domain; got=%v, want=%v", err, ErrDomainWithoutID)
+	}
+
+	if err := env.dom.Create(DomCreateDefault); err != nil {
+		t.Fatal(err)
+	}
+
+	id, err := env.dom.ID()
 	if err != nil {
 		t.Fatal(err)
 	}
-	defer data.cleanUp(*env.conn)
+
+	dom, err := env.conn.LookupDomainByID(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dom.Free()
+
+	name, err := dom.Name()
+	if err != nil {
+		t.Error(err)
+	}
+
+	if name != env.domData.Name {
+		t.Errorf("looked up domain with unexpected name; got=%v, want=%v", name, env.domData.Name)
+	}
+
+	if err = env.dom.Destroy(DomDestroyDefault); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = env.conn.LookupDomainByID(id); err == nil {
+		t.Error("an error was not returned when looking up the ID of a domain which is not running")
+	} else if !errors.Is(err, &Error{Code: ErrNoDomain}) {
+		t.Errorf("unexpected error when looking up the ID of a domain which is not running; got=%v, want code=%v", err, ErrNoDomain)
+	}
+}
+
+func TestDomainRename(t *testing.T) {
+	env := newTestEnvironment(t).withDomain()
+	defer env.cleanUp()
+
+	otherData, err := newTestDomainData(*env.conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer otherData.cleanUp(*env.conn)
 
 	var xml bytes.Buffer
 
-	if err := testDomainTmpl.Execute(&xml, data); err != nil {
+	if err = testDomainTmpl.Execute(&xml, otherData); err != nil {
 		t.Fatal(err)
 	}
 
-	dom, err := env.conn.CreateDomain(xml.String(), DomCreateAutodestroy)
+	other, err := env.conn.DefineDomain(xml.String())
 	if err != nil {
 		t.Fatal(err)
 	}
-	defer dom.Destroy(DomDestroyDefault)
+	defer other.Free()
+	defer other.Undefine(DomUndefineDefault)
+
+	if err = env.dom.Rename(otherData.Name, 0); err == nil {
+		t.Error("an error was not returned when renaming a domain to an existing name")
+	} else if _, ok := err.(*Error); !ok {
+		t.Errorf("the returned error should be a libvirt error; got=%T", err)
+	}
+
+	oldName := env.domData.Name
+	newName := utils.RandomString()
 
-	_, err = dom.ID()
+	if err = env.dom.Rename(newName, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	if name, err := env.dom.Name(); err != nil {
+		t.Error(err)
+	} else if name != newName {
+		t.Errorf("unexpected domain name after renaming; got=%v, want=%v", name, newName)
+	}
+
+	dom, err := env.conn.LookupDomainByName(newName)
 	if err != nil {
+		t.Error(err)
+	} else {
+		dom.Free()
+	}
+
+	if _, err = env.conn.LookupDomainByName(oldName); err == nil {
+		t.Error("a domain was found with the old name after renaming it")
+	}
+
+	if err = env.dom.Create(DomCreateDefault); err != nil {
 		t.Fatal(err)
 	}
+
+	if err = env.dom.Rename(oldName, 0); err == nil {
+		t.Error("an error was not returned when renaming a running domain")
+	} else if !errors.Is(err, &Error{Code: ErrOperationInvalid}) {
+		t.Errorf("unexpected error when renaming a running domain; got=%v, want code=%v", err, ErrOperationInvalid)
+	}
 }
 
 func TestDomainXML(t *testing.T) {
@@ -182,6 +297,104 @@ func TestDomainMetadata(t *testing.T) {
 	}
 }
 
+func TestDomainTitleDescription(t *testing.T) {
+	env := newTestEnvironment(t).withDomain()
+	defer env.cleanUp()
+
+	if err := env.dom.Create(DomCreateDefault); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, impact := range []DomainModificationImpact{DomAffectLive, DomAffectConfig} {
+		if title, err := env.dom.Title(impact); err != nil {
+			t.Error(err)
+		} else if title != "" {
+			t.Errorf("unexpected title of a domain without title; got=%q, want=\"\"", title)
+		}
+
+		if description, err := env.dom.Description(impact); err != nil {
+			t.Error(err)
+		} else if description != "" {
+			t.Errorf("unexpected description of a domain without description; got=%q, want=\"\"", description)
+		}
+
+		if err := env.dom.SetTitle("multi\nline", impact); err == nil {
+			t.Error("an error was not returned when setting a title with newlines")
+		}
+
+		wantTitle := utils.RandomString()
+		if err := env.dom.SetTitle(wantTitle, impact); err != nil {
+			t.Fatal(err)
+		}
+
+		wantDescription := utils.RandomString() + "\n" + utils.RandomString()
+		if err := env.dom.SetDescription(wantDescription, impact); err != nil {
+			t.Fatal(err)
+		}
+
+		if title, err := env.dom.Title(impact); err != nil {
+			t.Error(err)
+		} else if title != wantTitle {
+			t.Errorf("unexpected domain title; got=%q, want=%q", title, wantTitle)
+		}
+
+		if description, err := env.dom.Description(impact); err != nil {
+			t.Error(err)
+		} else if description != wantDescription {
+			t.Errorf("unexpected domain description; got=%q, want=%q", description, wantDescription)
+		}
+
+		if err := env.dom.SetTitle("", impact); err != nil {
+			t.Error(err)
+		}
+
+		if err := env.dom.SetDescription("", impact); err != nil {
+			t.Error(err)
+		}
+
+		if title, err := env.dom.Title(impact); err != nil {
+			t.Error(err)
+		} else if title != "" {
+			t.Errorf("unexpected domain title after clearing it; got=%q, want=\"\"", title)
+		}
+
+		if description, err := env.dom.Description(impact); err != nil {
+			t.Error(err)
+		} else if description != "" {
+			t.Errorf("unexpected domain description after clearing it; got=%q, want=\"\"", description)
+		}
+	}
+}
+
+func TestDomainDestroy(t *testing.T) {
+	env := newTestEnvironment(t).withDomain()
+	defer env.cleanUp()
+
+	if err := env.dom.Destroy(DomDestroyDefault); err == nil {
+		t.Error("an error was not returned when destroying a domain which is not running")
+	} else if !errors.Is(err, &Error{Code: ErrOperationInvalid}) {
+		t.Errorf("unexpected error when destroying a domain which is not running; got=%v, want code=%v", err, ErrOperationInvalid)
+	}
+
+	for _, flags := range []DomainDestroyFlag{DomDestroyDefault, DomDestroyGraceful} {
+		if err := env.dom.Create(DomCreateDefault); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := env.dom.Destroy(flags); err != nil {
+			t.Fatal(err)
+		}
+
+		active, err := env.dom.IsActive()
+		if err != nil {
+			t.Error(err)
+		}
+		if active {
+			t.Errorf("the domain should not be active after being destroyed (flags = %v)", flags)
+		}
+	}
+}
+
 func TestDomainReboot(t *testing.T) {
 	env := newTestEnvironment(t).withDomain()
 	defer env.cleanUp()
@@ -201,22 +414,36 @@ func TestDomainReboot(t *testing.T) {
 	if err := env.dom.Reboot(DomRebootDefault); err != nil {
 		t.Error(err)
 	}
+
+	if active, err := env.dom.IsActive(); err != nil {
+		t.Error(err)
+	} else if !active {
+		t.Error("the domain should still be active after being rebooted")
+	}
 }
 
 func TestDomainReset(t *testing.T) {
 	env := newTestEnvironment(t).withDomain()
 	defer env.cleanUp()
 
-	if err := env.dom.Reset(); err == nil {
+	if err := env.dom.Reset(0); err == nil {
 		t.Error("an error was not returned when trying to reset an offline domain")
+	} else if !errors.Is(err, &Error{Code: ErrOperationInvalid}) {
+		t.Errorf("unexpected error when trying to reset an offline domain; got=%v, want code=%v", err, ErrOperationInvalid)
 	}
 
 	if err := env.dom.Create(DomCreateAutodestroy); err != nil {
 		t.Fatal(err)
 	}
 
-	if err := env.dom.Reset(); err != nil {
+	if err := env.dom.Reset(0); err != nil {
+		t.Error(err)
+	}
+
+	if active, err := env.dom.IsActive(); err != nil {
 		t.Error(err)
+	} else if !active {
+		t.Error("the domain should still be active after being reset")
 	}
 }
 
@@ -228,21 +455,44 @@ func TestDomainShutdown(t *testing.T) {
 		t.Error("an error was not returned when trying to shutdown an offline domain")
 	}
 
-	if err := env.dom.Create(DomCreateAutodestroy); err != nil {
-		t.Fatal(err)
+	tests := []struct {
+		name     string
+		shutdown func() error
+	}{
+		{"Shutdown", env.dom.Shutdown},
+		{"ShutdownFlags", func() error { return env.dom.ShutdownFlags(DomShutdownACPIPowerBtn) }},
 	}
 
-	if err := env.dom.Shutdown(); err != nil {
-		t.Error(err)
+	for _, tt := range tests {
+		if err := env.dom.Create(DomCreateAutodestroy); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := tt.shutdown(); err != nil {
+			t.Errorf("%v: %v", tt.name, err)
+		}
+
+		state, reason, err := env.dom.State()
+		if err != nil {
+			t.Error(err)
+		}
+
+		if state != DomStateShutoff || DomainShutoffReason(reason) != DomShutoffReasonShutdown {
+			t.Errorf("unexpected domain state after %v; got=%v (reason %v), want=%v (reason %v)", tt.name, state, reason, DomStateShutoff, DomShutoffReasonShutdown)
+
+			if state != DomStateShutoff {
+				env.dom.Destroy(DomDestroyDefault)
+			}
+		}
 	}
 
-	state, reason, err := env.dom.State()
-	if err != nil {
-		t.Error(err)
+	if err := env.dom.Create(DomCreateAutodestroy); err != nil {
+		t.Fatal(err)
 	}
 
-	if state != DomStateShutoff && DomainShutoffReason(reason) != DomShutoffReasonShutdown {
-		t.Errorf("unexpected domain state; got=%v (reason %v), want=%v (reason %v)", state, reason, DomStateShutoff, DomShutoffReasonShutdown)
+	// the test domain doesn't have a guest agent
+	if err := env.dom.ShutdownFlags(DomShutdownGuestAgent); err == nil {
+		t.Error("an error was not returned when trying to shutdown a domain with the guest agent, but without an agent")
 	}
 }
 
@@ -254,11 +504,20 @@ func TestDomainSuspendResume(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if err := env.dom.Suspend(); err != nil {
+	state, reason, err := env.dom.State()
+	if err != nil {
+		t.Error(err)
+	}
+
+	if state != DomStateRunning || DomainRunningReason(reason) != DomRunningReasonBooted {
+		t.Errorf("unexpected domain state; got=%v (reason %v), want=%v (reason %v)", state, DomainRunningReason(reason), DomStateRunning, DomRunningReasonBooted)
+	}
+
+	if err = env.dom.Suspend(); err != nil {
 		t.Error(err)
 	}
 
-	state, reason, err := env.dom.State()
+	state, reason, err = env.dom.State()
 	if err != nil {
 		t.Error(err)
 	}
@@ -267,6 +526,18 @@ func TestDomainSuspendResume(t *testing.T) {
 		t.Errorf("unexpected domain state; got=%v (reason %v), want=%v (reason %v)", state, reason, DomStatePaused, DomPausedReasonUser)
 	}
 
+	if err = env.dom.Suspend(); !errors.Is(err, &Error{Code: ErrOperationInvalid}) {
+		t.Errorf("unexpected error when suspending a paused domain; got=%v, want=%v", err, ErrOperationInvalid)
+	}
+
+	if state, _, err = env.dom.State(); err != nil {
+		t.Error(err)
+	}
+
+	if state != DomStatePaused {
+		t.Errorf("unexpected domain state after suspending it twice; got=%v, want=%v", state, DomStatePaused)
+	}
+
 	if err = env.dom.Resume(); err != nil {
 		t.Error(err)
 	}
@@ -281,6 +552,72 @@ func TestDomainSuspendResume(t *testing.T) {
 	}
 }
 
+func TestDomainStateString(t *testing.T) {
+	tests := []struct {
+		value fmt.Stringer
+		want  string
+	}{
+		{DomStateRunning, "running"},
+		{DomStateShutoff, "shut off"},
+		{DomainState(99), "unknown state (99)"},
+		{DomPausedReasonUser, "user"},
+		{DomPausedReasonIOError, "I/O error"},
+		{DomShutoffReasonCrashed, "crashed"},
+		{DomainShutoffReason(99), "unknown reason (99)"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.value.String(); got != tt.want {
+			t.Errorf("unexpected string; got=%q, want=%q", got, tt.want)
+		}
+	}
+
+	if got, want := stateReasonString(DomStatePaused, int32(DomPausedReasonMigration)), "migrating"; got != want {
+		t.Errorf("unexpected reason string; got=%q, want=%q", got, want)
+	}
+}
+
+func TestDomainPMSuspendWakeup(t *testing.T) {
+	env := newTestEnvironment(t).withDomain()
+	defer env.cleanUp()
+
+	if err := env.dom.PMSuspendForDuration(NodeSuspendTargetMem, 0, 0); err == nil {
+		t.Error("an error was not returned when suspending the guest system of an offline domain")
+	} else if virErr, ok := err.(*Error); !ok || virErr.Code == ErrOK {
+		t.Errorf("the returned error should be a libvirt error with a code; got=%v", err)
+	}
+
+	if err := env.dom.Create(DomCreateAutodestroy); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := env.dom.PMWakeup(0); err == nil {
+		t.Error("an error was not returned when waking up a domain which is not suspended")
+	} else if virErr, ok := err.(*Error); !ok || virErr.Code == ErrOK {
+		t.Errorf("the returned error should be a libvirt error with a code; got=%v", err)
+	}
+
+	if err := env.dom.PMSuspendForDuration(NodeSuspendTargetMem, 0, 0); err != nil {
+		// the test domain doesn't have a guest agent
+		if _, ok := err.(*Error); !ok {
+			t.Errorf("the returned error should be a libvirt error; got=%T", err)
+		}
+		t.Skipf("the domain's guest system can't be suspended: %v", err)
+	}
+
+	state, _, err := env.dom.State()
+	if err != nil {
+		t.Error(err)
+	}
+	if state != DomStatePMSuspended {
+		t.Errorf("unexpected domain state after suspending its guest system; got=%v, want=%v", state, DomStatePMSuspended)
+	}
+
+	if err = env.dom.PMWakeup(0); err != nil {
+		t.Error(err)
+	}
+}
+
 func TestDomainCoreDump(t *testing.T) {
 	env := newTestEnvironment(t).withDomain()
 	defer env.cleanUp()
@@ -319,6 +656,28 @@ func TestDomainCoreDump(t *testing.T) {
 	if stat.Size() == 0 {
 		t.Error("core dump file was not generated (empty size)")
 	}
+
+	if err := env.dom.CoreDump(filepath.Join("/proc", utils.RandomString(), "core"), DomDumpFormatRaw, DomDumpMemoryOnly); err == nil {
+		t.Error("an error was not returned when dumping the core to a directory which can't be written")
+	}
+
+	memoryDumpFile, ioerr := ioutil.TempFile("", fmt.Sprintf("%v-memorydump_", env.domData.Name))
+	if ioerr != nil {
+		t.Fatal(ioerr)
+	}
+	defer os.Remove(memoryDumpFile.Name())
+
+	if err := env.dom.CoreDump(memoryDumpFile.Name(), DomDumpFormatRaw, DomDumpMemoryOnly); err != nil {
+		t.Fatal(err)
+	}
+
+	if stat, err = memoryDumpFile.Stat(); err != nil {
+		t.Fatal(err)
+	}
+
+	if stat.Size() == 0 {
+		t.Error("memory-only core dump file was not generated (empty size)")
+	}
 }
 
 func TestDomainRef(t *testing.T) {
@@ -387,130 +746,1671 @@ func TestDomainMemory(t *testing.T) {
 	if err = env.dom.SetMemory(newMemory, DomMemoryCurrent); err != nil {
 		t.Fatal(err)
 	}
-}
-
-func TestDomainVCPUs(t *testing.T) {
-	env := newTestEnvironment(t).withDomain()
-	defer env.cleanUp()
 
-	newMaxVCPUs := int32(rand.Intn(int(env.domData.MaxVCPUs)) + 1)
-	newVCPUs := int32(rand.Intn(int(newMaxVCPUs)) + 1)
+	if err = env.dom.SetMaxMemory(0); err == nil {
+		t.Error("an error was not returned when setting the domain maximum memory to 0")
+	}
 
-	if err := env.dom.SetVCPUs(0, DomVCPUsCurrent); err == nil {
-		t.Error("an error was not returned when setting an invalid VCPU number")
+	if err = env.dom.SetMaxMemory(env.domData.MaxMemory); err != nil {
+		t.Fatal(err)
 	}
 
-	if err := env.dom.SetVCPUs(uint32(newVCPUs), DomainVCPUsFlag(99)); err == nil {
-		t.Error("an error was not returned when using an invalid flag to set VCPU")
+	if maxMemory, err = env.dom.MaxMemory(); err != nil {
+		t.Error(err)
+	} else if maxMemory != env.domData.MaxMemory {
+		t.Errorf("wrong maximum memory; got=%v, want=%v", maxMemory, env.domData.MaxMemory)
 	}
+}
 
-	if _, err := env.dom.VCPUs(DomainVCPUsFlag(99)); err == nil {
-		t.Error("an error was not returned when using an invalid flag to get VCPU")
+func TestDomainMemoryBalloon(t *testing.T) {
+	env := newTestEnvironment(t).withDomain()
+	defer env.cleanUp()
+
+	if err := env.dom.Create(DomCreateDefault); err != nil {
+		t.Fatal(err)
 	}
 
-	vcpus, err := env.dom.VCPUs(DomVCPUsCurrent)
+	memory, err := env.dom.InfoMemory()
 	if err != nil {
 		t.Fatal(err)
 	}
-	if vcpus != env.domData.VCPUs {
-		t.Errorf("wrong VCPUs number; got=%v, want=%v", vcpus, env.domData.VCPUs)
-	}
 
-	maxVCPUs, err := env.dom.VCPUs(DomVCPUsMaximum)
+	maxMemory, err := env.dom.InfoMaxMemory()
 	if err != nil {
 		t.Fatal(err)
 	}
-	if maxVCPUs != env.domData.MaxVCPUs {
-		t.Errorf("wrong maximum VCPUs number; got=%v, want=%v", maxVCPUs, env.domData.MaxVCPUs)
+
+	if err = env.dom.SetMemory(maxMemory+1024, DomMemoryLive); err == nil {
+		t.Error("an error was not returned when ballooning a domain above its maximum memory")
 	}
 
-	if err = env.dom.SetVCPUs(uint32(newVCPUs), DomVCPUsCurrent); err != nil {
-		t.Fatal(err)
+	newMemory := memory / 2
+	if memory > 8192 {
+		newMemory = memory - 4096 // 4 MiB less
 	}
 
-	vcpus, err = env.dom.VCPUs(DomVCPUsCurrent)
-	if err != nil {
+	if err = env.dom.SetMemory(newMemory, DomMemoryLive|DomMemoryConfig); err != nil {
 		t.Fatal(err)
 	}
-	if vcpus != newVCPUs {
-		t.Errorf("wrong VCPUs count; got=%v, want=%v", vcpus, newVCPUs)
+
+	var desc struct {
+		CurrentMemory uint64 `xml:"currentMemory"`
+	}
+
+	if inactiveXML, err := env.dom.XML(DomXMLInactive); err != nil {
+		t.Error(err)
+	} else if err = xml.Unmarshal([]byte(inactiveXML), &desc); err != nil {
+		t.Error(err)
+	} else if desc.CurrentMemory != newMemory {
+		t.Errorf("unexpected configured domain memory after ballooning; got=%v, want=%v", desc.CurrentMemory, newMemory)
+	}
+
+	// the balloon driver changes the memory asynchronously, and only if it's
+	// active in the guest
+	timeout := time.After(5 * time.Second)
+	for memory != newMemory {
+		select {
+		case <-timeout:
+			t.Skipf("the balloon driver did not change the domain memory; got=%v, want=%v", memory, newMemory)
+		case <-time.After(100 * time.Millisecond):
+		}
+
+		if memory, err = env.dom.InfoMemory(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestDomainCPUStats(t *testing.T) {
+	env := newTestEnvironment(t).withDomain()
+	defer env.cleanUp()
+
+	if err := env.dom.Create(DomCreateDefault); err != nil {
+		t.Fatal(err)
+	}
+
+	stats, err := env.dom.CPUStatsTotal(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(1 * time.Second)
+
+	newStats, err := env.dom.CPUStatsTotal(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if newStats.CPUTime < stats.CPUTime {
+		t.Errorf("the domain CPU time decreased; before=%v, after=%v", stats.CPUTime, newStats.CPUTime)
+	}
+
+	perCPU, err := env.dom.CPUStatsPerCPU(0, 1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(perCPU) != 1 {
+		t.Errorf("unexpected number of per-CPU statistics; got=%v, want=1", len(perCPU))
+	}
+
+	if _, err = env.dom.CPUStatsPerCPU(-1, 1, 0); err != ErrInvalidStartCPU {
+		t.Errorf("unexpected error when reading the statistics of a negative CPU; got=%v, want=%v", err, ErrInvalidStartCPU)
+	}
+
+	if perCPU, err = env.dom.CPUStatsPerCPU(0, 128, 0); err != nil {
+		t.Error(err)
+	} else if _, cpus, err := env.conn.CPUMap(); err == nil && len(perCPU) > len(cpus) {
+		t.Errorf("more per-CPU statistics than host CPUs were returned; got=%v, want<=%v", len(perCPU), len(cpus))
+	}
+}
+
+func TestDomainJobInfo(t *testing.T) {
+	env := newTestEnvironment(t).withDomain()
+	defer env.cleanUp()
+
+	if err := env.dom.Create(DomCreateDefault); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := env.dom.JobInfo()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info != (DomainJobInfo{Type: DomJobNone}) {
+		t.Errorf("unexpected job information without an active job; got=%+v, want=%+v", info, DomainJobInfo{Type: DomJobNone})
+	}
+
+	saved := make(chan error, 1)
+	go func() {
+		saved <- env.dom.ManagedSave(DomSaveDefault)
+	}()
+
+	// the save may finish before any progress can be seen
+	var processed uint64
+	for done := false; !done; {
+		select {
+		case err = <-saved:
+			done = true
+		case <-time.After(10 * time.Millisecond):
+			info, err := env.dom.JobInfo()
+			if err != nil || info.Type == DomJobNone {
+				continue
+			}
+
+			if info.DataProcessed < processed {
+				t.Errorf("the processed job data decreased; before=%v, after=%v", processed, info.DataProcessed)
+			}
+			processed = info.DataProcessed
+		}
+	}
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = env.dom.ManagedSaveRemove(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestDomainJobStats(t *testing.T) {
+	env := newTestEnvironment(t).withDomain()
+	defer env.cleanUp()
+
+	if err := env.dom.Create(DomCreateDefault); err != nil {
+		t.Fatal(err)
+	}
+
+	if typ, _, err := env.dom.JobStats(DomJobStatsDefault); err != nil {
+		t.Error(err)
+	} else if typ != DomJobNone {
+		t.Errorf("unexpected job type without an active job; got=%v, want=%v", typ, DomJobNone)
+	}
+
+	if err := env.dom.ManagedSave(DomSaveDefault); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := env.dom.ManagedSaveRemove(); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	typ, params, err := env.dom.JobStats(DomJobStatsCompleted)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if typ == DomJobNone {
+		t.Skip("the statistics of the completed save job were not kept")
+	}
+
+	if typ != DomJobCompleted {
+		t.Errorf("unexpected type of the completed job; got=%v, want=%v", typ, DomJobCompleted)
+	}
+
+	if _, ok := params[DomJobStatsTimeElapsed]; !ok {
+		t.Errorf("the completed job statistics do not have %q; got=%v", DomJobStatsTimeElapsed, params)
+	}
+}
+
+func TestDomainAbortJob(t *testing.T) {
+	env := newTestEnvironment(t).withDomain()
+	defer env.cleanUp()
+
+	if err := env.dom.Create(DomCreateDefault); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := env.dom.AbortJob(); !errors.Is(err, &Error{Code: ErrOperationInvalid}) {
+		t.Errorf("unexpected error when aborting a job which does not exist; got=%v, want code=%v", err, ErrOperationInvalid)
+	}
+
+	if err := env.dom.AbortJobFlags(DomAbortJobDefault); !errors.Is(err, &Error{Code: ErrOperationInvalid}) {
+		t.Errorf("unexpected error when aborting a job which does not exist with flags; got=%v, want code=%v", err, ErrOperationInvalid)
+	}
+
+	saved := make(chan error, 1)
+	go func() {
+		saved <- env.dom.ManagedSave(DomSaveDefault)
+	}()
+
+	aborted := false
+	for done := false; !done; {
+		select {
+		case err := <-saved:
+			if !aborted {
+				if err == nil {
+					if err = env.dom.ManagedSaveRemove(); err != nil {
+						t.Error(err)
+					}
+				}
+				t.Skip("the save finished before it could be aborted")
+			}
+
+			if err == nil {
+				t.Fatal("an error was not returned by an aborted save")
+			}
+			done = true
+		case <-time.After(10 * time.Millisecond):
+			if aborted {
+				continue
+			}
+
+			if info, err := env.dom.JobInfo(); err == nil && info.Type != DomJobNone {
+				aborted = env.dom.AbortJob() == nil
+			}
+		}
+	}
+
+	if state, _, err := env.dom.State(); err != nil {
+		t.Error(err)
+	} else if state != DomStateRunning {
+		t.Errorf("unexpected domain state after aborting its save; got=%v, want=%v", state, DomStateRunning)
+	}
+
+	if info, err := env.dom.JobInfo(); err != nil {
+		t.Error(err)
+	} else if info.Type != DomJobNone {
+		t.Errorf("unexpected job type after aborting the job; got=%v, want=%v", info.Type, DomJobNone)
+	}
+}
+
+func TestDomainMemoryStats(t *testing.T) {
+	env := newTestEnvironment(t).withDomain()
+	defer env.cleanUp()
+
+	if _, err := env.dom.MemoryStats(DomMemoryStatsAll, 0); err == nil {
+		t.Error("an error was not returned when reading the memory statistics of an inactive domain")
+	}
+
+	if err := env.dom.Create(DomCreateDefault); err != nil {
+		t.Fatal(err)
+	}
+
+	stats, err := env.dom.MemoryStats(DomMemoryStatsAll, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the balloon may not have reached the configured memory yet
+	if stats.ActualBalloon == nil {
+		t.Error("the balloon size was not reported")
+	} else if *stats.ActualBalloon == 0 || *stats.ActualBalloon > env.domData.MaxMemory {
+		t.Errorf("unexpected balloon size; got=%v, want=(0,%v]", *stats.ActualBalloon, env.domData.MaxMemory)
+	}
+
+	if stats, err = env.dom.MemoryStats(1, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	reported := 0
+	for _, value := range []*uint64{stats.SwapIn, stats.SwapOut, stats.MajorFault, stats.MinorFault, stats.Unused, stats.Available, stats.ActualBalloon, stats.RSS, stats.Usable, stats.LastUpdate, stats.DiskCaches, stats.HugetlbPgAlloc, stats.HugetlbPgFail} {
+		if value != nil {
+			reported++
+		}
+	}
+
+	if reported > 1 {
+		t.Errorf("unexpected number of memory statistics; got=%v, want<=1", reported)
+	}
+}
+
+func TestDomainSetMemoryStatsPeriod(t *testing.T) {
+	env := newTestEnvironment(t).withDomain()
+	defer env.cleanUp()
+
+	if err := env.dom.Create(DomCreateDefault); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := env.dom.SetMemoryStatsPeriod(-1, DomMemoryLive); err == nil {
+		t.Error("an error was not returned when setting a negative memory statistics period")
+	}
+
+	if err := env.dom.SetMemoryStatsPeriod(1, DomMemoryLive); err != nil {
+		t.Fatal(err)
+	}
+
+	if xml, err := env.dom.XML(DomXMLDefault); err != nil {
+		t.Error(err)
+	} else if !strings.Contains(xml, "<stats period='1'/>") {
+		t.Error("the memory statistics period was not found in the domain XML")
+	}
+}
+
+func TestDomainVCPUs(t *testing.T) {
+	env := newTestEnvironment(t).withDomain()
+	defer env.cleanUp()
+
+	newMaxVCPUs := int32(rand.Intn(int(env.domData.MaxVCPUs)) + 1)
+	newVCPUs := int32(rand.Intn(int(newMaxVCPUs)) + 1)
+
+	if err := env.dom.SetVCPUs(0, DomVCPUsCurrent); err == nil {
+		t.Error("an error was not returned when setting an invalid VCPU number")
+	}
+
+	if err := env.dom.SetVCPUs(uint32(newVCPUs), DomainVCPUsFlag(99)); err == nil {
+		t.Error("an error was not returned when using an invalid flag to set VCPU")
+	}
+
+	if _, err := env.dom.VCPUs(DomainVCPUsFlag(99)); err == nil {
+		t.Error("an error was not returned when using an invalid flag to get VCPU")
+	}
+
+	vcpus, err := env.dom.VCPUs(DomVCPUsCurrent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vcpus != env.domData.VCPUs {
+		t.Errorf("wrong VCPUs number; got=%v, want=%v", vcpus, env.domData.VCPUs)
+	}
+
+	maxVCPUs, err := env.dom.VCPUs(DomVCPUsMaximum)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if maxVCPUs != env.domData.MaxVCPUs {
+		t.Errorf("wrong maximum VCPUs number; got=%v, want=%v", maxVCPUs, env.domData.MaxVCPUs)
+	}
+
+	if err = env.dom.SetVCPUs(uint32(newVCPUs), DomVCPUsCurrent); err != nil {
+		t.Fatal(err)
+	}
+
+	vcpus, err = env.dom.VCPUs(DomVCPUsCurrent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vcpus != newVCPUs {
+		t.Errorf("wrong VCPUs count; got=%v, want=%v", vcpus, newVCPUs)
+	}
+
+	if err = env.dom.SetVCPUs(uint32(newMaxVCPUs), DomVCPUsMaximum); err != nil {
+		t.Fatal(err)
+	}
+
+	maxVCPUs, err = env.dom.VCPUs(DomVCPUsMaximum)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if maxVCPUs != newMaxVCPUs {
+		t.Errorf("wrong new maximum VCPUs number; got=%v, want=%v", maxVCPUs, newMaxVCPUs)
+	}
+
+	if err = env.dom.SetVCPUs(uint32(newMaxVCPUs+1), DomVCPUsCurrent); err == nil {
+		t.Error("an error was not returned when setting a VCPU number greater than the maximum allowed")
+	}
+}
+
+func TestDomainVCPUsHotplug(t *testing.T) {
+	env := newTestEnvironment(t)
+	defer env.cleanUp()
+
+	var xml bytes.Buffer
+	data, err := newTestDomainData(*env.conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer data.cleanUp(*env.conn)
+
+	data.VCPUs = 1
+	data.MaxVCPUs = 2
+
+	if err = testDomainTmpl.Execute(&xml, data); err != nil {
+		t.Fatal(err)
+	}
+
+	dom, err := env.conn.DefineDomain(xml.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dom.Free()
+	defer dom.Undefine(DomUndefineDefault)
+
+	if err = dom.Create(DomCreateAutodestroy); err != nil {
+		t.Fatal(err)
+	}
+	defer dom.Destroy(DomDestroyDefault)
+
+	maxVCPUs, err := dom.MaxVCPUs()
+	if err != nil {
+		t.Error(err)
+	}
+	if maxVCPUs != data.MaxVCPUs {
+		t.Errorf("wrong maximum VCPUs number; got=%v, want=%v", maxVCPUs, data.MaxVCPUs)
+	}
+
+	if err = dom.SetVCPUs(uint32(data.MaxVCPUs+1), DomVCPUsLive); err == nil {
+		t.Error("an error was not returned when hotplugging more VCPUs than the maximum allowed")
+	}
+
+	if err = dom.SetVCPUs(uint32(data.MaxVCPUs), DomVCPUsLive); err != nil {
+		t.Fatal(err)
+	}
+
+	vcpus, err := dom.VCPUs(DomVCPUsLive)
+	if err != nil {
+		t.Error(err)
+	}
+	if vcpus != data.MaxVCPUs {
+		t.Errorf("wrong VCPUs number after hotplugging; got=%v, want=%v", vcpus, data.MaxVCPUs)
+	}
+
+	vcpus, err = dom.VCPUs(DomVCPusConfig)
+	if err != nil {
+		t.Error(err)
+	}
+	if vcpus != data.VCPUs {
+		t.Errorf("the persistent VCPUs number should not change after hotplugging; got=%v, want=%v", vcpus, data.VCPUs)
+	}
+
+	if err = dom.SetVCPU("0", false, DomAffectLive); err == nil {
+		t.Error("an error was not returned when disabling the VCPU 0")
+	}
+
+	// the VCPU 1 has been hotplugged, so it can be unplugged individually
+	if err = dom.SetVCPU("1", false, DomAffectLive); err != nil {
+		t.Fatal(err)
+	}
+
+	if vcpus, err = dom.VCPUs(DomVCPUsLive); err != nil {
+		t.Error(err)
+	} else if vcpus != data.VCPUs {
+		t.Errorf("wrong VCPUs number after disabling a VCPU; got=%v, want=%v", vcpus, data.VCPUs)
+	}
+
+	if xml, err := dom.XML(DomXMLDefault); err != nil {
+		t.Error(err)
+	} else if !strings.Contains(xml, "<vcpu id='1' enabled='no'") {
+		t.Error("the disabled VCPU was not found in the domain XML")
+	}
+
+	// the test domain doesn't have a guest agent
+	if _, err = dom.VCPUs(DomVCPUsGuest); err == nil {
+		t.Error("an error was not returned when querying the guest VCPUs without a guest agent")
+	} else if _, ok := err.(*Error); !ok {
+		t.Errorf("the returned error should be a libvirt error; got=%T", err)
+	}
+}
+
+func TestDomainGuestVCPUs(t *testing.T) {
+	env := newTestEnvironment(t).withDomain()
+	defer env.cleanUp()
+
+	if err := env.dom.Create(DomCreateDefault); err != nil {
+		t.Fatal(err)
+	}
+
+	params, err := env.dom.GuestVCPUs()
+	if errors.Is(err, &Error{Code: ErrArgumentUnsupported}) || errors.Is(err, &Error{Code: ErrAgentUnresponsive}) {
+		if err = env.dom.SetGuestVCPUs("0", true); !errors.Is(err, &Error{Code: ErrArgumentUnsupported}) && !errors.Is(err, &Error{Code: ErrAgentUnresponsive}) {
+			t.Errorf("unexpected error when changing the guest VCPUs without a guest agent; got=%v", err)
+		}
+
+		t.Skip("the test domain does not have a guest agent")
+	}
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	online, err := ParseCPUMapString(fmt.Sprint(params[DomGuestVCPUsOnline]))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(online) == 0 || !online[0] {
+		t.Errorf("the VCPU 0 should be online in the guest; got=%v", params[DomGuestVCPUsOnline])
+	}
+}
+
+func TestDomainVCPUInfo(t *testing.T) {
+	env := newTestEnvironment(t).withDomain()
+	defer env.cleanUp()
+
+	if _, err := env.dom.VCPUInfo(); err == nil {
+		t.Error("an error was not returned when reading the VCPUs information of an inactive domain")
+	}
+
+	if err := env.dom.Create(DomCreateDefault); err != nil {
+		t.Fatal(err)
+	}
+
+	_, cpus, err := env.conn.CPUMap()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := env.dom.VCPUInfo()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(info) != int(env.domData.VCPUs) {
+		t.Errorf("unexpected number of VCPUs; got=%v, want=%v", len(info), env.domData.VCPUs)
+	}
+
+	for _, vcpu := range info {
+		if len(vcpu.CPUs) != len(cpus) {
+			t.Errorf("unexpected CPU affinity length of VCPU %v; got=%v, want=%v", vcpu.Number, len(vcpu.CPUs), len(cpus))
+		}
+	}
+}
+
+func TestDomainPinVCPU(t *testing.T) {
+	env := newTestEnvironment(t).withDomain()
+	defer env.cleanUp()
+
+	if err := env.dom.Create(DomCreateDefault); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := env.dom.PinVCPU(0, nil, DomAffectLive); err != ErrEmptyCPUMap {
+		t.Errorf("unexpected error when pinning a VCPU to no CPU; got=%v, want=%v", err, ErrEmptyCPUMap)
+	}
+
+	if err := env.dom.PinVCPU(0, []bool{true}, DomAffectLive); err != nil {
+		t.Fatal(err)
+	}
+
+	pinning, err := env.dom.VCPUPinInfo(DomAffectLive)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(pinning) != int(env.domData.MaxVCPUs) {
+		t.Errorf("unexpected number of VCPUs in the pinning information; got=%v, want=%v", len(pinning), env.domData.MaxVCPUs)
+	}
+
+	if len(pinning) > 0 {
+		want := make([]bool, len(pinning[0]))
+		want[0] = true
+
+		if !reflect.DeepEqual(pinning[0], want) {
+			t.Errorf("unexpected CPU map of the pinned VCPU; got=%v, want=%v", pinning[0], want)
+		}
+	}
+}
+
+func TestDomainPinEmulator(t *testing.T) {
+	env := newTestEnvironment(t).withDomain()
+	defer env.cleanUp()
+
+	if err := env.dom.Create(DomCreateDefault); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := env.dom.PinEmulator(nil, DomAffectLive); err != ErrEmptyCPUMap {
+		t.Errorf("unexpected error when pinning the emulator to no CPU; got=%v, want=%v", err, ErrEmptyCPUMap)
+	}
+
+	if err := env.dom.PinEmulator([]bool{true}, DomAffectLive); err != nil {
+		t.Fatal(err)
+	}
+
+	cpus, err := env.dom.EmulatorPinInfo(DomAffectLive)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := make([]bool, len(cpus))
+	if len(want) > 0 {
+		want[0] = true
+	}
+
+	if len(cpus) == 0 || !reflect.DeepEqual(cpus, want) {
+		t.Errorf("unexpected CPU map of the pinned emulator; got=%v, want=%v", cpus, want)
+	}
+}
+
+func TestDomainIOThreads(t *testing.T) {
+	env := newTestEnvironment(t).withDomain()
+	defer env.cleanUp()
+
+	if err := env.dom.Create(DomCreateDefault); err != nil {
+		t.Fatal(err)
+	}
+
+	const id = 1
+
+	if err := env.dom.DelIOThread(id, DomAffectLive); err == nil {
+		t.Error("an error was not returned when deleting an I/O thread which does not exist")
+	}
+
+	if err := env.dom.AddIOThread(id, DomAffectLive); err != nil {
+		t.Fatal(err)
+	}
+
+	threads, err := env.dom.IOThreadInfo(DomAffectLive)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(threads) != 1 || threads[0].ID != id {
+		t.Fatalf("unexpected I/O threads after adding one; got=%+v, want ID=%v", threads, id)
+	}
+
+	if err = env.dom.PinIOThread(id, nil, DomAffectLive); err != ErrEmptyCPUMap {
+		t.Errorf("unexpected error when pinning an I/O thread to no CPU; got=%v, want=%v", err, ErrEmptyCPUMap)
+	}
+
+	if err = env.dom.PinIOThread(id, []bool{true}, DomAffectLive); err != nil {
+		t.Fatal(err)
+	}
+
+	if threads, err = env.dom.IOThreadInfo(DomAffectLive); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(threads) == 1 {
+		want := make([]bool, len(threads[0].CPUs))
+		if len(want) > 0 {
+			want[0] = true
+		}
+
+		if len(want) == 0 || !reflect.DeepEqual(threads[0].CPUs, want) {
+			t.Errorf("unexpected CPU map of the pinned I/O thread; got=%v, want=%v", threads[0].CPUs, want)
+		}
+	}
+
+	params := TypedParams{
+		DomIOThreadPollMaxNS: uint64(32768),
+	}
+
+	if err = env.dom.SetIOThreadParams(id+1, params, DomAffectLive); err == nil {
+		t.Error("an error was not returned when changing the parameters of an I/O thread which does not exist")
+	}
+
+	if err = env.dom.SetIOThreadParams(id, params, DomAffectLive); err != nil {
+		t.Error(err)
+	}
+
+	if err = env.dom.DelIOThread(id, DomAffectLive); err != nil {
+		t.Fatal(err)
+	}
+
+	if threads, err = env.dom.IOThreadInfo(DomAffectLive); err != nil {
+		t.Error(err)
+	} else if len(threads) != 0 {
+		t.Errorf("unexpected I/O threads after deleting them; got=%+v", threads)
+	}
+}
+
+func TestDomainInfo(t *testing.T) {
+	env := newTestEnvironment(t).withDomain()
+	defer env.cleanUp()
+
+	if err := env.dom.Create(DomCreateDefault); err != nil {
+		t.Fatal(err)
+	}
+
+	state, err := env.dom.InfoState()
+	if err != nil {
+		t.Error(err)
+	}
+	otherState, _, err := env.dom.State()
+	if err != nil {
+		t.Error(err)
+	}
+	if state != otherState {
+		t.Errorf("domain states obtained from different functions do not match; state1=%v, state2=%v", state, otherState)
+	}
+
+	maxMemory, err := env.dom.InfoMaxMemory()
+	if err != nil {
+		t.Error(err)
+	}
+	otherMaxMemory, err := env.dom.MaxMemory()
+	if err != nil {
+		t.Error(err)
+	}
+	if maxMemory != otherMaxMemory {
+		t.Errorf("domain maximum memories obtained from different functions do not match; memory1=%v, memory2=%v", maxMemory, otherMaxMemory)
+	}
+
+	vcpus, err := env.dom.InfoVCPUs()
+	if err != nil {
+		t.Error(err)
+	}
+	otherVcpus, err := env.dom.VCPUs(DomVCPUsCurrent)
+	if err != nil {
+		t.Error(err)
+	}
+	if vcpus != uint16(otherVcpus) {
+		t.Errorf("numbers of domain VCPUs obtained from different functions do not match; VCPUs1=%v, VCPUs2=%v", vcpus, otherVcpus)
+	}
+
+	memory, err := env.dom.InfoMemory()
+	if err != nil {
+		t.Error(err)
+	}
+	if memory != env.domData.Memory {
+		t.Errorf("wrong memory value; got=%v, want=%v", memory, env.domData.Memory)
+	}
+
+	if _, err = env.dom.InfoCPUTime(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestDomainCreate(t *testing.T) {
+	env := newTestEnvironment(t).withDomain()
+	defer env.cleanUp()
+
+	if err := env.dom.Create(DomCreatePaused); err != nil {
+		t.Fatal(err)
+	}
+
+	state, _, err := env.dom.State()
+	if err != nil {
+		t.Error(err)
+	}
+	if state != DomStatePaused {
+		t.Errorf("unexpected domain state after starting paused; got=%v, want=%v", state, DomStatePaused)
+	}
+
+	if err = env.dom.Resume(); err != nil {
+		t.Fatal(err)
+	}
+
+	if state, _, err = env.dom.State(); err != nil {
+		t.Error(err)
+	}
+	if state != DomStateRunning {
+		t.Errorf("unexpected domain state after resuming; got=%v, want=%v", state, DomStateRunning)
+	}
+
+	if err = env.dom.Create(DomCreateDefault); err == nil {
+		t.Error("an error was not returned when starting a running domain")
+	} else if !errors.Is(err, &Error{Code: ErrOperationInvalid}) {
+		t.Errorf("unexpected error when starting a running domain; got=%v, want code=%v", err, ErrOperationInvalid)
+	}
+
+	if err = env.dom.Destroy(DomDestroyDefault); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = env.dom.Create(DomCreateForceBoot); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestDomainCreateWithFiles(t *testing.T) {
+	env := newTestEnvironment(t).withDomain()
+	defer env.cleanUp()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+
+	files := []*os.File{r, w}
+
+	cFDs := cFileDescriptors(files)
+	if len(cFDs) != len(files) {
+		t.Fatalf("unexpected number of file descriptors; got=%v, want=%v", len(cFDs), len(files))
+	}
+
+	for i, file := range files {
+		if uintptr(cFDs[i]) != file.Fd() {
+			t.Errorf("unexpected file descriptor #%v; got=%v, want=%v", i, cFDs[i], file.Fd())
+		}
+	}
+
+	if err = env.dom.CreateWithFiles(files, DomCreateDefault); err != nil {
+		if errors.Is(err, &Error{Code: ErrNoSupport}) || errors.Is(err, &Error{Code: ErrOperationInvalid}) {
+			t.Skipf("passing files is not supported by the driver: %v", err)
+		}
+		t.Fatal(err)
+	}
+
+	if err = env.dom.Destroy(DomDestroyDefault); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestDomainSaveRestore(t *testing.T) {
+	env := newTestEnvironment(t).withDomain()
+	defer env.cleanUp()
+
+	if err := env.dom.Create(DomCreateDefault); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := env.dom.Save("", "", DomSaveDefault); err == nil {
+		t.Error("an error was not returned when using an invalid file name")
+	}
+
+	file, ioerr := ioutil.TempFile("", fmt.Sprintf("%v-save-restore_", env.domData.Name))
+	if ioerr != nil {
+		t.Fatal(ioerr)
+	}
+	defer os.Remove(file.Name())
+
+	if err := env.dom.Save(file.Name(), "", DomainSaveFlag(99)); err == nil {
+		t.Error("an error was not returned when using an invalid save flag")
+	}
+
+	if err := env.dom.Save(file.Name(), "", DomSaveDefault); err != nil {
+		t.Error(err)
+	}
+
+	domains, err := env.conn.ListDomains(DomListActive)
+	if err != nil {
+		t.Error(err)
+	}
+
+	for _, d := range domains {
+		if name, err := d.Name(); err != nil {
+			t.Error(err)
+		} else if name == env.domData.Name {
+			t.Error("the domain should not be listed as active after being saved")
+		}
+
+		d.Free()
+	}
+
+	state, reason, err := env.dom.State()
+	if err != nil {
+		t.Error(err)
+	}
+	if state != DomStateShutoff || DomainShutoffReason(reason) != DomShutoffReasonSaved {
+		t.Errorf("unexpected domain state; got=%v (reason %v), want=%v (reason %v)", state, reason, DomStateShutoff, DomShutoffReasonSaved)
+	}
+
+	if err = env.conn.RestoreDomain(utils.RandomString()); err == nil {
+		t.Error("an error was not returned when restoring a domain from a non-existing file")
+	}
+
+	if err = env.conn.RestoreDomain(file.Name()); err != nil {
+		t.Fatal(err)
+	}
+
+	state, reason, err = env.dom.State()
+	if err != nil {
+		t.Error(err)
+	}
+	if state != DomStateRunning || DomainRunningReason(reason) != DomRunningReasonRestored {
+		t.Errorf("unexpected domain state; got=%v (reason %v), want=%v (reason %v)", state, reason, DomStateRunning, DomRunningReasonRestored)
+	}
+
+	if err = env.dom.Save(file.Name(), "", DomSaveDefault); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = env.conn.RestoreDomainFlags(file.Name(), "", DomSavePaused); err != nil {
+		t.Fatal(err)
+	}
+
+	state, _, err = env.dom.State()
+	if err != nil {
+		t.Error(err)
+	}
+	if state != DomStatePaused {
+		t.Errorf("unexpected domain state; got=%v, want=%v", state, DomStatePaused)
+	}
+}
+
+func TestDomainSaveImageXML(t *testing.T) {
+	env := newTestEnvironment(t).withDomain()
+	defer env.cleanUp()
+
+	if _, err := env.conn.SaveImageXML(utils.RandomString(), DomXMLDefault); err == nil {
+		t.Error("an error was not returned when reading the XML of a non-existing saved domain")
+	}
+
+	if err := env.dom.Create(DomCreateDefault); err != nil {
+		t.Fatal(err)
+	}
+
+	file, ioerr := ioutil.TempFile("", fmt.Sprintf("%v-save-image_", env.domData.Name))
+	if ioerr != nil {
+		t.Fatal(ioerr)
+	}
+	defer os.Remove(file.Name())
+
+	if err := env.dom.Save(file.Name(), "", DomSaveDefault); err != nil {
+		t.Fatal(err)
+	}
+
+	xml, err := env.conn.SaveImageXML(file.Name(), DomXMLDefault)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	description := utils.RandomString()
+	xml = strings.Replace(xml, "</name>", fmt.Sprintf("</name><description>%v</description>", description), 1)
+
+	if err = env.conn.DefineSaveImageXML(file.Name(), "", DomSaveDefault); err == nil {
+		t.Error("an error was not returned when defining an empty saved domain XML")
+	}
+
+	if err = env.conn.DefineSaveImageXML(file.Name(), xml, DomSaveDefault); err != nil {
+		t.Fatal(err)
+	}
+
+	if xml, err = env.conn.SaveImageXML(file.Name(), DomXMLDefault); err != nil {
+		t.Error(err)
+	} else if !strings.Contains(xml, description) {
+		t.Error("the saved domain XML was not updated")
+	}
+
+	if err = env.conn.RestoreDomain(file.Name()); err != nil {
+		t.Fatal(err)
+	}
+
+	if xml, err = env.dom.XML(DomXMLDefault); err != nil {
+		t.Error(err)
+	} else if !strings.Contains(xml, description) {
+		t.Error("the restored domain does not have the updated XML")
+	}
+}
+
+func TestDomainHotplugDisk(t *testing.T) {
+	env := newTestEnvironment(t).withDomain().withStorageVolume()
+	defer env.cleanUp()
+
+	if err := env.dom.Create(DomCreateDefault); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := env.dom.AttachDevice("<disk", DomDeviceModifyLive); err == nil {
+		t.Error("an error was not returned when attaching an invalid device XML")
+	} else if _, ok := err.(*Error); !ok {
+		t.Errorf("the returned error should be a libvirt error; got=%T", err)
+	}
+
+	diskXML := env.diskDeviceXML("vdb")
+
+	if err := env.dom.AttachDevice(diskXML, DomDeviceModifyLive); err != nil {
+		t.Fatal(err)
+	}
+
+	path, err := env.vol.Path()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if xml, err := env.dom.XML(DomXMLDefault); err != nil {
+		t.Error(err)
+	} else if !strings.Contains(xml, path) {
+		t.Error("the attached disk was not found in the domain XML")
+	}
+
+	if xml, err := env.dom.XML(DomXMLInactive); err != nil {
+		t.Error(err)
+	} else if strings.Contains(xml, path) {
+		t.Error("a disk attached only to the live domain was found in the persistent domain XML")
+	}
+
+	if err := env.dom.DetachDevice(diskXML, DomDeviceModifyLive); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestDomainDetachDeviceAlias(t *testing.T) {
+	stopEventLoop := testRunEventLoop(t)
+	defer stopEventLoop()
+
+	env := newTestEnvironment(t).withDomain().withStorageVolume()
+	defer env.cleanUp()
+
+	if err := env.dom.Create(DomCreateDefault); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := env.dom.AttachDevice(env.diskDeviceXML("vdb"), DomDeviceModifyLive); err != nil {
+		t.Fatal(err)
+	}
+
+	liveXML, err := env.dom.XML(DomXMLDefault)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var domXML struct {
+		Disks []struct {
+			Target struct {
+				Dev string `xml:"dev,attr"`
+			} `xml:"target"`
+			Alias struct {
+				Name string `xml:"name,attr"`
+			} `xml:"alias"`
+		} `xml:"devices>disk"`
+	}
+
+	if err = xml.Unmarshal([]byte(liveXML), &domXML); err != nil {
+		t.Fatal(err)
+	}
+
+	var alias string
+	for _, disk := range domXML.Disks {
+		if disk.Target.Dev == "vdb" {
+			alias = disk.Alias.Name
+		}
+	}
+
+	if alias == "" {
+		t.Fatal("the alias of the attached disk was not found in the domain XML")
+	}
+
+	if err = env.dom.DetachDeviceAlias(utils.RandomString(), DomDeviceModifyLive); err == nil {
+		t.Error("an error was not returned when detaching a device with an unknown alias")
+	}
+
+	removed := make(chan string, 10)
+
+	id, err := env.conn.RegisterDomainDeviceRemovedEvent(env.dom, func(dom Domain, devAlias string) {
+		removed <- devAlias
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer env.conn.DeregisterDomainEvent(id)
+
+	if err = env.dom.DetachDeviceAlias(alias, DomDeviceModifyLive); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case got := <-removed:
+		if got != alias {
+			t.Errorf("unexpected alias of the removed device; got=%v, want=%v", got, alias)
+		}
+	case <-time.After(30 * time.Second):
+		t.Error("the device removed event was not received")
+	}
+}
+
+func TestDomainUpdateCDROM(t *testing.T) {
+	env := newTestEnvironment(t).withDomain()
+	defer env.cleanUp()
+
+	var isoPaths [2]string
+	var cdromXMLs [2]string
+
+	for i := range isoPaths {
+		iso, err := ioutil.TempFile("", "cdrom-")
+		if err != nil {
+			t.Fatal(err)
+		}
+		iso.Close()
+		defer os.Remove(iso.Name())
+
+		var xml bytes.Buffer
+		if err = testDeviceCDROMTmpl.Execute(&xml, iso.Name()); err != nil {
+			t.Fatal(err)
+		}
+
+		isoPaths[i] = iso.Name()
+		cdromXMLs[i] = xml.String()
+	}
+
+	if err := env.dom.AttachDevice(cdromXMLs[0], DomDeviceModifyConfig); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := env.dom.Create(DomCreateDefault); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := env.dom.UpdateDevice(cdromXMLs[1], DomDeviceModifyLive); err != nil {
+		t.Fatal(err)
+	}
+
+	if xml, err := env.dom.XML(DomXMLDefault); err != nil {
+		t.Error(err)
+	} else if !strings.Contains(xml, isoPaths[1]) {
+		t.Error("the live domain XML does not have the new CD-ROM media")
+	}
+
+	if xml, err := env.dom.XML(DomXMLInactive); err != nil {
+		t.Error(err)
+	} else if !strings.Contains(xml, isoPaths[0]) || strings.Contains(xml, isoPaths[1]) {
+		t.Error("the persistent domain XML should still have the old CD-ROM media after a live update")
+	}
+
+	if err := env.dom.UpdateDevice(cdromXMLs[1], DomDeviceModifyConfig); err != nil {
+		t.Fatal(err)
+	}
+
+	if xml, err := env.dom.XML(DomXMLInactive); err != nil {
+		t.Error(err)
+	} else if !strings.Contains(xml, isoPaths[1]) {
+		t.Error("the persistent domain XML does not have the new CD-ROM media")
+	}
+}
+
+func TestDomainIsUpdated(t *testing.T) {
+	env := newTestEnvironment(t).withDomain()
+	defer env.cleanUp()
+
+	if err := env.dom.Create(DomCreateAutodestroy); err != nil {
+		t.Fatal(err)
+	}
+
+	active, err := env.dom.IsActive()
+	if err != nil {
+		t.Error(err)
+	}
+	if !active {
+		t.Error("a started domain should be active")
+	}
+
+	persistent, err := env.dom.IsPersistent()
+	if err != nil {
+		t.Error(err)
+	}
+	if !persistent {
+		t.Error("a defined domain should be persistent")
+	}
+
+	updated, err := env.dom.IsUpdated()
+	if err != nil {
+		t.Error(err)
+	}
+	if updated {
+		t.Error("a started domain should not have been updated before attaching a device")
+	}
+
+	if err = env.dom.AttachDevice(testDeviceInterfaceXML, DomDeviceModifyLive); err != nil {
+		t.Fatal(err)
+	}
+
+	updated, err = env.dom.IsUpdated()
+	if err != nil {
+		t.Error(err)
+	}
+	if !updated {
+		t.Error("a domain should have been updated after attaching a device only to its live configuration")
+	}
+}
+
+func TestDomainDevices(t *testing.T) {
+	env := newTestEnvironment(t).withDomain()
+	defer env.cleanUp()
+
+	if err := env.dom.AttachDevice("", DomDeviceModifyCurrent); err == nil {
+		t.Error("an error was not returned when attaching an empty XML")
+	}
+
+	if err := env.dom.DetachDevice("", DomDeviceModifyCurrent); err == nil {
+		t.Error("an error was not returned when detaching an empty XML")
+	}
+
+	if err := env.dom.UpdateDevice("", DomDeviceModifyCurrent); err == nil {
+		t.Error("an error was not returned when updating an empty XML")
+	}
+
+	if err := env.dom.AttachDevice(testDeviceLogXML, DomainDeviceModifyFlag(99)); err == nil {
+		t.Error("an error was not returned when attaching a device with an invalid modify flag")
+	}
+
+	if err := env.dom.DetachDevice(testDeviceLogXML, DomainDeviceModifyFlag(99)); err == nil {
+		t.Error("an error was not returned when detaching a device with an invalid modify flag")
+	}
+
+	if err := env.dom.UpdateDevice(testDeviceLogXML, DomainDeviceModifyFlag(99)); err == nil {
+		t.Error("an error was not returned when updating a device with an invalid modify flag")
+	}
+
+	if err := env.dom.AttachDevice(testDeviceLogXML, DomDeviceModifyCurrent); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := env.dom.UpdateDevice(testDeviceTmpXML, DomDeviceModifyCurrent); err != nil {
+		t.Error(err)
+	}
+
+	if err := env.dom.DetachDevice(testDeviceTmpXML, DomDeviceModifyCurrent); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestDomainUndefineManagedSave(t *testing.T) {
+	env := newTestEnvironment(t)
+	defer env.cleanUp()
+
+	data, err := newTestDomainData(*env.conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer data.cleanUp(*env.conn)
+
+	var xml bytes.Buffer
+
+	if err = testDomainTmpl.Execute(&xml, data); err != nil {
+		t.Fatal(err)
+	}
+
+	dom, err := env.conn.DefineDomain(xml.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dom.Free()
+
+	if err = dom.Create(DomCreateDefault); err != nil {
+		dom.Undefine(DomUndefineDefault)
+		t.Fatal(err)
+	}
+
+	if err = dom.ManagedSave(DomSaveDefault); err != nil {
+		dom.Destroy(DomDestroyDefault)
+		dom.Undefine(DomUndefineDefault)
+		t.Fatal(err)
+	}
+
+	if err = dom.Undefine(DomUndefineDefault); err == nil {
+		t.Error("an error was not returned when undefining a domain with a managed save image")
+	} else if virErr, ok := err.(*Error); !ok || len(virErr.Message) == 0 {
+		t.Errorf("the returned error should be a libvirt error with a message; got=%v", err)
+	}
+
+	if err = dom.Undefine(DomUndefineManagedSave); err != nil {
+		t.Error(err)
+	}
+
+	if _, err = env.conn.LookupDomainByName(data.Name); err == nil {
+		t.Error("a domain was found after being undefined")
+	}
+}
+
+func TestDomainManagedSave(t *testing.T) {
+	env := newTestEnvironment(t).withDomain()
+	defer env.cleanUp()
+
+	if err := env.dom.Create(DomCreateDefault); err != nil {
+		t.Fatal(err)
+	}
+	// do not Destroy the domain - it will be already destroyed in the end
+
+	if err := env.dom.ManagedSave(DomainSaveFlag(99)); err == nil {
+		t.Error("an error was not returned when using an invalid save flag")
+	}
+
+	hasManagedSave, err := env.dom.HasManagedSaveImage()
+	if err != nil {
+		t.Error(err)
+	}
+	if hasManagedSave {
+		t.Error("the test domain should not have a managed save image initially")
+	}
+
+	if err := env.dom.ManagedSave(DomSaveDefault); err != nil {
+		t.Error(err)
+	}
+
+	hasManagedSave, err = env.dom.HasManagedSaveImage()
+	if err != nil {
+		t.Error(err)
+	}
+	if !hasManagedSave {
+		t.Error("the test domain should have a managed save image after creating a managed save image")
+	}
+
+	state, reason, err := env.dom.State()
+	if err != nil {
+		t.Error(err)
+	}
+	if expectedState := DomStateShutoff; state != expectedState && DomainShutoffReason(reason) != DomShutoffReasonSaved {
+		t.Errorf("unexpected domain state; got=%v (reason %v), want=%v (reason %v)", state, reason, expectedState, DomShutoffReasonSaved)
+	}
+
+	if err = env.dom.Create(DomCreateDefault); err != nil {
+		t.Error(err)
+	}
+
+	hasManagedSave, err = env.dom.HasManagedSaveImage()
+	if err != nil {
+		t.Error(err)
+	}
+	if hasManagedSave {
+		t.Error("the test domain should not have a managed save image anymore after starting from an existing managed save image")
+	}
+
+	state, reason, err = env.dom.State()
+	if err != nil {
+		t.Error(err)
+	}
+	if expectedState := DomStateRunning; state != expectedState && DomainRunningReason(reason) != DomRunningReasonRestored {
+		t.Errorf("unexpected domain state; got=%v (reason %v), want=%v (reason %v)", state, reason, expectedState, DomRunningReasonRestored)
+	}
+
+	if err := env.dom.ManagedSave(DomSaveDefault); err != nil {
+		t.Error(err)
+	}
+
+	state, reason, err = env.dom.State()
+	if err != nil {
+		t.Error(err)
+	}
+	if expectedState := DomStateShutoff; state != expectedState && DomainShutoffReason(reason) != DomShutoffReasonSaved {
+		t.Errorf("unexpected domain state; got=%v (reason %v), want=%v (reason %v)", state, reason, expectedState, DomShutoffReasonSaved)
+	}
+
+	hasManagedSave, err = env.dom.HasManagedSaveImage()
+	if err != nil {
+		t.Error(err)
+	}
+	if !hasManagedSave {
+		t.Error("the test domain should have a managed save image after creating a managed save image")
+	}
+
+	if err := env.dom.ManagedSaveRemove(); err != nil {
+		t.Error(err)
+	}
+
+	hasManagedSave, err = env.dom.HasManagedSaveImage()
+	if err != nil {
+		t.Error(err)
+	}
+	if hasManagedSave {
+		t.Error("the test domain should not have a managed save image anymore after removing it")
+	}
+}
+
+func TestDomainManagedSaveXML(t *testing.T) {
+	env := newTestEnvironment(t).withDomain()
+	defer env.cleanUp()
+
+	if _, err := env.dom.ManagedSaveXML(DomXMLDefault); err == nil {
+		t.Error("an error was not returned when reading the XML of a non-existing managed save image")
+	}
+
+	xml, err := env.dom.XML(DomXMLDefault)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = env.dom.ManagedSaveDefineXML(xml, DomSaveDefault); err == nil {
+		t.Error("an error was not returned when updating the XML of a non-existing managed save image")
+	}
+
+	if err = env.dom.Create(DomCreateDefault); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = env.dom.ManagedSave(DomSaveDefault); err != nil {
+		t.Fatal(err)
+	}
+	defer env.dom.ManagedSaveRemove()
+
+	if xml, err = env.dom.ManagedSaveXML(DomXMLDefault); err != nil {
+		t.Fatal(err)
+	}
+
+	description := utils.RandomString()
+	xml = strings.Replace(xml, "</name>", fmt.Sprintf("</name><description>%v</description>", description), 1)
+
+	if err = env.dom.ManagedSaveDefineXML(xml, DomSaveDefault); err != nil {
+		t.Fatal(err)
+	}
+
+	if xml, err = env.dom.ManagedSaveXML(DomXMLDefault); err != nil {
+		t.Error(err)
+	} else if !strings.Contains(xml, description) {
+		t.Error("the managed save image XML was not updated")
+	}
+}
+
+func TestDomainSetUserPassword(t *testing.T) {
+	env := newTestEnvironment(t).withDomain()
+	defer env.cleanUp()
+
+	if err := env.dom.Create(DomCreateDefault); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := env.dom.SetUserPassword("root", utils.RandomString(), DomainSetUserPasswordFlag(99)); err == nil {
+		t.Error("an error was not returned when using an invalid flag to set a user password")
+	}
+
+	err := env.dom.SetUserPassword("root", "$6$salt$hash", DomSetUserPasswordEncrypted)
+	if errors.Is(err, &Error{Code: ErrArgumentUnsupported}) || errors.Is(err, &Error{Code: ErrAgentUnresponsive}) {
+		t.Skip("the test domain does not have a guest agent")
+	}
+
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestDomainSchedulerParameters(t *testing.T) {
+	env := newTestEnvironment(t).withDomain()
+	defer env.cleanUp()
+
+	if err := env.dom.Create(DomCreateDefault); err != nil {
+		t.Fatal(err)
+	}
+
+	typ, nParams, err := env.dom.SchedulerType()
+	if errors.Is(err, &Error{Code: ErrOperationInvalid}) {
+		t.Skip("the cgroup CPU controller is not available")
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if typ == "" || nParams == 0 {
+		t.Errorf("unexpected scheduler type; got=%q (%v parameters)", typ, nParams)
+	}
+
+	params, err := env.dom.SchedulerParameters(DomAffectLive)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := params[DomSchedulerCPUShares]; !ok {
+		t.Errorf("the scheduler parameter %v was not returned; got=%v", DomSchedulerCPUShares, params)
+	}
+
+	if err = env.dom.SetSchedulerParameters(TypedParams{DomSchedulerCPUShares: uint64(512)}, DomAffectLive); err != nil {
+		t.Fatal(err)
+	}
+
+	if params, err = env.dom.SchedulerParameters(DomAffectLive); err != nil {
+		t.Error(err)
+	} else if shares := params[DomSchedulerCPUShares]; shares != uint64(512) {
+		t.Errorf("unexpected live CPU shares; got=%v, want=%v", shares, 512)
+	}
+
+	// the live and the persistent configurations can be changed together
+	if err = env.dom.SetSchedulerParameters(TypedParams{DomSchedulerCPUShares: uint64(256)}, DomAffectLive|DomAffectConfig); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, impact := range []DomainModificationImpact{DomAffectLive, DomAffectConfig} {
+		if params, err = env.dom.SchedulerParameters(impact); err != nil {
+			t.Error(err)
+		} else if shares := params[DomSchedulerCPUShares]; shares != uint64(256) {
+			t.Errorf("unexpected CPU shares (impact = %v); got=%v, want=%v", impact, shares, 256)
+		}
+	}
+}
+
+func TestDomainMemoryParameters(t *testing.T) {
+	env := newTestEnvironment(t).withDomain()
+	defer env.cleanUp()
+
+	params, err := env.dom.MemoryParameters(DomAffectConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if limit := params[DomMemoryParamHardLimit]; limit != DomMemoryParamUnlimited {
+		t.Errorf("unexpected initial memory hard limit; got=%v, want=%v", limit, DomMemoryParamUnlimited)
+	}
+
+	hardLimit := env.domData.Memory + 10240 // 10 MiB more
+
+	if err = env.dom.SetMemoryParameters(TypedParams{DomMemoryParamHardLimit: hardLimit}, DomAffectConfig); err != nil {
+		t.Fatal(err)
+	}
+
+	if params, err = env.dom.MemoryParameters(DomAffectConfig); err != nil {
+		t.Error(err)
+	} else if limit := params[DomMemoryParamHardLimit]; limit != hardLimit {
+		t.Errorf("unexpected memory hard limit; got=%v, want=%v", limit, hardLimit)
 	}
 
-	if err = env.dom.SetVCPUs(uint32(newMaxVCPUs), DomVCPUsMaximum); err != nil {
+	if err = env.dom.SetMemoryParameters(TypedParams{DomMemoryParamHardLimit: DomMemoryParamUnlimited}, DomAffectConfig); err != nil {
 		t.Fatal(err)
 	}
 
-	maxVCPUs, err = env.dom.VCPUs(DomVCPUsMaximum)
+	if params, err = env.dom.MemoryParameters(DomAffectConfig); err != nil {
+		t.Error(err)
+	} else if limit := params[DomMemoryParamHardLimit]; limit != DomMemoryParamUnlimited {
+		t.Errorf("unexpected memory hard limit after removing it; got=%v, want=%v", limit, DomMemoryParamUnlimited)
+	}
+}
+
+func TestDomainNUMAParameters(t *testing.T) {
+	env := newTestEnvironment(t).withDomain()
+	defer env.cleanUp()
+
+	params, err := env.dom.NUMAParameters(DomAffectConfig)
 	if err != nil {
 		t.Fatal(err)
 	}
-	if maxVCPUs != newMaxVCPUs {
-		t.Errorf("wrong new maximum VCPUs number; got=%v, want=%v", maxVCPUs, newMaxVCPUs)
+
+	if mode, ok := params[DomNUMAMode].(int32); !ok || DomainNUMATuneMode(mode) != DomNUMATuneModeStrict {
+		t.Errorf("unexpected default NUMA mode; got=%v, want=%v", params[DomNUMAMode], DomNUMATuneModeStrict)
 	}
 
-	if err = env.dom.SetVCPUs(uint32(newMaxVCPUs+1), DomVCPUsCurrent); err == nil {
-		t.Error("an error was not returned when setting a VCPU number greater than the maximum allowed")
+	if err = env.dom.SetNUMAParameters(TypedParams{DomNUMANodeset: "0"}, DomAffectConfig); err != nil {
+		t.Fatal(err)
+	}
+
+	if params, err = env.dom.NUMAParameters(DomAffectConfig); err != nil {
+		t.Error(err)
+	} else if nodeset := params[DomNUMANodeset]; nodeset != "0" {
+		t.Errorf("unexpected NUMA nodeset; got=%v, want=%v", nodeset, "0")
+	}
+
+	if err = env.dom.Create(DomCreateDefault); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = env.dom.SetNUMAParameters(TypedParams{DomNUMAMode: int32(DomNUMATuneModeInterleave)}, DomAffectLive); err == nil {
+		t.Error("an error was not returned when changing the NUMA mode of a running domain")
+	} else if _, ok := err.(*Error); !ok {
+		t.Errorf("the returned error should be a libvirt error; got=%T", err)
 	}
 }
 
-func TestDomainInfo(t *testing.T) {
+func TestDomainPerfEvents(t *testing.T) {
 	env := newTestEnvironment(t).withDomain()
 	defer env.cleanUp()
 
-	if err := env.dom.Create(DomCreateDefault); err != nil {
+	if err := env.dom.SetPerfEvents(TypedParams{DomPerfCPUCycles: true}, DomAffectConfig); err != nil {
 		t.Fatal(err)
 	}
 
-	state, err := env.dom.InfoState()
+	params, err := env.dom.PerfEvents(DomAffectConfig)
 	if err != nil {
-		t.Error(err)
+		t.Fatal(err)
 	}
-	otherState, _, err := env.dom.State()
-	if err != nil {
+
+	if enabled := params[DomPerfCPUCycles]; enabled != true {
+		t.Errorf("unexpected perf event %v; got=%v, want=%v", DomPerfCPUCycles, enabled, true)
+	}
+
+	if xml, err := env.dom.XML(DomXMLInactive); err != nil {
 		t.Error(err)
+	} else if !strings.Contains(xml, "cpu_cycles") {
+		t.Error("the enabled perf event was not found in the persistent domain XML")
 	}
-	if state != otherState {
-		t.Errorf("domain states obtained from different functions do not match; state1=%v, state2=%v", state, otherState)
+
+	// the domain would fail to start if the host didn't support the event
+	if err = env.dom.SetPerfEvents(TypedParams{DomPerfCPUCycles: false}, DomAffectConfig); err != nil {
+		t.Fatal(err)
 	}
 
-	maxMemory, err := env.dom.InfoMaxMemory()
-	if err != nil {
-		t.Error(err)
+	if err = env.dom.Create(DomCreateDefault); err != nil {
+		t.Fatal(err)
 	}
-	otherMaxMemory, err := env.dom.MaxMemory()
-	if err != nil {
-		t.Error(err)
+
+	if err = env.dom.SetPerfEvents(TypedParams{DomPerfCPUCycles: true}, DomAffectLive); err != nil {
+		if _, ok := err.(*Error); !ok {
+			t.Fatalf("the returned error should be a libvirt error; got=%T", err)
+		}
+
+		t.Skipf("the host does not support perf events: %v", err)
 	}
-	if maxMemory != otherMaxMemory {
-		t.Errorf("domain maximum memories obtained from different functions do not match; memory1=%v, memory2=%v", maxMemory, otherMaxMemory)
+
+	if params, err = env.dom.PerfEvents(DomAffectLive); err != nil {
+		t.Fatal(err)
 	}
 
-	vcpus, err := env.dom.InfoVCPUs()
+	if enabled := params[DomPerfCPUCycles]; enabled != true {
+		t.Errorf("unexpected perf event %v of the running domain; got=%v, want=%v", DomPerfCPUCycles, enabled, true)
+	}
+}
+
+func TestDomainBlkioParameters(t *testing.T) {
+	env := newTestEnvironment(t).withDomain()
+	defer env.cleanUp()
+
+	if err := env.dom.Create(DomCreateDefault); err != nil {
+		t.Fatal(err)
+	}
+
+	err := env.dom.SetBlkioParameters(TypedParams{DomBlkioWeight: uint32(500)}, DomAffectLive)
 	if err != nil {
-		t.Error(err)
+		if _, ok := err.(*Error); !ok {
+			t.Fatalf("the returned error should be a libvirt error; got=%T", err)
+		}
+
+		t.Skipf("the host does not support the blkio weight: %v", err)
 	}
-	otherVcpus, err := env.dom.VCPUs(DomVCPUsCurrent)
+
+	params, err := env.dom.BlkioParameters(DomAffectLive)
 	if err != nil {
-		t.Error(err)
+		t.Fatal(err)
 	}
-	if vcpus != uint16(otherVcpus) {
-		t.Errorf("numbers of domain VCPUs obtained from different functions do not match; VCPUs1=%v, VCPUs2=%v", vcpus, otherVcpus)
+
+	if weight := params[DomBlkioWeight]; weight != uint32(500) {
+		t.Errorf("unexpected blkio weight; got=%v, want=%v", weight, 500)
+	}
+}
+
+func TestDomainBlkioDeviceValues(t *testing.T) {
+	values := DomainBlkioDeviceValues{
+		"/dev/sdb": 200,
+		"/dev/sda": 100,
 	}
 
-	memory, err := env.dom.InfoMemory()
+	str := values.String()
+	if want := "/dev/sda,100,/dev/sdb,200"; str != want {
+		t.Errorf("unexpected blkio device values; got=%q, want=%q", str, want)
+	}
+
+	parsed, err := ParseDomainBlkioDeviceValues(str)
 	if err != nil {
-		t.Error(err)
+		t.Fatal(err)
 	}
-	if memory != env.domData.Memory {
-		t.Errorf("wrong memory value; got=%v, want=%v", memory, env.domData.Memory)
+
+	if !reflect.DeepEqual(parsed, values) {
+		t.Errorf("unexpected parsed blkio device values; got=%v, want=%v", parsed, values)
 	}
 
-	if _, err = env.dom.InfoCPUTime(); err != nil {
-		t.Error(err)
+	if parsed, err = ParseDomainBlkioDeviceValues(""); err != nil || len(parsed) != 0 {
+		t.Errorf("unexpected result when parsing empty blkio device values; got=%v (error %v)", parsed, err)
+	}
+
+	for _, str := range []string{"/dev/sda", "/dev/sda,foo"} {
+		if _, err = ParseDomainBlkioDeviceValues(str); err == nil {
+			t.Errorf("an error was not returned when parsing invalid blkio device values %q", str)
+		}
 	}
 }
 
-func TestDomainSaveRestore(t *testing.T) {
+func TestDomainBlockIOTune(t *testing.T) {
 	env := newTestEnvironment(t).withDomain()
 	defer env.cleanUp()
 
@@ -518,177 +2418,254 @@ func TestDomainSaveRestore(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if err := env.dom.Save("", "", DomSaveDefault); err == nil {
-		t.Error("an error was not returned when using an invalid file name")
+	if _, err := env.dom.BlockIOTune(utils.RandomString(), DomAffectLive); !errors.Is(err, &Error{Code: ErrInvalidArg}) {
+		t.Errorf("unexpected error when reading the I/O tuning of a disk which does not exist; got=%v, want code=%v", err, ErrInvalidArg)
 	}
 
-	file, ioerr := ioutil.TempFile("", fmt.Sprintf("%v-save-restore_", env.domData.Name))
-	if ioerr != nil {
-		t.Fatal(ioerr)
+	if err := env.dom.SetBlockIOTune(env.domData.DiskTarget, TypedParams{DomBlockIOTuneTotalIOPSSec: uint64(1000)}, DomAffectLive); err != nil {
+		t.Fatal(err)
 	}
-	defer os.Remove(file.Name())
 
-	if err := env.dom.Save(file.Name(), "", DomainSaveFlag(99)); err == nil {
-		t.Error("an error was not returned when using an invalid save flag")
+	params, err := env.dom.BlockIOTune(env.domData.DiskTarget, DomAffectLive)
+	if err != nil {
+		t.Fatal(err)
 	}
 
-	if err := env.dom.Save(file.Name(), "", DomSaveDefault); err != nil {
-		t.Error(err)
+	if iops := params[DomBlockIOTuneTotalIOPSSec]; iops != uint64(1000) {
+		t.Errorf("unexpected block I/O tuning parameter %v; got=%v, want=%v", DomBlockIOTuneTotalIOPSSec, iops, 1000)
 	}
+}
 
-	state, reason, err := env.dom.State()
+func TestDomainBlockStats(t *testing.T) {
+	env := newTestEnvironment(t).withDomain()
+	defer env.cleanUp()
+
+	if err := env.dom.Create(DomCreateDefault); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := env.dom.BlockStats(utils.RandomString()); !errors.Is(err, &Error{Code: ErrInvalidArg}) {
+		t.Errorf("unexpected error when reading the statistics of a disk which does not exist; got=%v, want code=%v", err, ErrInvalidArg)
+	}
+
+	stats, err := env.dom.BlockStats(env.domData.DiskTarget)
 	if err != nil {
-		t.Error(err)
+		t.Fatal(err)
 	}
-	if state != DomStateShutoff && DomainShutoffReason(reason) != DomShutoffReasonSaved {
-		t.Errorf("unexpected domain state; got=%v (reason %v), want=%v (reason %v)", state, reason, DomStateShutoff, DomShutoffReasonSaved)
+
+	if stats.RdBytes < 0 {
+		t.Errorf("unexpected number of read bytes; got=%v, want>=0", stats.RdBytes)
 	}
 
-	if err = env.conn.RestoreDomain(file.Name(), "", DomSaveDefault); err != nil {
+	if _, err := json.Marshal(stats); err != nil {
 		t.Error(err)
 	}
 
-	state, reason, err = env.dom.State()
-	if err != nil {
+	if _, err := env.dom.BlockStats(""); err != nil {
 		t.Error(err)
 	}
-	if state != DomStateRunning && DomainRunningReason(reason) != DomRunningReasonRestored {
-		t.Errorf("unexpected domain state; got=%v (reason %v), want=%v (reason %v)", state, reason, DomStateRunning, DomRunningReasonRestored)
-	}
 }
 
-func TestDomainDevices(t *testing.T) {
+func TestDomainInterfaceStats(t *testing.T) {
 	env := newTestEnvironment(t).withDomain()
 	defer env.cleanUp()
 
-	if err := env.dom.AttachDevice("", DomDeviceModifyCurrent); err == nil {
-		t.Error("an error was not returned when attaching an empty XML")
+	if err := env.dom.AttachDevice(testDeviceInterfaceXML, DomDeviceModifyConfig); err != nil {
+		t.Fatal(err)
 	}
 
-	if err := env.dom.DetachDevice("", DomDeviceModifyCurrent); err == nil {
-		t.Error("an error was not returned when detaching an empty XML")
+	if err := env.dom.Create(DomCreateDefault); err != nil {
+		t.Fatal(err)
 	}
 
-	if err := env.dom.UpdateDevice("", DomDeviceModifyCurrent); err == nil {
-		t.Error("an error was not returned when updating an empty XML")
+	if _, err := env.dom.InterfaceStats(utils.RandomString()); err == nil {
+		t.Error("an error was not returned when reading the statistics of an interface which does not exist")
 	}
 
-	if err := env.dom.AttachDevice(testDeviceLogXML, DomainDeviceModifyFlag(99)); err == nil {
-		t.Error("an error was not returned when attaching a device with an invalid modify flag")
+	names, err := env.dom.InterfaceNames()
+	if err != nil {
+		t.Fatal(err)
 	}
 
-	if err := env.dom.DetachDevice(testDeviceLogXML, DomainDeviceModifyFlag(99)); err == nil {
-		t.Error("an error was not returned when detaching a device with an invalid modify flag")
+	if len(names) == 0 {
+		t.Skip("the test domain interface does not have a host-side name")
 	}
 
-	if err := env.dom.UpdateDevice(testDeviceLogXML, DomainDeviceModifyFlag(99)); err == nil {
-		t.Error("an error was not returned when updating a device with an invalid modify flag")
+	stats, err := env.dom.InterfaceStats(names[0])
+	if err != nil {
+		t.Fatal(err)
 	}
 
-	if err := env.dom.AttachDevice(testDeviceLogXML, DomDeviceModifyCurrent); err != nil {
+	if stats.RxBytes != nil && *stats.RxBytes < 0 {
+		t.Errorf("unexpected number of received bytes; got=%v, want>=0", *stats.RxBytes)
+	}
+}
+
+func TestDomainInterfaceParameters(t *testing.T) {
+	env := newTestEnvironment(t).withDomain()
+	defer env.cleanUp()
+
+	if err := env.dom.AttachDevice(testDeviceInterfaceXML, DomDeviceModifyConfig); err != nil {
 		t.Fatal(err)
 	}
 
-	if err := env.dom.UpdateDevice(testDeviceTmpXML, DomDeviceModifyCurrent); err != nil {
-		t.Error(err)
+	if _, err := env.dom.InterfaceParameters(utils.RandomString(), DomAffectConfig); err == nil {
+		t.Error("an error was not returned when reading the parameters of an interface which does not exist")
 	}
 
-	if err := env.dom.DetachDevice(testDeviceTmpXML, DomDeviceModifyCurrent); err != nil {
-		t.Error(err)
+	if err := env.dom.SetInterfaceParameters(testDeviceInterfaceMAC, TypedParams{DomBandwidthOutAverage: uint32(1024)}, DomAffectConfig); err != nil {
+		t.Fatal(err)
+	}
+
+	params, err := env.dom.InterfaceParameters(testDeviceInterfaceMAC, DomAffectConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if average := params[DomBandwidthOutAverage]; average != uint32(1024) {
+		t.Errorf("unexpected interface parameter %v; got=%v, want=%v", DomBandwidthOutAverage, average, 1024)
 	}
 }
 
-func TestDomainManagedSave(t *testing.T) {
+func TestDomainTime(t *testing.T) {
 	env := newTestEnvironment(t).withDomain()
 	defer env.cleanUp()
 
+	if _, _, err := env.dom.Time(0); err == nil {
+		t.Error("an error was not returned when reading the time of an offline domain")
+	}
+
+	if _, err := env.dom.GuestTime(); err == nil {
+		t.Error("an error was not returned when reading the guest time of an offline domain")
+	}
+
 	if err := env.dom.Create(DomCreateDefault); err != nil {
 		t.Fatal(err)
 	}
-	// do not Destroy the domain - it will be already destroyed in the end
 
-	if err := env.dom.ManagedSave(DomainSaveFlag(99)); err == nil {
-		t.Error("an error was not returned when using an invalid save flag")
+	now := time.Now().Unix()
+
+	if err := env.dom.SetTime(now, 0, DomainSetTimeFlag(99)); err == nil {
+		t.Error("an error was not returned when using an invalid flag to set the guest time")
+	}
+
+	err := env.dom.SetTime(now, 0, DomSetTimeDefault)
+	if errors.Is(err, &Error{Code: ErrArgumentUnsupported}) || errors.Is(err, &Error{Code: ErrAgentUnresponsive}) {
+		if _, _, err = env.dom.Time(0); !errors.Is(err, &Error{Code: ErrArgumentUnsupported}) && !errors.Is(err, &Error{Code: ErrAgentUnresponsive}) {
+			t.Errorf("unexpected error when reading the guest time without a guest agent; got=%v", err)
+		}
+
+		if _, err = env.dom.GuestTime(); !errors.Is(err, &Error{Code: ErrArgumentUnsupported}) && !errors.Is(err, &Error{Code: ErrAgentUnresponsive}) {
+			t.Errorf("unexpected error when reading the guest time without a guest agent; got=%v", err)
+		}
+
+		t.Skip("the test domain does not have a guest agent")
 	}
 
-	hasManagedSave, err := env.dom.HasManagedSaveImage()
 	if err != nil {
-		t.Error(err)
+		t.Fatal(err)
 	}
-	if hasManagedSave {
-		t.Error("the test domain should not have a managed save image initially")
+
+	seconds, nanoseconds, err := env.dom.Time(0)
+	if err != nil {
+		t.Fatal(err)
 	}
 
-	if err := env.dom.ManagedSave(DomSaveDefault); err != nil {
-		t.Error(err)
+	if seconds < now {
+		t.Errorf("unexpected guest time after setting it; got=%v, want>=%v", seconds, now)
 	}
 
-	hasManagedSave, err = env.dom.HasManagedSaveImage()
+	if nanoseconds >= uint(time.Second) {
+		t.Errorf("unexpected guest time nanoseconds; got=%v, want<%v", nanoseconds, uint(time.Second))
+	}
+
+	guestTime, err := env.dom.GuestTime()
 	if err != nil {
-		t.Error(err)
+		t.Fatal(err)
 	}
-	if !hasManagedSave {
-		t.Error("the test domain should have a managed save image after creating a managed save image")
+
+	if guestTime.Unix() < seconds {
+		t.Errorf("unexpected guest time after reading it again; got=%v, want>=%v", guestTime, time.Unix(seconds, int64(nanoseconds)))
 	}
 
-	state, reason, err := env.dom.State()
-	if err != nil {
+	if err = env.dom.SetTime(0, 0, DomSetTimeSync); err != nil {
 		t.Error(err)
 	}
-	if expectedState := DomStateShutoff; state != expectedState && DomainShutoffReason(reason) != DomShutoffReasonSaved {
-		t.Errorf("unexpected domain state; got=%v (reason %v), want=%v (reason %v)", state, reason, expectedState, DomShutoffReasonSaved)
+}
+
+func TestDomainSetLifecycleAction(t *testing.T) {
+	env := newTestEnvironment(t).withDomain()
+	defer env.cleanUp()
+
+	if err := env.dom.SetLifecycleAction(DomLifecyclePoweroff, DomLifecycleActionCoredumpDestroy, DomAffectConfig); err == nil {
+		t.Error("an error was not returned when setting a coredump action on poweroff")
 	}
 
-	if err = env.dom.Create(DomCreateDefault); err != nil {
-		t.Error(err)
+	if err := env.dom.SetLifecycleAction(DomLifecycleCrash, DomLifecycleActionCoredumpDestroy, DomAffectConfig); err != nil {
+		t.Fatal(err)
 	}
 
-	hasManagedSave, err = env.dom.HasManagedSaveImage()
-	if err != nil {
+	if xml, err := env.dom.XML(DomXMLInactive); err != nil {
 		t.Error(err)
+	} else if !strings.Contains(xml, "<on_crash>coredump-destroy</on_crash>") {
+		t.Error("the new crash action was not found in the persistent domain XML")
 	}
-	if hasManagedSave {
-		t.Error("the test domain should not have a managed save image anymore after starting from an existing managed save image")
+}
+
+func TestDomainLaunchSecurity(t *testing.T) {
+	env := newTestEnvironment(t).withDomain()
+	defer env.cleanUp()
+
+	if err := env.dom.Create(DomCreateDefault); err != nil {
+		t.Fatal(err)
 	}
 
-	state, reason, err = env.dom.State()
+	// the test domain doesn't use SEV
+	params, err := env.dom.LaunchSecurityInfo(0)
 	if err != nil {
-		t.Error(err)
-	}
-	if expectedState := DomStateRunning; state != expectedState && DomainRunningReason(reason) != DomRunningReasonRestored {
-		t.Errorf("unexpected domain state; got=%v (reason %v), want=%v (reason %v)", state, reason, expectedState, DomRunningReasonRestored)
+		t.Fatal(err)
 	}
 
-	if err := env.dom.ManagedSave(DomSaveDefault); err != nil {
-		t.Error(err)
+	if _, ok := params[DomLaunchSecuritySEVMeasurement]; ok {
+		t.Errorf("a domain without launch security should not have a measurement; got=%v", params)
 	}
 
-	state, reason, err = env.dom.State()
-	if err != nil {
-		t.Error(err)
+	secret := TypedParams{
+		DomLaunchSecuritySEVSecret:       "c2VjcmV0",
+		DomLaunchSecuritySEVSecretHeader: "aGVhZGVy",
 	}
-	if expectedState := DomStateShutoff; state != expectedState && DomainShutoffReason(reason) != DomShutoffReasonSaved {
-		t.Errorf("unexpected domain state; got=%v (reason %v), want=%v (reason %v)", state, reason, expectedState, DomShutoffReasonSaved)
+
+	if err = env.dom.SetLaunchSecurityState(secret, 0); err == nil {
+		t.Error("an error was not returned when injecting a secret into a domain without launch security")
+	} else if _, ok := err.(*Error); !ok {
+		t.Errorf("the returned error should be a libvirt error; got=%T", err)
 	}
+}
 
-	hasManagedSave, err = env.dom.HasManagedSaveImage()
-	if err != nil {
-		t.Error(err)
+func TestDomainInjectNMI(t *testing.T) {
+	env := newTestEnvironment(t).withDomain()
+	defer env.cleanUp()
+
+	if err := env.dom.InjectNMI(0); err == nil {
+		t.Error("an error was not returned when injecting an NMI into an offline domain")
+	} else if !errors.Is(err, &Error{Code: ErrOperationInvalid}) {
+		t.Errorf("unexpected error when injecting an NMI into an offline domain; got=%v, want code=%v", err, ErrOperationInvalid)
 	}
-	if !hasManagedSave {
-		t.Error("the test domain should have a managed save image after creating a managed save image")
+
+	if err := env.dom.Create(DomCreateAutodestroy); err != nil {
+		t.Fatal(err)
 	}
 
-	if err := env.dom.ManagedSaveRemove(); err != nil {
-		t.Error(err)
+	if err := env.dom.InjectNMI(0); err != nil {
+		t.Fatal(err)
 	}
 
-	hasManagedSave, err = env.dom.HasManagedSaveImage()
+	state, _, err := env.dom.State()
 	if err != nil {
 		t.Error(err)
 	}
-	if hasManagedSave {
-		t.Error("the test domain should not have a managed save image anymore after removing it")
+	if state != DomStateRunning {
+		t.Errorf("unexpected domain state after injecting an NMI; got=%v, want=%v", state, DomStateRunning)
 	}
 }
 
@@ -703,6 +2680,19 @@ func TestDomainSendKey(t *testing.T) {
 	if err := env.dom.SendKey(DomKeycodeSetLinux, time.Duration(50)*time.Millisecond, testCtrlAltDel); err != nil {
 		t.Error(err)
 	}
+
+	if err := env.dom.SendKey(DomKeycodeSetLinux, 0, nil); err != ErrInvalidKeycodeCount {
+		t.Errorf("unexpected error when sending no keycodes; got=%v, want=%v", err, ErrInvalidKeycodeCount)
+	}
+
+	tooManyKeycodes := make([]uint32, DomSendKeyMaxKeys+1)
+	for i := range tooManyKeycodes {
+		tooManyKeycodes[i] = 30 // KEY_A
+	}
+
+	if err := env.dom.SendKey(DomKeycodeSetLinux, 0, tooManyKeycodes); err != ErrInvalidKeycodeCount {
+		t.Errorf("unexpected error when sending too many keycodes; got=%v, want=%v", err, ErrInvalidKeycodeCount)
+	}
 }
 
 func TestDomainSendProcessSignal(t *testing.T) {
@@ -857,7 +2847,7 @@ func BenchmarkDomainSaveRestore(b *testing.B) {
 			b.Error(err)
 		}
 
-		if err := env.conn.RestoreDomain(file.Name(), "", DomSaveDefault); err != nil {
+		if err := env.conn.RestoreDomain(file.Name()); err != nil {
 			b.Error(err)
 		}
 	}
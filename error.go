@@ -1,12 +1,29 @@
 package libvirt
 
-// #include <libvirt/virterror.h>
+/*
+#include <libvirt/virterror.h>
+
+void setErrorFuncHelper(void);
+*/
 import "C"
 import (
 	"fmt"
-	"log"
+	"sync"
 )
 
+// errorFunc holds the function which handles every error raised by libvirt.
+var errorFunc = struct {
+	sync.RWMutex
+	fn func(Error)
+}{}
+
+// libvirt prints every error to stderr by default; a handler which does
+// nothing is installed instead, and the errors are still available through
+// LastError.
+func init() {
+	C.setErrorFuncHelper()
+}
+
 // ErrorCode is the error code.
 type ErrorCode uint32
 
@@ -198,6 +215,24 @@ func (err *Error) Error() string {
 	return fmt.Sprintf("%s [error code = %d]", err.Message, err.Code)
 }
 
+// Is reports whether the error matches "target", so errors.Is can be used to
+// check libvirt errors. "target" matches if it's an *Error with the same code
+// and, if its domain isn't ErrDomNone, the same domain. For example:
+//
+//	errors.Is(err, &Error{Code: ErrNoDomain})
+func (err *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	if !ok || err == nil || t == nil {
+		return false
+	}
+
+	if t.Code != err.Code {
+		return false
+	}
+
+	return t.Domain == ErrDomNone || t.Domain == err.Domain
+}
+
 // NewError creates an error based on a native libvirt error. If the libvirt
 // error pointer is nil, returns nil.
 func NewError(virError C.virErrorPtr) *Error {
@@ -212,7 +247,7 @@ func NewError(virError C.virErrorPtr) *Error {
 		ErrorLevel(virError.level),
 		C.GoString(virError.str1),
 		C.GoString(virError.str2),
-		C.GoString(virError.str2),
+		C.GoString(virError.str3),
 		int32(virError.int1),
 		int32(virError.int2),
 	}
@@ -220,12 +255,43 @@ func NewError(virError C.virErrorPtr) *Error {
 
 // LastError provides a pointer to the last error caught at the library level.
 // The error object is kept in thread local storage, so separate threads can
-// safely access this concurrently.
+// safely access this concurrently. If there's no error, nil is returned.
+// As goroutines may move between threads, the calling goroutine must be locked
+// to its thread (see runtime.LockOSThread) since before the failed call;
+// otherwise, the error may belong to another call.
 func LastError() *Error {
-	cError := C.virGetLastError()
-	if cError == nil {
-		log.Println("LastError() did not return an error")
+	return NewError(C.virGetLastError())
+}
+
+// SetErrorFunc sets the function which is called whenever libvirt raises an
+// error, including the ones returned by this package. The function may be
+// called from any thread. By default, this package installs a handler which
+// ignores the errors; if "cb" is nil, libvirt's default handler, which prints
+// every error to stderr, is restored instead.
+func SetErrorFunc(cb func(Error)) {
+	errorFunc.Lock()
+	defer errorFunc.Unlock()
+
+	errorFunc.fn = cb
+
+	if cb == nil {
+		C.virSetErrorFunc(nil, nil)
+	} else {
+		C.setErrorFuncHelper()
 	}
+}
+
+//export errorFuncCallback
+func errorFuncCallback(cErr C.virErrorPtr) {
+	errorFunc.RLock()
+	fn := errorFunc.fn
+	errorFunc.RUnlock()
 
-	return NewError(cError)
+	if fn == nil {
+		return
+	}
+
+	if err := NewError(cErr); err != nil {
+		fn(*err)
+	}
 }
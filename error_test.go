@@ -1,7 +1,15 @@
 package libvirt
 
 import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
 	"testing"
+
+	"github.com/cd1/utils-golang"
 )
 
 func TestErrorNew(t *testing.T) {
@@ -9,3 +17,158 @@ func TestErrorNew(t *testing.T) {
 		t.Error("creating an error with a nil value should return nil")
 	}
 }
+
+func TestErrorNoDomain(t *testing.T) {
+	env := newTestEnvironment(t)
+	defer env.cleanUp()
+
+	_, err := env.conn.LookupDomainByName(utils.RandomString())
+	if err == nil {
+		t.Fatal("an error was not returned when looking up a non-existing domain")
+	}
+
+	var virErr *Error
+	if !errors.As(err, &virErr) {
+		t.Fatalf("the returned error is not a libvirt error; got=%T", err)
+	}
+
+	if virErr.Code != ErrNoDomain {
+		t.Errorf("unexpected error code; got=%v, want=%v", virErr.Code, ErrNoDomain)
+	}
+
+	if len(virErr.Message) == 0 {
+		t.Error("the libvirt error message should not be empty")
+	}
+
+	if !errors.Is(err, &Error{Code: ErrNoDomain}) {
+		t.Error("the returned error should match a libvirt error with the same code")
+	}
+
+	if !errors.Is(err, &Error{Code: ErrNoDomain, Domain: virErr.Domain}) {
+		t.Error("the returned error should match a libvirt error with the same code and domain")
+	}
+
+	if errors.Is(err, &Error{Code: ErrNoNetwork}) {
+		t.Error("the returned error should not match a libvirt error with a different code")
+	}
+}
+
+// captureStderr runs "fn" and returns what has been written to the standard
+// error output meanwhile, including by C code. "fn" must not stop the test
+// (e.g. with t.Fatal), otherwise the standard error output isn't restored.
+func captureStderr(t *testing.T, fn func()) string {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	stderr, err := syscall.Dup(syscall.Stderr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer syscall.Close(stderr)
+
+	if err = syscall.Dup3(int(w.Fd()), syscall.Stderr, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	fn()
+
+	if err = syscall.Dup3(stderr, syscall.Stderr, 0); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	output, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(output)
+}
+
+func TestErrorSetErrorFunc(t *testing.T) {
+	env := newTestEnvironment(t)
+	defer env.cleanUp()
+
+	errs := make(chan Error, 10)
+
+	SetErrorFunc(func(err Error) {
+		select {
+		case errs <- err:
+		default:
+		}
+	})
+	// keep libvirt from printing the errors of the following tests
+	defer SetErrorFunc(func(Error) {})
+
+	output := captureStderr(t, func() {
+		if _, err := env.conn.LookupDomainByName(utils.RandomString()); err == nil {
+			t.Error("an error was not returned when looking up a non-existing domain")
+		}
+	})
+
+	if output != "" {
+		t.Errorf("the error was printed to stderr while an error function was set; got=%q", output)
+	}
+
+	select {
+	case err := <-errs:
+		if err.Code != ErrNoDomain {
+			t.Errorf("unexpected error code; got=%v, want=%v", err.Code, ErrNoDomain)
+		}
+	default:
+		t.Fatal("the error function was not called")
+	}
+
+	SetErrorFunc(nil)
+
+	output = captureStderr(t, func() {
+		if _, err := env.conn.LookupDomainByName(utils.RandomString()); err == nil {
+			t.Error("an error was not returned when looking up a non-existing domain")
+		}
+	})
+
+	if output == "" {
+		t.Error("the error was not printed to stderr after restoring libvirt's default error function")
+	}
+
+	select {
+	case err := <-errs:
+		t.Errorf("the error function was called after being unset: %v", &err)
+	default:
+	}
+}
+
+func TestErrorConcurrentCalls(t *testing.T) {
+	env := newTestEnvironment(t)
+	defer env.cleanUp()
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for j := 0; j < 20; j++ {
+				name := utils.RandomString()
+
+				_, err := env.conn.LookupDomainByName(name)
+				if err == nil {
+					t.Error("an error was not returned when looking up a non-existing domain")
+					return
+				}
+
+				if !strings.Contains(err.Error(), name) {
+					t.Errorf("the error does not belong to the failed call; got=%v, want a message with %q", err, name)
+					return
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+}
@@ -0,0 +1,167 @@
+package libvirt
+
+/*
+#include <libvirt/libvirt.h>
+
+static void eventLoopWakeupCallback(int timer, void *opaque)
+{
+    // do nothing; firing this timeout just makes the loop iteration return
+}
+
+static int addEventLoopWakeupTimeout(void)
+{
+    return virEventAddTimeout(-1, eventLoopWakeupCallback, NULL, NULL);
+}
+*/
+import "C"
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+)
+
+// ErrNoEventLoop is returned when an operation requires an event loop
+// implementation, but EventRegisterDefaultImpl hasn't been called yet (or,
+// for a connection, it hasn't been called before the connection was opened).
+var ErrNoEventLoop = errors.New("no event loop implementation has been registered")
+
+// ErrEventLoopRunning is returned by "EventLoop.Run" when an event loop is
+// already running.
+var ErrEventLoopRunning = errors.New("the event loop is already running")
+
+// eventLoopState holds the package-level state of the libvirt event loop.
+// libvirt supports only one event loop implementation per process, and only
+// one goroutine should run it.
+var eventLoopState = struct {
+	sync.Mutex
+	registered bool
+	running    bool
+}{}
+
+// eventLoopRegistered tells whether the default event loop implementation has
+// been registered.
+func eventLoopRegistered() bool {
+	eventLoopState.Lock()
+	defer eventLoopState.Unlock()
+
+	return eventLoopState.registered
+}
+
+// EventRegisterDefaultImpl registers the default event loop implementation
+// provided by libvirt. It must be called before opening the connections which
+// use events, keepalive messages or close callbacks. Calling it more than once
+// has no further effect.
+func EventRegisterDefaultImpl() error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	eventLoopState.Lock()
+	defer eventLoopState.Unlock()
+
+	if eventLoopState.registered {
+		return nil
+	}
+
+	cRet := C.virEventRegisterDefaultImpl()
+	ret := int32(cRet)
+
+	if ret == -1 {
+		return LastError()
+	}
+
+	eventLoopState.registered = true
+
+	return nil
+}
+
+// EventRunDefaultImpl runs one iteration of the default event loop. It blocks
+// until at least one event has been dispatched. EventRegisterDefaultImpl must
+// be called before this function. Usually, EventLoop should be used instead.
+func EventRunDefaultImpl() error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if !eventLoopRegistered() {
+		return ErrNoEventLoop
+	}
+
+	cRet := C.virEventRunDefaultImpl()
+	ret := int32(cRet)
+
+	if ret == -1 {
+		return LastError()
+	}
+
+	return nil
+}
+
+// EventLoop runs the default event loop in the background. The zero value is
+// ready to be used.
+type EventLoop struct {
+	done chan struct{}
+}
+
+// Run starts running the default event loop in a new goroutine, until "ctx"
+// is cancelled. EventRegisterDefaultImpl must be called before this method;
+// otherwise, ErrNoEventLoop is returned. Only one event loop can run at a
+// time; if there's another one running, ErrEventLoopRunning is returned.
+func (loop *EventLoop) Run(ctx context.Context) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	eventLoopState.Lock()
+	defer eventLoopState.Unlock()
+
+	if !eventLoopState.registered {
+		return ErrNoEventLoop
+	}
+
+	if eventLoopState.running {
+		return ErrEventLoopRunning
+	}
+
+	// the timeout starts disabled; it's only fired to wake the loop up when
+	// the context is cancelled
+	cTimer := C.addEventLoopWakeupTimeout()
+	if int32(cTimer) == -1 {
+		return LastError()
+	}
+
+	eventLoopState.running = true
+	loop.done = make(chan struct{})
+
+	go func() {
+		<-ctx.Done()
+		C.virEventUpdateTimeout(cTimer, 0)
+	}()
+
+	go func() {
+		// libvirt wakes the loop up by signaling the thread which runs it, so
+		// all iterations must run on the same thread
+		runtime.LockOSThread()
+		defer runtime.UnlockOSThread()
+
+		for ctx.Err() == nil {
+			C.virEventRunDefaultImpl()
+		}
+
+		C.virEventRemoveTimeout(cTimer)
+
+		eventLoopState.Lock()
+		eventLoopState.running = false
+		eventLoopState.Unlock()
+
+		close(loop.done)
+	}()
+
+	return nil
+}
+
+// Wait blocks until the event loop started by Run stops. If the loop hasn't
+// been started, it returns immediately.
+func (loop *EventLoop) Wait() {
+	if loop.done != nil {
+		<-loop.done
+	}
+}
@@ -0,0 +1,74 @@
+package libvirt
+
+import (
+	"context"
+	"testing"
+)
+
+func TestEventLoopRun(t *testing.T) {
+	if err := EventRegisterDefaultImpl(); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var loop EventLoop
+	if err := loop.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	var another EventLoop
+	if err := another.Run(ctx); err != ErrEventLoopRunning {
+		t.Errorf("unexpected error when running a second event loop; got=%v, want=%v", err, ErrEventLoopRunning)
+	}
+
+	cancel()
+	loop.Wait()
+
+	// the loop can run again after it has been stopped
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := loop.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	cancel()
+	loop.Wait()
+}
+
+func TestEventLoopKeepAlive(t *testing.T) {
+	// the event loop may have been registered by a previous test, so pretend
+	// it hasn't while opening the connection
+	eventLoopState.Lock()
+	registered := eventLoopState.registered
+	eventLoopState.registered = false
+	eventLoopState.Unlock()
+
+	conn, err := Open(testConnectionURI, ReadWrite, testLogOutput)
+
+	eventLoopState.Lock()
+	eventLoopState.registered = registered
+	eventLoopState.Unlock()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = conn.SetKeepAlive(5, 3); err != ErrNoEventLoop {
+		t.Errorf("unexpected error when setting keepalive before registering the event loop; got=%v, want=%v", err, ErrNoEventLoop)
+	}
+
+	conn.Close()
+
+	stopEventLoop := testRunEventLoop(t)
+	defer stopEventLoop()
+
+	env := newTestEnvironment(t)
+	defer env.cleanUp()
+
+	if err := env.conn.SetKeepAlive(5, 3); err != nil {
+		t.Error(err)
+	}
+}
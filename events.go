@@ -0,0 +1,899 @@
+package libvirt
+
+/*
+#include <libvirt/libvirt.h>
+
+int registerCloseCallbackHelper(virConnectPtr conn, long callbackID);
+int unregisterCloseCallbackHelper(virConnectPtr conn);
+int domainEventRegisterAnyHelper(virConnectPtr conn, virDomainPtr dom, int eventID, long callbackID);
+int networkEventRegisterAnyHelper(virConnectPtr conn, virNetworkPtr net, int eventID, long callbackID);
+int nodeDeviceEventRegisterAnyHelper(virConnectPtr conn, virNodeDevicePtr dev, int eventID, long callbackID);
+int secretEventRegisterAnyHelper(virConnectPtr conn, virSecretPtr secret, int eventID, long callbackID);
+*/
+import "C"
+import (
+	"log"
+	"runtime"
+)
+
+// ConnectionCloseReason describes why a connection has been closed.
+type ConnectionCloseReason uint32
+
+// Possible values for ConnectionCloseReason.
+const (
+	ConnCloseReasonError     ConnectionCloseReason = C.VIR_CONNECT_CLOSE_REASON_ERROR
+	ConnCloseReasonEOF       ConnectionCloseReason = C.VIR_CONNECT_CLOSE_REASON_EOF
+	ConnCloseReasonKeepAlive ConnectionCloseReason = C.VIR_CONNECT_CLOSE_REASON_KEEPALIVE
+	ConnCloseReasonClient    ConnectionCloseReason = C.VIR_CONNECT_CLOSE_REASON_CLIENT
+)
+
+// ConnectionCloseCallback is called when a connection is closed.
+type ConnectionCloseCallback func(reason ConnectionCloseReason)
+
+// EventCallbackID identifies an event callback registered on a connection. It
+// is used to deregister the callback.
+type EventCallbackID int32
+
+// DomainEventType describes the type of a domain lifecycle event.
+type DomainEventType int32
+
+// Possible values for DomainEventType.
+const (
+	DomEventDefined     DomainEventType = C.VIR_DOMAIN_EVENT_DEFINED
+	DomEventUndefined   DomainEventType = C.VIR_DOMAIN_EVENT_UNDEFINED
+	DomEventStarted     DomainEventType = C.VIR_DOMAIN_EVENT_STARTED
+	DomEventSuspended   DomainEventType = C.VIR_DOMAIN_EVENT_SUSPENDED
+	DomEventResumed     DomainEventType = C.VIR_DOMAIN_EVENT_RESUMED
+	DomEventStopped     DomainEventType = C.VIR_DOMAIN_EVENT_STOPPED
+	DomEventShutdown    DomainEventType = C.VIR_DOMAIN_EVENT_SHUTDOWN
+	DomEventPMSuspended DomainEventType = C.VIR_DOMAIN_EVENT_PMSUSPENDED
+	DomEventCrashed     DomainEventType = C.VIR_DOMAIN_EVENT_CRASHED
+)
+
+// DomainEventDefinedDetail describes the details of a DomEventDefined event.
+type DomainEventDefinedDetail int32
+
+// Possible values for DomainEventDefinedDetail.
+const (
+	DomEventDefinedAdded        DomainEventDefinedDetail = C.VIR_DOMAIN_EVENT_DEFINED_ADDED
+	DomEventDefinedUpdated      DomainEventDefinedDetail = C.VIR_DOMAIN_EVENT_DEFINED_UPDATED
+	DomEventDefinedRenamed      DomainEventDefinedDetail = C.VIR_DOMAIN_EVENT_DEFINED_RENAMED
+	DomEventDefinedFromSnapshot DomainEventDefinedDetail = C.VIR_DOMAIN_EVENT_DEFINED_FROM_SNAPSHOT
+)
+
+// DomainEventUndefinedDetail describes the details of a DomEventUndefined
+// event.
+type DomainEventUndefinedDetail int32
+
+// Possible values for DomainEventUndefinedDetail.
+const (
+	DomEventUndefinedRemoved DomainEventUndefinedDetail = C.VIR_DOMAIN_EVENT_UNDEFINED_REMOVED
+	DomEventUndefinedRenamed DomainEventUndefinedDetail = C.VIR_DOMAIN_EVENT_UNDEFINED_RENAMED
+)
+
+// DomainEventStartedDetail describes the details of a DomEventStarted event.
+type DomainEventStartedDetail int32
+
+// Possible values for DomainEventStartedDetail.
+const (
+	DomEventStartedBooted       DomainEventStartedDetail = C.VIR_DOMAIN_EVENT_STARTED_BOOTED
+	DomEventStartedMigrated     DomainEventStartedDetail = C.VIR_DOMAIN_EVENT_STARTED_MIGRATED
+	DomEventStartedRestored     DomainEventStartedDetail = C.VIR_DOMAIN_EVENT_STARTED_RESTORED
+	DomEventStartedFromSnapshot DomainEventStartedDetail = C.VIR_DOMAIN_EVENT_STARTED_FROM_SNAPSHOT
+	DomEventStartedWakeup       DomainEventStartedDetail = C.VIR_DOMAIN_EVENT_STARTED_WAKEUP
+)
+
+// DomainEventSuspendedDetail describes the details of a DomEventSuspended
+// event.
+type DomainEventSuspendedDetail int32
+
+// Possible values for DomainEventSuspendedDetail.
+const (
+	DomEventSuspendedPaused         DomainEventSuspendedDetail = C.VIR_DOMAIN_EVENT_SUSPENDED_PAUSED
+	DomEventSuspendedMigrated       DomainEventSuspendedDetail = C.VIR_DOMAIN_EVENT_SUSPENDED_MIGRATED
+	DomEventSuspendedIOError        DomainEventSuspendedDetail = C.VIR_DOMAIN_EVENT_SUSPENDED_IOERROR
+	DomEventSuspendedWatchdog       DomainEventSuspendedDetail = C.VIR_DOMAIN_EVENT_SUSPENDED_WATCHDOG
+	DomEventSuspendedRestored       DomainEventSuspendedDetail = C.VIR_DOMAIN_EVENT_SUSPENDED_RESTORED
+	DomEventSuspendedFromSnapshot   DomainEventSuspendedDetail = C.VIR_DOMAIN_EVENT_SUSPENDED_FROM_SNAPSHOT
+	DomEventSuspendedAPIError       DomainEventSuspendedDetail = C.VIR_DOMAIN_EVENT_SUSPENDED_API_ERROR
+	DomEventSuspendedPostCopy       DomainEventSuspendedDetail = C.VIR_DOMAIN_EVENT_SUSPENDED_POSTCOPY
+	DomEventSuspendedPostCopyFailed DomainEventSuspendedDetail = C.VIR_DOMAIN_EVENT_SUSPENDED_POSTCOPY_FAILED
+)
+
+// DomainEventResumedDetail describes the details of a DomEventResumed event.
+type DomainEventResumedDetail int32
+
+// Possible values for DomainEventResumedDetail.
+const (
+	DomEventResumedUnpaused     DomainEventResumedDetail = C.VIR_DOMAIN_EVENT_RESUMED_UNPAUSED
+	DomEventResumedMigrated     DomainEventResumedDetail = C.VIR_DOMAIN_EVENT_RESUMED_MIGRATED
+	DomEventResumedFromSnapshot DomainEventResumedDetail = C.VIR_DOMAIN_EVENT_RESUMED_FROM_SNAPSHOT
+	DomEventResumedPostCopy     DomainEventResumedDetail = C.VIR_DOMAIN_EVENT_RESUMED_POSTCOPY
+)
+
+// DomainEventStoppedDetail describes the details of a DomEventStopped event.
+type DomainEventStoppedDetail int32
+
+// Possible values for DomainEventStoppedDetail.
+const (
+	DomEventStoppedShutdown     DomainEventStoppedDetail = C.VIR_DOMAIN_EVENT_STOPPED_SHUTDOWN
+	DomEventStoppedDestroyed    DomainEventStoppedDetail = C.VIR_DOMAIN_EVENT_STOPPED_DESTROYED
+	DomEventStoppedCrashed      DomainEventStoppedDetail = C.VIR_DOMAIN_EVENT_STOPPED_CRASHED
+	DomEventStoppedMigrated     DomainEventStoppedDetail = C.VIR_DOMAIN_EVENT_STOPPED_MIGRATED
+	DomEventStoppedSaved        DomainEventStoppedDetail = C.VIR_DOMAIN_EVENT_STOPPED_SAVED
+	DomEventStoppedFailed       DomainEventStoppedDetail = C.VIR_DOMAIN_EVENT_STOPPED_FAILED
+	DomEventStoppedFromSnapshot DomainEventStoppedDetail = C.VIR_DOMAIN_EVENT_STOPPED_FROM_SNAPSHOT
+)
+
+// DomainEventShutdownDetail describes the details of a DomEventShutdown
+// event.
+type DomainEventShutdownDetail int32
+
+// Possible values for DomainEventShutdownDetail.
+const (
+	DomEventShutdownFinished DomainEventShutdownDetail = C.VIR_DOMAIN_EVENT_SHUTDOWN_FINISHED
+	DomEventShutdownGuest    DomainEventShutdownDetail = C.VIR_DOMAIN_EVENT_SHUTDOWN_GUEST
+	DomEventShutdownHost     DomainEventShutdownDetail = C.VIR_DOMAIN_EVENT_SHUTDOWN_HOST
+)
+
+// DomainEventPMSuspendedDetail describes the details of a DomEventPMSuspended
+// event.
+type DomainEventPMSuspendedDetail int32
+
+// Possible values for DomainEventPMSuspendedDetail.
+const (
+	DomEventPMSuspendedMemory DomainEventPMSuspendedDetail = C.VIR_DOMAIN_EVENT_PMSUSPENDED_MEMORY
+	DomEventPMSuspendedDisk   DomainEventPMSuspendedDetail = C.VIR_DOMAIN_EVENT_PMSUSPENDED_DISK
+)
+
+// DomainEventCrashedDetail describes the details of a DomEventCrashed event.
+type DomainEventCrashedDetail int32
+
+// Possible values for DomainEventCrashedDetail.
+const (
+	DomEventCrashedPanicked DomainEventCrashedDetail = C.VIR_DOMAIN_EVENT_CRASHED_PANICKED
+)
+
+// DomainLifecycleCallback is called when a domain lifecycle event happens.
+// "detail" should be converted to the detail type of "event" (e.g.
+// DomainEventStartedDetail for DomEventStarted). "dom" is only valid during
+// the callback; Domain.Ref must be used to keep it afterwards.
+type DomainLifecycleCallback func(dom Domain, event DomainEventType, detail int)
+
+// WatchdogAction describes the action taken when a domain's watchdog fires.
+type WatchdogAction int32
+
+// Possible values for WatchdogAction.
+const (
+	WatchdogActionNone      WatchdogAction = C.VIR_DOMAIN_EVENT_WATCHDOG_NONE
+	WatchdogActionPause     WatchdogAction = C.VIR_DOMAIN_EVENT_WATCHDOG_PAUSE
+	WatchdogActionReset     WatchdogAction = C.VIR_DOMAIN_EVENT_WATCHDOG_RESET
+	WatchdogActionPowerOff  WatchdogAction = C.VIR_DOMAIN_EVENT_WATCHDOG_POWEROFF
+	WatchdogActionShutdown  WatchdogAction = C.VIR_DOMAIN_EVENT_WATCHDOG_SHUTDOWN
+	WatchdogActionDebug     WatchdogAction = C.VIR_DOMAIN_EVENT_WATCHDOG_DEBUG
+	WatchdogActionInjectNMI WatchdogAction = C.VIR_DOMAIN_EVENT_WATCHDOG_INJECTNMI
+)
+
+// IOErrorAction describes the action taken when a domain's disk has an I/O
+// error.
+type IOErrorAction int32
+
+// Possible values for IOErrorAction.
+const (
+	IOErrorActionNone   IOErrorAction = C.VIR_DOMAIN_EVENT_IO_ERROR_NONE
+	IOErrorActionPause  IOErrorAction = C.VIR_DOMAIN_EVENT_IO_ERROR_PAUSE
+	IOErrorActionReport IOErrorAction = C.VIR_DOMAIN_EVENT_IO_ERROR_REPORT
+)
+
+// DomainRebootCallback is called when a domain is rebooted. "dom" is only
+// valid during the callback; Domain.Ref must be used to keep it afterwards.
+type DomainRebootCallback func(dom Domain)
+
+// DomainRTCChangeCallback is called when a domain's real time clock changes.
+// "utcOffset" is the new offset from UTC, in seconds. "dom" is only valid
+// during the callback; Domain.Ref must be used to keep it afterwards.
+type DomainRTCChangeCallback func(dom Domain, utcOffset int64)
+
+// DomainWatchdogCallback is called when a domain's watchdog fires. "dom" is
+// only valid during the callback; Domain.Ref must be used to keep it
+// afterwards.
+type DomainWatchdogCallback func(dom Domain, action WatchdogAction)
+
+// DomainIOErrorCallback is called when a domain's disk has an I/O error.
+// "srcPath" is the host path of the disk, "devAlias" is the device alias and
+// "reason" describes the error (e.g. "enospc"). "dom" is only valid during the
+// callback; Domain.Ref must be used to keep it afterwards.
+type DomainIOErrorCallback func(dom Domain, srcPath string, devAlias string, action IOErrorAction, reason string)
+
+// DomainDeviceRemovedCallback is called when a device has been removed from a
+// domain. "devAlias" is the alias of the removed device. "dom" is only valid
+// during the callback; Domain.Ref must be used to keep it afterwards.
+type DomainDeviceRemovedCallback func(dom Domain, devAlias string)
+
+// NetworkEventType describes the type of a network lifecycle event.
+type NetworkEventType int32
+
+// Possible values for NetworkEventType.
+const (
+	NetEventDefined   NetworkEventType = C.VIR_NETWORK_EVENT_DEFINED
+	NetEventUndefined NetworkEventType = C.VIR_NETWORK_EVENT_UNDEFINED
+	NetEventStarted   NetworkEventType = C.VIR_NETWORK_EVENT_STARTED
+	NetEventStopped   NetworkEventType = C.VIR_NETWORK_EVENT_STOPPED
+)
+
+// NetworkLifecycleCallback is called when a network lifecycle event happens.
+// "detail" is reserved for future use. "net" is only valid during the
+// callback.
+type NetworkLifecycleCallback func(net Network, event NetworkEventType, detail int)
+
+// NodeDeviceEventType describes the type of a node device lifecycle event.
+type NodeDeviceEventType int32
+
+// Possible values for NodeDeviceEventType.
+const (
+	NodeDevEventCreated NodeDeviceEventType = C.VIR_NODE_DEVICE_EVENT_CREATED
+	NodeDevEventDeleted NodeDeviceEventType = C.VIR_NODE_DEVICE_EVENT_DELETED
+)
+
+// NodeDeviceLifecycleCallback is called when a node device is created or
+// deleted (e.g. a USB device is plugged). "detail" is reserved for future
+// use. "dev" is only valid during the callback; NodeDevice.Ref must be used
+// to keep it afterwards.
+type NodeDeviceLifecycleCallback func(dev NodeDevice, event NodeDeviceEventType, detail int)
+
+// NodeDeviceUpdateCallback is called when a node device is updated. "dev" is
+// only valid during the callback; NodeDevice.Ref must be used to keep it
+// afterwards.
+type NodeDeviceUpdateCallback func(dev NodeDevice)
+
+// SecretEventType describes the type of a secret lifecycle event.
+type SecretEventType int32
+
+// Possible values for SecretEventType.
+const (
+	SecEventDefined   SecretEventType = C.VIR_SECRET_EVENT_DEFINED
+	SecEventUndefined SecretEventType = C.VIR_SECRET_EVENT_UNDEFINED
+)
+
+// SecretLifecycleCallback is called when a secret is defined or undefined.
+// "detail" is reserved for future use. "sec" is only valid during the
+// callback; Secret.Ref must be used to keep it afterwards.
+type SecretLifecycleCallback func(sec Secret, event SecretEventType, detail int)
+
+// SecretValueChangedCallback is called when the value of a secret changes.
+// "sec" is only valid during the callback; Secret.Ref must be used to keep it
+// afterwards.
+type SecretValueChangedCallback func(sec Secret)
+
+// eventCallback holds a Go event callback, along with the logger of the
+// connection which registered it, so the objects handed to the callback can
+// log to the same place.
+type eventCallback struct {
+	log *log.Logger
+	fn  interface{}
+}
+
+// RegisterCloseCallback registers a callback to be invoked when the
+// connection is closed, e.g. because the daemon has been restarted, a
+// keepalive timeout has expired or the connection has been closed by the
+// client. Only one callback can be registered per connection.
+// The callback is dispatched from the libvirt event loop, so an event loop
+// implementation must be registered and running. It's released by libvirt
+// once it has been unregistered or the connection has been closed.
+func (conn Connection) RegisterCloseCallback(cb ConnectionCloseCallback) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := conn.valid(); err != nil {
+		return err
+	}
+
+	conn.log.Println("registering connection close callback...")
+	id := registerCallback(cb)
+	cRet := C.registerCloseCallbackHelper(conn.virConnect, C.long(id))
+	ret := int32(cRet)
+
+	if ret == -1 {
+		unregisterCallback(id)
+		err := LastError()
+		conn.log.Printf("an error occurred: %v\n", err)
+		return err
+	}
+
+	conn.log.Println("close callback registered")
+
+	return nil
+}
+
+// UnregisterCloseCallback unregisters the callback previously registered with
+// RegisterCloseCallback.
+func (conn Connection) UnregisterCloseCallback() error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := conn.valid(); err != nil {
+		return err
+	}
+
+	conn.log.Println("unregistering connection close callback...")
+	cRet := C.unregisterCloseCallbackHelper(conn.virConnect)
+	ret := int32(cRet)
+
+	if ret == -1 {
+		err := LastError()
+		conn.log.Printf("an error occurred: %v\n", err)
+		return err
+	}
+
+	conn.log.Println("close callback unregistered")
+
+	return nil
+}
+
+// registerDomainEvent registers "cb" for the domain event "eventID"; "name"
+// describes the event in the log.
+func (conn Connection) registerDomainEvent(dom *Domain, eventID C.int, name string, cb interface{}) (EventCallbackID, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := conn.valid(); err != nil {
+		return 0, err
+	}
+
+	var cDom C.virDomainPtr
+	if dom != nil {
+		cDom = dom.virDomain
+	}
+
+	conn.log.Printf("registering domain %v event callback...\n", name)
+	id := registerCallback(eventCallback{
+		log: conn.log,
+		fn:  cb,
+	})
+
+	cRet := C.domainEventRegisterAnyHelper(conn.virConnect, cDom, eventID, C.long(id))
+	ret := int32(cRet)
+
+	if ret == -1 {
+		unregisterCallback(id)
+		err := LastError()
+		conn.log.Printf("an error occurred: %v\n", err)
+		return 0, err
+	}
+
+	callbackID := EventCallbackID(ret)
+
+	conn.log.Printf("event callback registered (ID = %v)\n", callbackID)
+
+	return callbackID, nil
+}
+
+// RegisterDomainLifecycleEvent registers a callback to be invoked when the
+// lifecycle of "dom" changes (e.g. it is started or stopped). If "dom" is
+// nil, the callback is invoked for all domains. The returned ID can be used
+// to deregister the callback with DeregisterDomainEvent.
+// The callback is dispatched from the libvirt event loop, so an event loop
+// implementation must be registered and running.
+func (conn Connection) RegisterDomainLifecycleEvent(dom *Domain, cb DomainLifecycleCallback) (EventCallbackID, error) {
+	return conn.registerDomainEvent(dom, C.VIR_DOMAIN_EVENT_ID_LIFECYCLE, "lifecycle", cb)
+}
+
+// RegisterDomainRebootEvent registers a callback to be invoked when "dom" is
+// rebooted. If "dom" is nil, the callback is invoked for all domains. The
+// returned ID can be used to deregister the callback with
+// DeregisterDomainEvent.
+func (conn Connection) RegisterDomainRebootEvent(dom *Domain, cb DomainRebootCallback) (EventCallbackID, error) {
+	return conn.registerDomainEvent(dom, C.VIR_DOMAIN_EVENT_ID_REBOOT, "reboot", cb)
+}
+
+// RegisterDomainRTCChangeEvent registers a callback to be invoked when the
+// real time clock of "dom" changes. If "dom" is nil, the callback is invoked
+// for all domains. The returned ID can be used to deregister the callback
+// with DeregisterDomainEvent.
+func (conn Connection) RegisterDomainRTCChangeEvent(dom *Domain, cb DomainRTCChangeCallback) (EventCallbackID, error) {
+	return conn.registerDomainEvent(dom, C.VIR_DOMAIN_EVENT_ID_RTC_CHANGE, "RTC change", cb)
+}
+
+// RegisterDomainWatchdogEvent registers a callback to be invoked when the
+// watchdog of "dom" fires. If "dom" is nil, the callback is invoked for all
+// domains. The returned ID can be used to deregister the callback with
+// DeregisterDomainEvent.
+func (conn Connection) RegisterDomainWatchdogEvent(dom *Domain, cb DomainWatchdogCallback) (EventCallbackID, error) {
+	return conn.registerDomainEvent(dom, C.VIR_DOMAIN_EVENT_ID_WATCHDOG, "watchdog", cb)
+}
+
+// RegisterDomainIOErrorEvent registers a callback to be invoked when a disk of
+// "dom" has an I/O error. If "dom" is nil, the callback is invoked for all
+// domains. The returned ID can be used to deregister the callback with
+// DeregisterDomainEvent.
+func (conn Connection) RegisterDomainIOErrorEvent(dom *Domain, cb DomainIOErrorCallback) (EventCallbackID, error) {
+	return conn.registerDomainEvent(dom, C.VIR_DOMAIN_EVENT_ID_IO_ERROR_REASON, "I/O error", cb)
+}
+
+// RegisterDomainDeviceRemovedEvent registers a callback to be invoked when a
+// device has been removed from "dom", which is how the completion of
+// Domain.DetachDevice and Domain.DetachDeviceAlias is signalled. If "dom" is
+// nil, the callback is invoked for all domains. The returned ID can be used to
+// deregister the callback with DeregisterDomainEvent.
+func (conn Connection) RegisterDomainDeviceRemovedEvent(dom *Domain, cb DomainDeviceRemovedCallback) (EventCallbackID, error) {
+	return conn.registerDomainEvent(dom, C.VIR_DOMAIN_EVENT_ID_DEVICE_REMOVED, "device removed", cb)
+}
+
+// DeregisterDomainEvent removes a domain event callback previously registered
+// with one of the RegisterDomain*Event functions.
+func (conn Connection) DeregisterDomainEvent(id EventCallbackID) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := conn.valid(); err != nil {
+		return err
+	}
+
+	conn.log.Printf("deregistering domain event callback (ID = %v)...\n", id)
+	cRet := C.virConnectDomainEventDeregisterAny(conn.virConnect, C.int(id))
+	ret := int32(cRet)
+
+	if ret == -1 {
+		err := LastError()
+		conn.log.Printf("an error occurred: %v\n", err)
+		return err
+	}
+
+	conn.log.Println("event callback deregistered")
+
+	return nil
+}
+
+// RegisterNetworkLifecycleEvent registers a callback to be invoked when the
+// lifecycle of "net" changes (e.g. it is defined or started). If "net" is
+// nil, the callback is invoked for all networks. The returned ID can be used
+// to deregister the callback with DeregisterNetworkEvent.
+// The callback is dispatched from the libvirt event loop, so an event loop
+// implementation must be registered and running.
+func (conn Connection) RegisterNetworkLifecycleEvent(net *Network, cb NetworkLifecycleCallback) (EventCallbackID, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := conn.valid(); err != nil {
+		return 0, err
+	}
+
+	var cNet C.virNetworkPtr
+	if net != nil {
+		cNet = net.virNetwork
+	}
+
+	conn.log.Println("registering network lifecycle event callback...")
+	id := registerCallback(eventCallback{
+		log: conn.log,
+		fn:  cb,
+	})
+
+	cRet := C.networkEventRegisterAnyHelper(conn.virConnect, cNet, C.VIR_NETWORK_EVENT_ID_LIFECYCLE, C.long(id))
+	ret := int32(cRet)
+
+	if ret == -1 {
+		unregisterCallback(id)
+		err := LastError()
+		conn.log.Printf("an error occurred: %v\n", err)
+		return 0, err
+	}
+
+	callbackID := EventCallbackID(ret)
+
+	conn.log.Printf("event callback registered (ID = %v)\n", callbackID)
+
+	return callbackID, nil
+}
+
+// DeregisterNetworkEvent removes a network event callback previously
+// registered with RegisterNetworkLifecycleEvent. The Go callback is released
+// once libvirt is done with it.
+func (conn Connection) DeregisterNetworkEvent(id EventCallbackID) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := conn.valid(); err != nil {
+		return err
+	}
+
+	conn.log.Printf("deregistering network event callback (ID = %v)...\n", id)
+	cRet := C.virConnectNetworkEventDeregisterAny(conn.virConnect, C.int(id))
+	ret := int32(cRet)
+
+	if ret == -1 {
+		err := LastError()
+		conn.log.Printf("an error occurred: %v\n", err)
+		return err
+	}
+
+	conn.log.Println("event callback deregistered")
+
+	return nil
+}
+
+// registerNodeDeviceEvent registers "cb" for the node device event "eventID"
+// of all node devices; "name" describes the event in the log.
+func (conn Connection) registerNodeDeviceEvent(eventID C.int, name string, cb interface{}) (EventCallbackID, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := conn.valid(); err != nil {
+		return 0, err
+	}
+
+	conn.log.Printf("registering node device %v event callback...\n", name)
+	id := registerCallback(eventCallback{
+		log: conn.log,
+		fn:  cb,
+	})
+
+	cRet := C.nodeDeviceEventRegisterAnyHelper(conn.virConnect, nil, eventID, C.long(id))
+	ret := int32(cRet)
+
+	if ret == -1 {
+		unregisterCallback(id)
+		err := LastError()
+		conn.log.Printf("an error occurred: %v\n", err)
+		return 0, err
+	}
+
+	callbackID := EventCallbackID(ret)
+
+	conn.log.Printf("event callback registered (ID = %v)\n", callbackID)
+
+	return callbackID, nil
+}
+
+// RegisterNodeDeviceEvent registers a callback to be invoked when a node
+// device is created or deleted (e.g. a USB device is plugged or a SR-IOV VF is
+// added). The returned ID can be used to deregister the callback with
+// DeregisterNodeDeviceEvent.
+// The callback is dispatched from the libvirt event loop, so an event loop
+// implementation must be registered and running.
+func (conn Connection) RegisterNodeDeviceEvent(cb NodeDeviceLifecycleCallback) (EventCallbackID, error) {
+	return conn.registerNodeDeviceEvent(C.VIR_NODE_DEVICE_EVENT_ID_LIFECYCLE, "lifecycle", cb)
+}
+
+// RegisterNodeDeviceUpdateEvent registers a callback to be invoked when a node
+// device is updated. The returned ID can be used to deregister the callback
+// with DeregisterNodeDeviceEvent.
+func (conn Connection) RegisterNodeDeviceUpdateEvent(cb NodeDeviceUpdateCallback) (EventCallbackID, error) {
+	return conn.registerNodeDeviceEvent(C.VIR_NODE_DEVICE_EVENT_ID_UPDATE, "update", cb)
+}
+
+// DeregisterNodeDeviceEvent removes a node device event callback previously
+// registered with RegisterNodeDeviceEvent or RegisterNodeDeviceUpdateEvent.
+// The Go callback is released once libvirt is done with it.
+func (conn Connection) DeregisterNodeDeviceEvent(id EventCallbackID) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := conn.valid(); err != nil {
+		return err
+	}
+
+	conn.log.Printf("deregistering node device event callback (ID = %v)...\n", id)
+	cRet := C.virConnectNodeDeviceEventDeregisterAny(conn.virConnect, C.int(id))
+	ret := int32(cRet)
+
+	if ret == -1 {
+		err := LastError()
+		conn.log.Printf("an error occurred: %v\n", err)
+		return err
+	}
+
+	conn.log.Println("event callback deregistered")
+
+	return nil
+}
+
+// registerSecretEvent registers "cb" for the secret event "eventID"; "name"
+// describes the event in the log.
+func (conn Connection) registerSecretEvent(sec *Secret, eventID C.int, name string, cb interface{}) (EventCallbackID, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := conn.valid(); err != nil {
+		return 0, err
+	}
+
+	var cSec C.virSecretPtr
+	if sec != nil {
+		cSec = sec.virSecret
+	}
+
+	conn.log.Printf("registering secret %v event callback...\n", name)
+	id := registerCallback(eventCallback{
+		log: conn.log,
+		fn:  cb,
+	})
+
+	cRet := C.secretEventRegisterAnyHelper(conn.virConnect, cSec, eventID, C.long(id))
+	ret := int32(cRet)
+
+	if ret == -1 {
+		unregisterCallback(id)
+		err := LastError()
+		conn.log.Printf("an error occurred: %v\n", err)
+		return 0, err
+	}
+
+	callbackID := EventCallbackID(ret)
+
+	conn.log.Printf("event callback registered (ID = %v)\n", callbackID)
+
+	return callbackID, nil
+}
+
+// RegisterSecretEvent registers callbacks to be invoked when "sec" is defined
+// or undefined ("lifecycleCb") and when its value changes ("valueChangedCb").
+// If "sec" is nil, the callbacks are invoked for all secrets. Either callback
+// may be nil, in which case it isn't registered and its returned ID is -1.
+// The returned IDs can be used to deregister each callback with
+// DeregisterSecretEvent.
+// The callbacks are dispatched from the libvirt event loop, so an event loop
+// implementation must be registered and running.
+func (conn Connection) RegisterSecretEvent(sec *Secret, lifecycleCb SecretLifecycleCallback, valueChangedCb SecretValueChangedCallback) (lifecycleID EventCallbackID, valueChangedID EventCallbackID, err error) {
+	lifecycleID, valueChangedID = -1, -1
+
+	if lifecycleCb != nil {
+		if lifecycleID, err = conn.registerSecretEvent(sec, C.VIR_SECRET_EVENT_ID_LIFECYCLE, "lifecycle", lifecycleCb); err != nil {
+			return -1, -1, err
+		}
+	}
+
+	if valueChangedCb != nil {
+		if valueChangedID, err = conn.registerSecretEvent(sec, C.VIR_SECRET_EVENT_ID_VALUE_CHANGED, "value changed", valueChangedCb); err != nil {
+			if lifecycleID != -1 {
+				conn.DeregisterSecretEvent(lifecycleID)
+			}
+
+			return -1, -1, err
+		}
+	}
+
+	return lifecycleID, valueChangedID, nil
+}
+
+// DeregisterSecretEvent removes a secret event callback previously registered
+// with RegisterSecretEvent. The Go callback is released
+// once libvirt is done with it.
+func (conn Connection) DeregisterSecretEvent(id EventCallbackID) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := conn.valid(); err != nil {
+		return err
+	}
+
+	conn.log.Printf("deregistering secret event callback (ID = %v)...\n", id)
+	cRet := C.virConnectSecretEventDeregisterAny(conn.virConnect, C.int(id))
+	ret := int32(cRet)
+
+	if ret == -1 {
+		err := LastError()
+		conn.log.Printf("an error occurred: %v\n", err)
+		return err
+	}
+
+	conn.log.Println("event callback deregistered")
+
+	return nil
+}
+
+//export connectCloseCallback
+func connectCloseCallback(cConn C.virConnectPtr, cReason C.int, cID C.long) {
+	cb, ok := lookupCallback(int(cID))
+	if !ok {
+		return
+	}
+
+	if callback, ok := cb.(ConnectionCloseCallback); ok && callback != nil {
+		callback(ConnectionCloseReason(cReason))
+	}
+}
+
+//export freeCallback
+func freeCallback(cID C.long) {
+	unregisterCallback(int(cID))
+}
+
+// lookupEventCallback finds the event callback identified by "id".
+func lookupEventCallback(id C.long) (eventCallback, bool) {
+	cb, ok := lookupCallback(int(id))
+	if !ok {
+		return eventCallback{}, false
+	}
+
+	ecb, ok := cb.(eventCallback)
+
+	return ecb, ok
+}
+
+//export domainEventLifecycleCallback
+func domainEventLifecycleCallback(cConn C.virConnectPtr, cDom C.virDomainPtr, cEvent C.int, cDetail C.int, cID C.long) {
+	ecb, ok := lookupEventCallback(cID)
+	if !ok {
+		return
+	}
+
+	if callback, ok := ecb.fn.(DomainLifecycleCallback); ok && callback != nil {
+		dom := Domain{
+			log:       ecb.log,
+			virDomain: cDom,
+		}
+
+		callback(dom, DomainEventType(cEvent), int(cDetail))
+	}
+}
+
+//export domainEventRebootCallback
+func domainEventRebootCallback(cConn C.virConnectPtr, cDom C.virDomainPtr, cID C.long) {
+	ecb, ok := lookupEventCallback(cID)
+	if !ok {
+		return
+	}
+
+	if callback, ok := ecb.fn.(DomainRebootCallback); ok && callback != nil {
+		dom := Domain{
+			log:       ecb.log,
+			virDomain: cDom,
+		}
+
+		callback(dom)
+	}
+}
+
+//export domainEventRTCChangeCallback
+func domainEventRTCChangeCallback(cConn C.virConnectPtr, cDom C.virDomainPtr, cUTCOffset C.longlong, cID C.long) {
+	ecb, ok := lookupEventCallback(cID)
+	if !ok {
+		return
+	}
+
+	if callback, ok := ecb.fn.(DomainRTCChangeCallback); ok && callback != nil {
+		dom := Domain{
+			log:       ecb.log,
+			virDomain: cDom,
+		}
+
+		callback(dom, int64(cUTCOffset))
+	}
+}
+
+//export domainEventWatchdogCallback
+func domainEventWatchdogCallback(cConn C.virConnectPtr, cDom C.virDomainPtr, cAction C.int, cID C.long) {
+	ecb, ok := lookupEventCallback(cID)
+	if !ok {
+		return
+	}
+
+	if callback, ok := ecb.fn.(DomainWatchdogCallback); ok && callback != nil {
+		dom := Domain{
+			log:       ecb.log,
+			virDomain: cDom,
+		}
+
+		callback(dom, WatchdogAction(cAction))
+	}
+}
+
+//export domainEventIOErrorCallback
+func domainEventIOErrorCallback(cConn C.virConnectPtr, cDom C.virDomainPtr, cSrcPath *C.char, cDevAlias *C.char, cAction C.int, cReason *C.char, cID C.long) {
+	ecb, ok := lookupEventCallback(cID)
+	if !ok {
+		return
+	}
+
+	if callback, ok := ecb.fn.(DomainIOErrorCallback); ok && callback != nil {
+		dom := Domain{
+			log:       ecb.log,
+			virDomain: cDom,
+		}
+
+		callback(dom, C.GoString(cSrcPath), C.GoString(cDevAlias), IOErrorAction(cAction), C.GoString(cReason))
+	}
+}
+
+//export domainEventDeviceRemovedCallback
+func domainEventDeviceRemovedCallback(cConn C.virConnectPtr, cDom C.virDomainPtr, cDevAlias *C.char, cID C.long) {
+	ecb, ok := lookupEventCallback(cID)
+	if !ok {
+		return
+	}
+
+	if callback, ok := ecb.fn.(DomainDeviceRemovedCallback); ok && callback != nil {
+		dom := Domain{
+			log:       ecb.log,
+			virDomain: cDom,
+		}
+
+		callback(dom, C.GoString(cDevAlias))
+	}
+}
+
+//export networkEventLifecycleCallback
+func networkEventLifecycleCallback(cConn C.virConnectPtr, cNet C.virNetworkPtr, cEvent C.int, cDetail C.int, cID C.long) {
+	ecb, ok := lookupEventCallback(cID)
+	if !ok {
+		return
+	}
+
+	if callback, ok := ecb.fn.(NetworkLifecycleCallback); ok && callback != nil {
+		net := Network{
+			log:        ecb.log,
+			virNetwork: cNet,
+		}
+
+		callback(net, NetworkEventType(cEvent), int(cDetail))
+	}
+}
+
+//export nodeDeviceEventLifecycleCallback
+func nodeDeviceEventLifecycleCallback(cConn C.virConnectPtr, cDev C.virNodeDevicePtr, cEvent C.int, cDetail C.int, cID C.long) {
+	ecb, ok := lookupEventCallback(cID)
+	if !ok {
+		return
+	}
+
+	if callback, ok := ecb.fn.(NodeDeviceLifecycleCallback); ok && callback != nil {
+		dev := NodeDevice{
+			log:           ecb.log,
+			virNodeDevice: cDev,
+		}
+
+		callback(dev, NodeDeviceEventType(cEvent), int(cDetail))
+	}
+}
+
+//export nodeDeviceEventUpdateCallback
+func nodeDeviceEventUpdateCallback(cConn C.virConnectPtr, cDev C.virNodeDevicePtr, cID C.long) {
+	ecb, ok := lookupEventCallback(cID)
+	if !ok {
+		return
+	}
+
+	if callback, ok := ecb.fn.(NodeDeviceUpdateCallback); ok && callback != nil {
+		dev := NodeDevice{
+			log:           ecb.log,
+			virNodeDevice: cDev,
+		}
+
+		callback(dev)
+	}
+}
+
+//export secretEventLifecycleCallback
+func secretEventLifecycleCallback(cConn C.virConnectPtr, cSec C.virSecretPtr, cEvent C.int, cDetail C.int, cID C.long) {
+	ecb, ok := lookupEventCallback(cID)
+	if !ok {
+		return
+	}
+
+	if callback, ok := ecb.fn.(SecretLifecycleCallback); ok && callback != nil {
+		sec := Secret{
+			log:       ecb.log,
+			virSecret: cSec,
+		}
+
+		callback(sec, SecretEventType(cEvent), int(cDetail))
+	}
+}
+
+//export secretEventValueChangedCallback
+func secretEventValueChangedCallback(cConn C.virConnectPtr, cSec C.virSecretPtr, cID C.long) {
+	ecb, ok := lookupEventCallback(cID)
+	if !ok {
+		return
+	}
+
+	if callback, ok := ecb.fn.(SecretValueChangedCallback); ok && callback != nil {
+		sec := Secret{
+			log:       ecb.log,
+			virSecret: cSec,
+		}
+
+		callback(sec)
+	}
+}
@@ -0,0 +1,308 @@
+package libvirt
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestConnectionCloseCallback(t *testing.T) {
+	stopEventLoop := testRunEventLoop(t)
+	defer stopEventLoop()
+
+	env := newTestEnvironment(t)
+	defer env.cleanUp()
+
+	if err := env.conn.UnregisterCloseCallback(); err == nil {
+		t.Error("an error was not returned when unregistering a close callback which was not registered")
+	}
+
+	cb := func(reason ConnectionCloseReason) {}
+
+	if err := env.conn.RegisterCloseCallback(cb); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := env.conn.RegisterCloseCallback(cb); err == nil {
+		t.Error("an error was not returned when registering a second close callback")
+	}
+
+	if err := env.conn.UnregisterCloseCallback(); err != nil {
+		t.Error(err)
+	}
+
+	// the callback can be registered again after being unregistered
+	if err := env.conn.RegisterCloseCallback(cb); err != nil {
+		t.Error(err)
+	}
+
+	conn, err := Open(testConnectionURI, ReadWrite, testLogOutput)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reasons := make(chan ConnectionCloseReason, 1)
+
+	if err = conn.RegisterCloseCallback(func(reason ConnectionCloseReason) {
+		reasons <- reason
+	}); err != nil {
+		conn.Close()
+		t.Fatal(err)
+	}
+
+	if _, err = conn.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case reason := <-reasons:
+		if reason != ConnCloseReasonClient {
+			t.Errorf("unexpected connection close reason; got=%v, want=%v", reason, ConnCloseReasonClient)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("the close callback was not called after closing the connection")
+	}
+}
+
+func TestConnectionDomainLifecycleEvent(t *testing.T) {
+	stopEventLoop := testRunEventLoop(t)
+	defer stopEventLoop()
+
+	env := newTestEnvironment(t).withDomain()
+	defer env.cleanUp()
+
+	if err := env.conn.DeregisterDomainEvent(EventCallbackID(-1)); err == nil {
+		t.Error("an error was not returned when deregistering an invalid event callback")
+	}
+
+	events := make(chan DomainEventType, 10)
+
+	id, err := env.conn.RegisterDomainLifecycleEvent(env.dom, func(dom Domain, event DomainEventType, detail int) {
+		events <- event
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer env.conn.DeregisterDomainEvent(id)
+
+	if err = env.dom.Create(DomCreateDefault); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = env.dom.Destroy(DomDestroyDefault); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []DomainEventType{DomEventStarted, DomEventStopped} {
+		select {
+		case got := <-events:
+			if got != want {
+				t.Errorf("unexpected domain lifecycle event; got=%v, want=%v", got, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("the domain lifecycle event was not received; want=%v", want)
+		}
+	}
+}
+
+func TestConnectionDomainEvents(t *testing.T) {
+	stopEventLoop := testRunEventLoop(t)
+	defer stopEventLoop()
+
+	env := newTestEnvironment(t).withDomain()
+	defer env.cleanUp()
+
+	var ids []EventCallbackID
+
+	id, err := env.conn.RegisterDomainRebootEvent(env.dom, func(dom Domain) {})
+	if err != nil {
+		t.Error(err)
+	} else {
+		ids = append(ids, id)
+	}
+
+	id, err = env.conn.RegisterDomainRTCChangeEvent(env.dom, func(dom Domain, utcOffset int64) {})
+	if err != nil {
+		t.Error(err)
+	} else {
+		ids = append(ids, id)
+	}
+
+	id, err = env.conn.RegisterDomainWatchdogEvent(nil, func(dom Domain, action WatchdogAction) {})
+	if err != nil {
+		t.Error(err)
+	} else {
+		ids = append(ids, id)
+	}
+
+	id, err = env.conn.RegisterDomainIOErrorEvent(nil, func(dom Domain, srcPath string, devAlias string, action IOErrorAction, reason string) {})
+	if err != nil {
+		t.Error(err)
+	} else {
+		ids = append(ids, id)
+	}
+
+	id, err = env.conn.RegisterDomainDeviceRemovedEvent(env.dom, func(dom Domain, devAlias string) {})
+	if err != nil {
+		t.Error(err)
+	} else {
+		ids = append(ids, id)
+	}
+
+	for _, id := range ids {
+		if err := env.conn.DeregisterDomainEvent(id); err != nil {
+			t.Error(err)
+		}
+
+		if err := env.conn.DeregisterDomainEvent(id); err == nil {
+			t.Error("an error was not returned when deregistering an event callback twice")
+		}
+	}
+}
+
+func TestConnectionNetworkLifecycleEvent(t *testing.T) {
+	stopEventLoop := testRunEventLoop(t)
+	defer stopEventLoop()
+
+	env := newTestEnvironment(t)
+	defer env.cleanUp()
+
+	if err := env.conn.DeregisterNetworkEvent(EventCallbackID(-1)); err == nil {
+		t.Error("an error was not returned when deregistering an invalid event callback")
+	}
+
+	data := newTestNetworkData()
+	events := make(chan NetworkEventType, 10)
+
+	id, err := env.conn.RegisterNetworkLifecycleEvent(nil, func(net Network, event NetworkEventType, detail int) {
+		if name, err := net.Name(); err == nil && name == data.Name {
+			events <- event
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer env.conn.DeregisterNetworkEvent(id)
+
+	var xml bytes.Buffer
+
+	if err = testNetworkTmpl.Execute(&xml, data); err != nil {
+		t.Fatal(err)
+	}
+
+	net, err := env.conn.DefineNetwork(xml.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer net.Free()
+
+	if err = net.Undefine(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []NetworkEventType{NetEventDefined, NetEventUndefined} {
+		select {
+		case got := <-events:
+			if got != want {
+				t.Errorf("unexpected network lifecycle event; got=%v, want=%v", got, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("the network lifecycle event was not received; want=%v", want)
+		}
+	}
+}
+
+func TestConnectionNodeDeviceEvents(t *testing.T) {
+	stopEventLoop := testRunEventLoop(t)
+	defer stopEventLoop()
+
+	env := newTestEnvironment(t)
+	defer env.cleanUp()
+
+	if err := env.conn.DeregisterNodeDeviceEvent(EventCallbackID(-1)); err == nil {
+		t.Error("an error was not returned when deregistering an invalid event callback")
+	}
+
+	callbacks.RLock()
+	count := len(callbacks.funcs)
+	callbacks.RUnlock()
+
+	lifecycleID, err := env.conn.RegisterNodeDeviceEvent(func(dev NodeDevice, event NodeDeviceEventType, detail int) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	updateID, err := env.conn.RegisterNodeDeviceUpdateEvent(func(dev NodeDevice) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = env.conn.DeregisterNodeDeviceEvent(lifecycleID); err != nil {
+		t.Error(err)
+	}
+
+	if err = env.conn.DeregisterNodeDeviceEvent(updateID); err != nil {
+		t.Error(err)
+	}
+
+	// libvirt releases the callbacks asynchronously
+	timeout := time.After(5 * time.Second)
+	for {
+		callbacks.RLock()
+		current := len(callbacks.funcs)
+		callbacks.RUnlock()
+
+		if current <= count {
+			break
+		}
+
+		select {
+		case <-timeout:
+			t.Fatalf("the deregistered callbacks were not released; got=%v, want<=%v", current, count)
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+func TestConnectionSecretEvents(t *testing.T) {
+	stopEventLoop := testRunEventLoop(t)
+	defer stopEventLoop()
+
+	env := newTestEnvironment(t).withSecret()
+	defer env.cleanUp()
+
+	if err := env.conn.DeregisterSecretEvent(EventCallbackID(-1)); err == nil {
+		t.Error("an error was not returned when deregistering an invalid event callback")
+	}
+
+	if lifecycleID, valueChangedID, err := env.conn.RegisterSecretEvent(nil, nil, nil); err != nil {
+		t.Error(err)
+	} else if lifecycleID != -1 || valueChangedID != -1 {
+		t.Errorf("unexpected callback IDs without callbacks; got=%v/%v, want=-1/-1", lifecycleID, valueChangedID)
+	}
+
+	changed := make(chan struct{}, 10)
+
+	lifecycleID, valueChangedID, err := env.conn.RegisterSecretEvent(env.sec, func(sec Secret, event SecretEventType, detail int) {}, func(sec Secret) {
+		changed <- struct{}{}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer env.conn.DeregisterSecretEvent(valueChangedID)
+
+	// the callbacks can be deregistered independently
+	if err = env.conn.DeregisterSecretEvent(lifecycleID); err != nil {
+		t.Error(err)
+	}
+
+	if err = env.sec.SetValue(env.secData.Value); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-changed:
+	case <-time.After(5 * time.Second):
+		t.Error("the secret value changed event was not received")
+	}
+}
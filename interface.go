@@ -5,6 +5,9 @@ package libvirt
 import "C"
 import (
 	"log"
+	"runtime"
+	"unicode/utf8"
+	"unsafe"
 )
 
 // InterfaceListFlag defines a filter when listing network interfaces.
@@ -17,6 +20,16 @@ const (
 	IfaceListInactive InterfaceListFlag = C.VIR_CONNECT_LIST_INTERFACES_INACTIVE
 )
 
+// InterfaceXMLFlag defines how the XML content should be read from an
+// interface.
+type InterfaceXMLFlag uint32
+
+// Possible values for InterfaceXMLFlag.
+const (
+	IfaceXMLDefault  InterfaceXMLFlag = 0
+	IfaceXMLInactive InterfaceXMLFlag = C.VIR_INTERFACE_XML_INACTIVE
+)
+
 // Interface holds a libvirt network interface. There are no exported fields.
 type Interface struct {
 	log          *log.Logger
@@ -26,6 +39,9 @@ type Interface struct {
 // Free frees the interface object. The interface itself is unaltered. The data
 // structure is freed and should not be used thereafter.
 func (iface Interface) Free() error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	iface.log.Println("freeing interface object...")
 	cRet := C.virInterfaceFree(iface.virInterface)
 	ret := int32(cRet)
@@ -40,3 +56,66 @@ func (iface Interface) Free() error {
 
 	return nil
 }
+
+// Name gets the public name for that interface.
+func (iface Interface) Name() (string, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	iface.log.Println("reading interface name...")
+	cName := C.virInterfaceGetName(iface.virInterface)
+
+	if cName == nil {
+		err := LastError()
+		iface.log.Printf("an error occurred: %v\n", err)
+		return "", err
+	}
+
+	name := C.GoString(cName)
+	iface.log.Printf("name: %v\n", name)
+
+	return name, nil
+}
+
+// MACAddress gets the MAC address for that interface as string.
+func (iface Interface) MACAddress() (string, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	iface.log.Println("reading interface MAC address...")
+	cMAC := C.virInterfaceGetMACString(iface.virInterface)
+
+	if cMAC == nil {
+		err := LastError()
+		iface.log.Printf("an error occurred: %v\n", err)
+		return "", err
+	}
+
+	mac := C.GoString(cMAC)
+	iface.log.Printf("MAC address: %v\n", mac)
+
+	return mac, nil
+}
+
+// XML provides an XML description of the interface. The description may be
+// reused later to redefine the interface.
+func (iface Interface) XML(flags InterfaceXMLFlag) (string, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	iface.log.Printf("reading interface XML (flags = %v)...\n", flags)
+	cXML := C.virInterfaceGetXMLDesc(iface.virInterface, C.uint(flags))
+
+	if cXML == nil {
+		err := LastError()
+		iface.log.Printf("an error occurred: %v\n", err)
+		return "", err
+	}
+	defer C.free(unsafe.Pointer(cXML))
+
+	xml := C.GoString(cXML)
+
+	iface.log.Printf("XML length: %v runes\n", utf8.RuneCountInString(xml))
+
+	return xml, nil
+}
@@ -0,0 +1,51 @@
+package libvirt
+
+import (
+	"testing"
+)
+
+func TestInterfaceInit(t *testing.T) {
+	// the "test" driver always has the interface "eth1"
+	conn, err := Open("test:///default", ReadOnly, testLogOutput)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	interfaces, err := conn.ListInterfaces(IfaceListAll)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(interfaces) == 0 {
+		t.Skip("the driver doesn't report any interface")
+	}
+
+	for _, iface := range interfaces {
+		defer iface.Free()
+	}
+
+	iface := interfaces[0]
+
+	name, err := iface.Name()
+	if err != nil {
+		t.Error(err)
+	}
+
+	if len(name) == 0 {
+		t.Error("empty interface name")
+	}
+
+	if _, err = iface.MACAddress(); err != nil {
+		t.Error(err)
+	}
+
+	xml, err := iface.XML(IfaceXMLDefault)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if len(xml) == 0 {
+		t.Error("empty interface XML")
+	}
+}
@@ -2,6 +2,7 @@ package libvirt
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
 	"fmt"
 	"io/ioutil"
@@ -14,6 +15,13 @@ import (
 	"github.com/cd1/utils-golang"
 )
 
+const testAuthNodeXML = `
+<node>
+    <auth>
+        <user password="{{.Password}}">{{.Username}}</user>
+    </auth>
+</node>`
+
 const testDeviceLogXML = `
 <disk type="dir" device="cdrom">
     <driver name="qemu" type="raw" />
@@ -30,6 +38,29 @@ const testDeviceTmpXML = `
     <readonly />
 </disk>`
 
+const testDeviceCDROMXML = `
+<disk type="file" device="cdrom">
+    <driver name="qemu" type="raw" />
+    <source file="{{.}}" />
+    <target dev="hdc" />
+    <readonly />
+</disk>`
+
+const testDeviceDiskXML = `
+<disk type="file" device="disk">
+    <driver name="qemu" type="{{.FormatType}}" />
+    <source file="{{.Path}}" />
+    <target dev="{{.Target}}" bus="virtio" />
+</disk>`
+
+const testDeviceInterfaceMAC = "52:54:00:6c:69:62"
+
+const testDeviceInterfaceXML = `
+<interface type="user">
+    <mac address="` + testDeviceInterfaceMAC + `" />
+    <model type="virtio" />
+</interface>`
+
 const testDomainMetadataXML = `
 <{{.MetadataTag}}>
     {{.MetadataContent}}
@@ -54,6 +85,12 @@ const testDomainXML = `
     </devices>
 </domain>`
 
+const testNetworkXML = `
+<network>
+    <name>{{.Name}}</name>
+    <uuid>{{.UUID}}</uuid>
+</network>`
+
 const testSecretXML = `
 <secret>
     <uuid>{{.UUID}}</uuid>
@@ -94,8 +131,12 @@ var (
 
 // These variables shouldn't be changed.
 var (
+	testAuthNodeTmpl       = template.Must(template.New("test-auth-node").Parse(testAuthNodeXML))
+	testDeviceCDROMTmpl    = template.Must(template.New("test-device-cdrom").Parse(testDeviceCDROMXML))
+	testDeviceDiskTmpl     = template.Must(template.New("test-device-disk").Parse(testDeviceDiskXML))
 	testDomainMetadataTmpl = template.Must(template.New("test-domain-metadata").Parse(testDomainMetadataXML))
 	testDomainTmpl         = template.Must(template.New("test-domain").Parse(testDomainXML))
+	testNetworkTmpl        = template.Must(template.New("test-network").Parse(testNetworkXML))
 	testSecretTmpl         = template.Must(template.New("test-secret").Parse(testSecretXML))
 	testSnapshotTmpl       = template.Must(template.New("test-snapshot").Parse(testSnapshotXML))
 	testStoragePoolTmpl    = template.Must(template.New("test-storagepool").Parse(testStoragePoolXML))
@@ -123,6 +164,12 @@ type testDomainData struct {
 	poolData          *testStoragePoolData
 }
 
+// testNetworkData contains the data of a network used for testing.
+type testNetworkData struct {
+	Name string
+	UUID string
+}
+
 // testSecretData contains the data of a secret used for testing.
 type testSecretData struct {
 	UUID            string
@@ -161,6 +208,8 @@ type testEnvironment struct {
 	conn     *Connection
 	dom      *Domain
 	domData  *testDomainData
+	net      *Network
+	netData  *testNetworkData
 	pool     *StoragePool
 	poolData *testStoragePoolData
 	sec      *Secret
@@ -180,7 +229,7 @@ func newTestDomainData(conn Connection) (*testDomainData, error) {
 		DiskSize:          rand.Intn(1048576) + 1, // <= 1 MiB
 		DiskTarget:        "vda",
 		Name:              fmt.Sprintf("domain-%v", utils.RandomString()),
-		MaxMemory:         1048576, // 1 MiB
+		MaxMemory:         1048576, // 1 GiB
 		MaxVCPUs:          4,
 		MetadataContent:   fmt.Sprintf("content-%v", utils.RandomString()),
 		MetadataKey:       fmt.Sprintf("key-%v", utils.RandomString()),
@@ -268,6 +317,15 @@ func (data *testDomainData) cleanUp(conn Connection) error {
 	return nil
 }
 
+// newTestNetworkData creates new data for a test network. The values are
+// generated randomly every time this function is called.
+func newTestNetworkData() *testNetworkData {
+	return &testNetworkData{
+		Name: fmt.Sprintf("network-%v", utils.RandomString()),
+		UUID: uuid.New(),
+	}
+}
+
 // newTestSecretData creates new data for a test secret. The values are
 // generated randomly every time this function is called.
 func newTestSecretData() *testSecretData {
@@ -329,6 +387,28 @@ func newTestStorageVolumeData() *testStorageVolumeData {
 	}
 }
 
+// testRunEventLoop registers the default event loop implementation and runs
+// it in the background. The returned function stops the loop. Connections
+// which need the event loop must be opened after calling this function.
+func testRunEventLoop(t testing.TB) func() {
+	if err := EventRegisterDefaultImpl(); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var loop EventLoop
+	if err := loop.Run(ctx); err != nil {
+		cancel()
+		t.Fatal(err)
+	}
+
+	return func() {
+		cancel()
+		loop.Wait()
+	}
+}
+
 // newTestEnvironment creates a new test environment. Basically it opens a
 // connection to libvirt.
 func newTestEnvironment(t testing.TB) *testEnvironment {
@@ -383,6 +463,16 @@ func (env *testEnvironment) cleanUp() {
 		}
 	}
 
+	if env.net != nil {
+		if err := env.net.Undefine(); err != nil {
+			env.t.Error(err)
+		}
+
+		if err := env.net.Free(); err != nil {
+			env.t.Error(err)
+		}
+	}
+
 	if env.sec != nil {
 		if err := env.sec.Undefine(); err != nil {
 			env.t.Error(err)
@@ -489,6 +579,28 @@ func (env *testEnvironment) withSnapshot() *testEnvironment {
 	return env
 }
 
+// withNetwork defines a new test network. The network "net" will remain
+// inactive.
+func (env *testEnvironment) withNetwork() *testEnvironment {
+	data := newTestNetworkData()
+
+	var xml bytes.Buffer
+
+	if err := testNetworkTmpl.Execute(&xml, data); err != nil {
+		env.t.Fatal(err)
+	}
+
+	net, err := env.conn.DefineNetwork(xml.String())
+	if err != nil {
+		env.t.Fatal(err)
+	}
+
+	env.netData = data
+	env.net = &net
+
+	return env
+}
+
 // withSecret defines a new test secret.
 func (env *testEnvironment) withSecret() *testEnvironment {
 	data := newTestSecretData()
@@ -564,6 +676,29 @@ func (env *testEnvironment) withStorageVolume() *testEnvironment {
 	return env
 }
 
+// diskDeviceXML returns the XML of a disk device backed by the test storage
+// volume, to be attached to the test domain as "target".
+func (env *testEnvironment) diskDeviceXML(target string) string {
+	path, err := env.vol.Path()
+	if err != nil {
+		env.t.Fatal(err)
+	}
+
+	data := struct {
+		FormatType string
+		Path       string
+		Target     string
+	}{env.volData.FormatType, path, target}
+
+	var xml bytes.Buffer
+
+	if err = testDeviceDiskTmpl.Execute(&xml, data); err != nil {
+		env.t.Fatal(err)
+	}
+
+	return xml.String()
+}
+
 // withStream creates a new test stream.
 func (env *testEnvironment) withStream() *testEnvironment {
 	str, err := env.conn.NewStream(StrDefault)
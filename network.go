@@ -0,0 +1,139 @@
+package libvirt
+
+// #include <stdlib.h>
+// #include <libvirt/libvirt.h>
+import "C"
+import (
+	"log"
+	"runtime"
+	"unicode/utf8"
+	"unsafe"
+)
+
+// NetworkListFlag defines a filter when listing networks.
+type NetworkListFlag uint32
+
+// Possible values for NetworkListFlag.
+const (
+	NetListAll         NetworkListFlag = 0
+	NetListInactive    NetworkListFlag = C.VIR_CONNECT_LIST_NETWORKS_INACTIVE
+	NetListActive      NetworkListFlag = C.VIR_CONNECT_LIST_NETWORKS_ACTIVE
+	NetListPersistent  NetworkListFlag = C.VIR_CONNECT_LIST_NETWORKS_PERSISTENT
+	NetListTransient   NetworkListFlag = C.VIR_CONNECT_LIST_NETWORKS_TRANSIENT
+	NetListAutostart   NetworkListFlag = C.VIR_CONNECT_LIST_NETWORKS_AUTOSTART
+	NetListNoAutostart NetworkListFlag = C.VIR_CONNECT_LIST_NETWORKS_NO_AUTOSTART
+)
+
+// Network holds a libvirt virtual network. There are no exported fields.
+type Network struct {
+	log        *log.Logger
+	virNetwork C.virNetworkPtr
+}
+
+// Free frees the network object. The running instance is kept alive. The data
+// structure is freed and should not be used thereafter.
+func (net Network) Free() error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	net.log.Println("freeing network object...")
+	cRet := C.virNetworkFree(net.virNetwork)
+	ret := int32(cRet)
+
+	if ret == -1 {
+		err := LastError()
+		net.log.Printf("an error occurred: %v\n", err)
+		return err
+	}
+
+	net.log.Println("network freed")
+
+	return nil
+}
+
+// Undefine undefines a network but does not stop it if it is running.
+func (net Network) Undefine() error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	net.log.Println("undefining network...")
+	cRet := C.virNetworkUndefine(net.virNetwork)
+	ret := int32(cRet)
+
+	if ret == -1 {
+		err := LastError()
+		net.log.Printf("an error occurred: %v\n", err)
+		return err
+	}
+
+	net.log.Println("network undefined")
+
+	return nil
+}
+
+// Name gets the public name for that network.
+func (net Network) Name() (string, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	net.log.Println("reading network name...")
+	cName := C.virNetworkGetName(net.virNetwork)
+
+	if cName == nil {
+		err := LastError()
+		net.log.Printf("an error occurred: %v\n", err)
+		return "", err
+	}
+
+	name := C.GoString(cName)
+	net.log.Printf("name: %v\n", name)
+
+	return name, nil
+}
+
+// UUID gets the UUID for a network as string.
+func (net Network) UUID() (string, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	cUUID := (*C.char)(C.malloc(C.size_t(C.VIR_UUID_STRING_BUFLEN)))
+	defer C.free(unsafe.Pointer(cUUID))
+
+	net.log.Println("reading network UUID...")
+	cRet := C.virNetworkGetUUIDString(net.virNetwork, cUUID)
+	ret := int32(cRet)
+
+	if ret == -1 {
+		err := LastError()
+		net.log.Printf("an error occurred: %v\n", err)
+		return "", err
+	}
+
+	uuid := C.GoString(cUUID)
+	net.log.Printf("UUID: %v\n", uuid)
+
+	return uuid, nil
+}
+
+// XML provides an XML description of the network. The description may be
+// reused later to relaunch the network with Connection.DefineNetwork.
+func (net Network) XML() (string, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	net.log.Println("reading network XML...")
+	cXML := C.virNetworkGetXMLDesc(net.virNetwork, 0)
+
+	if cXML == nil {
+		err := LastError()
+		net.log.Printf("an error occurred: %v\n", err)
+		return "", err
+	}
+	defer C.free(unsafe.Pointer(cXML))
+
+	xml := C.GoString(cXML)
+
+	net.log.Printf("XML length: %v runes\n", utf8.RuneCountInString(xml))
+
+	return xml, nil
+}
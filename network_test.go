@@ -0,0 +1,37 @@
+package libvirt
+
+import (
+	"testing"
+)
+
+func TestNetworkInit(t *testing.T) {
+	env := newTestEnvironment(t).withNetwork()
+	defer env.cleanUp()
+
+	name, err := env.net.Name()
+	if err != nil {
+		t.Error(err)
+	}
+
+	if name != env.netData.Name {
+		t.Errorf("wrong test network name; got=%v, want=%v", name, env.netData.Name)
+	}
+
+	uuid, err := env.net.UUID()
+	if err != nil {
+		t.Error(err)
+	}
+
+	if uuid != env.netData.UUID {
+		t.Errorf("wrong test network UUID; got=%v, want=%v", uuid, env.netData.UUID)
+	}
+
+	xml, err := env.net.XML()
+	if err != nil {
+		t.Error(err)
+	}
+
+	if len(xml) == 0 {
+		t.Error("empty network XML")
+	}
+}
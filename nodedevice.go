@@ -0,0 +1,200 @@
+package libvirt
+
+// #include <stdlib.h>
+// #include <libvirt/libvirt.h>
+// #include <libvirt/virterror.h>
+import "C"
+import (
+	"log"
+	"runtime"
+	"unicode/utf8"
+	"unsafe"
+)
+
+// NodeDeviceListFlag defines a filter when listing node devices.
+type NodeDeviceListFlag uint32
+
+// Possible values for NodeDeviceListFlag.
+const (
+	NodeDevListAll          NodeDeviceListFlag = 0
+	NodeDevListSystem       NodeDeviceListFlag = C.VIR_CONNECT_LIST_NODE_DEVICES_CAP_SYSTEM
+	NodeDevListPCIDev       NodeDeviceListFlag = C.VIR_CONNECT_LIST_NODE_DEVICES_CAP_PCI_DEV
+	NodeDevListUSBDev       NodeDeviceListFlag = C.VIR_CONNECT_LIST_NODE_DEVICES_CAP_USB_DEV
+	NodeDevListUSBInterface NodeDeviceListFlag = C.VIR_CONNECT_LIST_NODE_DEVICES_CAP_USB_INTERFACE
+	NodeDevListNet          NodeDeviceListFlag = C.VIR_CONNECT_LIST_NODE_DEVICES_CAP_NET
+	NodeDevListSCSIHost     NodeDeviceListFlag = C.VIR_CONNECT_LIST_NODE_DEVICES_CAP_SCSI_HOST
+	NodeDevListSCSITarget   NodeDeviceListFlag = C.VIR_CONNECT_LIST_NODE_DEVICES_CAP_SCSI_TARGET
+	NodeDevListSCSI         NodeDeviceListFlag = C.VIR_CONNECT_LIST_NODE_DEVICES_CAP_SCSI
+	NodeDevListStorage      NodeDeviceListFlag = C.VIR_CONNECT_LIST_NODE_DEVICES_CAP_STORAGE
+	NodeDevListFCHost       NodeDeviceListFlag = C.VIR_CONNECT_LIST_NODE_DEVICES_CAP_FC_HOST
+	NodeDevListVports       NodeDeviceListFlag = C.VIR_CONNECT_LIST_NODE_DEVICES_CAP_VPORTS
+	NodeDevListSCSIGeneric  NodeDeviceListFlag = C.VIR_CONNECT_LIST_NODE_DEVICES_CAP_SCSI_GENERIC
+	NodeDevListDRM          NodeDeviceListFlag = C.VIR_CONNECT_LIST_NODE_DEVICES_CAP_DRM
+	NodeDevListMDevTypes    NodeDeviceListFlag = C.VIR_CONNECT_LIST_NODE_DEVICES_CAP_MDEV_TYPES
+	NodeDevListMDev         NodeDeviceListFlag = C.VIR_CONNECT_LIST_NODE_DEVICES_CAP_MDEV
+	NodeDevListCCWDev       NodeDeviceListFlag = C.VIR_CONNECT_LIST_NODE_DEVICES_CAP_CCW_DEV
+)
+
+// NodeDevice holds a libvirt node device (i.e. a device on the host). There
+// are no exported fields.
+type NodeDevice struct {
+	log           *log.Logger
+	virNodeDevice C.virNodeDevicePtr
+}
+
+// Free drops a reference to the node device, freeing it if this was the last
+// reference.
+func (dev NodeDevice) Free() error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	dev.log.Println("freeing node device object...")
+	cRet := C.virNodeDeviceFree(dev.virNodeDevice)
+	ret := int32(cRet)
+
+	if ret == -1 {
+		err := LastError()
+		dev.log.Printf("an error occurred: %v\n", err)
+		return err
+	}
+
+	dev.log.Println("node device freed")
+
+	return nil
+}
+
+// Ref increments the reference count on the node device. For each additional
+// call to this method, there shall be a corresponding call to "Free" to
+// release the reference count, once the caller no longer needs the reference
+// to this object.
+func (dev NodeDevice) Ref() error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	dev.log.Println("incrementing node device's reference count...")
+	cRet := C.virNodeDeviceRef(dev.virNodeDevice)
+	ret := int32(cRet)
+
+	if ret == -1 {
+		err := LastError()
+		dev.log.Printf("an error occurred: %v\n", err)
+		return err
+	}
+
+	dev.log.Println("reference count incremented")
+
+	return nil
+}
+
+// Name gets the device name.
+func (dev NodeDevice) Name() (string, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	dev.log.Println("reading node device name...")
+	cName := C.virNodeDeviceGetName(dev.virNodeDevice)
+
+	if cName == nil {
+		err := LastError()
+		dev.log.Printf("an error occurred: %v\n", err)
+		return "", err
+	}
+
+	name := C.GoString(cName)
+	dev.log.Printf("name: %v\n", name)
+
+	return name, nil
+}
+
+// Parent gets the name of the device's parent. If the device doesn't have a
+// parent, an empty string is returned.
+func (dev NodeDevice) Parent() (string, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	dev.log.Println("reading node device parent...")
+	cParent := C.virNodeDeviceGetParent(dev.virNodeDevice)
+
+	if cParent == nil {
+		// libvirt also returns NULL when the device doesn't have a parent
+		if cError := C.virGetLastError(); cError != nil {
+			err := NewError(cError)
+			dev.log.Printf("an error occurred: %v\n", err)
+			return "", err
+		}
+
+		dev.log.Println("node device has no parent")
+		return "", nil
+	}
+
+	parent := C.GoString(cParent)
+	dev.log.Printf("parent: %v\n", parent)
+
+	return parent, nil
+}
+
+// XML fetches an XML document describing the node device.
+func (dev NodeDevice) XML() (string, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	dev.log.Println("reading node device XML...")
+	cXML := C.virNodeDeviceGetXMLDesc(dev.virNodeDevice, 0)
+
+	if cXML == nil {
+		err := LastError()
+		dev.log.Printf("an error occurred: %v\n", err)
+		return "", err
+	}
+	defer C.free(unsafe.Pointer(cXML))
+
+	xml := C.GoString(cXML)
+
+	dev.log.Printf("XML length: %v runes\n", utf8.RuneCountInString(xml))
+
+	return xml, nil
+}
+
+// ListCaps lists the names of the capabilities supported by the device (e.g.
+// "pci", "net").
+func (dev NodeDevice) ListCaps() ([]string, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	dev.log.Println("querying number of node device capabilities...")
+	cRet := C.virNodeDeviceNumOfCaps(dev.virNodeDevice)
+	ret := int32(cRet)
+
+	if ret == -1 {
+		err := LastError()
+		dev.log.Printf("an error occurred: %v\n", err)
+		return nil, err
+	}
+
+	if ret == 0 {
+		dev.log.Println("node device has no capabilities")
+		return []string{}, nil
+	}
+
+	cCaps := make([]*C.char, ret)
+
+	dev.log.Printf("reading %v node device capabilities...\n", ret)
+	cRet = C.virNodeDeviceListCaps(dev.virNodeDevice, &cCaps[0], C.int(len(cCaps)))
+	ret = int32(cRet)
+
+	if ret == -1 {
+		err := LastError()
+		dev.log.Printf("an error occurred: %v\n", err)
+		return nil, err
+	}
+
+	caps := make([]string, ret)
+	for i := range caps {
+		caps[i] = C.GoString(cCaps[i])
+		C.free(unsafe.Pointer(cCaps[i]))
+	}
+
+	dev.log.Printf("capabilities: %v\n", caps)
+
+	return caps, nil
+}
@@ -0,0 +1,71 @@
+package libvirt
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNodeDeviceInit(t *testing.T) {
+	env := newTestEnvironment(t)
+	defer env.cleanUp()
+
+	devices, err := env.conn.ListNodeDevices(NodeDevListPCIDev)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(devices) == 0 {
+		t.Skip("the host doesn't have any PCI device")
+	}
+
+	for _, d := range devices {
+		defer d.Free()
+	}
+
+	dev := devices[0]
+
+	name, err := dev.Name()
+	if err != nil {
+		t.Error(err)
+	}
+
+	if len(name) == 0 {
+		t.Error("empty node device name")
+	}
+
+	if err = dev.Ref(); err != nil {
+		t.Error(err)
+	} else if err = dev.Free(); err != nil {
+		t.Error(err)
+	}
+
+	if _, err = dev.Parent(); err != nil {
+		t.Error(err)
+	}
+
+	xml, err := dev.XML()
+	if err != nil {
+		t.Error(err)
+	}
+
+	if !strings.Contains(xml, "<capability type='pci'>") {
+		t.Errorf("the node device listed as a PCI device doesn't have the PCI capability; got=%v", xml)
+	}
+
+	caps, err := dev.ListCaps()
+	if err != nil {
+		t.Error(err)
+	}
+
+	var found bool
+	for _, c := range caps {
+		if c == "pci" {
+			found = true
+			break
+		}
+	}
+
+	if !found {
+		t.Errorf("the capability \"pci\" was not listed; got=%v", caps)
+	}
+}
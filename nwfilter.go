@@ -0,0 +1,105 @@
+package libvirt
+
+// #include <stdlib.h>
+// #include <libvirt/libvirt.h>
+import "C"
+import (
+	"log"
+	"runtime"
+	"unicode/utf8"
+	"unsafe"
+)
+
+// NWFilter holds a libvirt network filter. There are no exported fields.
+type NWFilter struct {
+	log         *log.Logger
+	virNWFilter C.virNWFilterPtr
+}
+
+// Free frees the network filter object. The filter itself is unaltered. The
+// data structure is freed and should not be used thereafter.
+func (filter NWFilter) Free() error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	filter.log.Println("freeing network filter object...")
+	cRet := C.virNWFilterFree(filter.virNWFilter)
+	ret := int32(cRet)
+
+	if ret == -1 {
+		err := LastError()
+		filter.log.Printf("an error occurred: %v\n", err)
+		return err
+	}
+
+	filter.log.Println("network filter freed")
+
+	return nil
+}
+
+// Name gets the public name for that network filter.
+func (filter NWFilter) Name() (string, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	filter.log.Println("reading network filter name...")
+	cName := C.virNWFilterGetName(filter.virNWFilter)
+
+	if cName == nil {
+		err := LastError()
+		filter.log.Printf("an error occurred: %v\n", err)
+		return "", err
+	}
+
+	name := C.GoString(cName)
+	filter.log.Printf("name: %v\n", name)
+
+	return name, nil
+}
+
+// UUID gets the UUID for a network filter as string.
+func (filter NWFilter) UUID() (string, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	cUUID := (*C.char)(C.malloc(C.size_t(C.VIR_UUID_STRING_BUFLEN)))
+	defer C.free(unsafe.Pointer(cUUID))
+
+	filter.log.Println("reading network filter UUID...")
+	cRet := C.virNWFilterGetUUIDString(filter.virNWFilter, cUUID)
+	ret := int32(cRet)
+
+	if ret == -1 {
+		err := LastError()
+		filter.log.Printf("an error occurred: %v\n", err)
+		return "", err
+	}
+
+	uuid := C.GoString(cUUID)
+	filter.log.Printf("UUID: %v\n", uuid)
+
+	return uuid, nil
+}
+
+// XML provides an XML description of the network filter. The description may
+// be reused later to redefine the network filter.
+func (filter NWFilter) XML() (string, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	filter.log.Println("reading network filter XML...")
+	cXML := C.virNWFilterGetXMLDesc(filter.virNWFilter, 0)
+
+	if cXML == nil {
+		err := LastError()
+		filter.log.Printf("an error occurred: %v\n", err)
+		return "", err
+	}
+	defer C.free(unsafe.Pointer(cXML))
+
+	xml := C.GoString(cXML)
+
+	filter.log.Printf("XML length: %v runes\n", utf8.RuneCountInString(xml))
+
+	return xml, nil
+}
@@ -0,0 +1,59 @@
+package libvirt
+
+import (
+	"testing"
+)
+
+func TestNWFilterInit(t *testing.T) {
+	env := newTestEnvironment(t)
+	defer env.cleanUp()
+
+	filters, err := env.conn.ListNWFilters()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(filters) == 0 {
+		t.Skip("the driver doesn't provide any network filter")
+	}
+
+	for _, f := range filters {
+		defer f.Free()
+	}
+
+	filter := filters[0]
+
+	name, err := filter.Name()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	uuid, err := filter.UUID()
+	if err != nil {
+		t.Error(err)
+	}
+
+	xml, err := filter.XML()
+	if err != nil {
+		t.Error(err)
+	}
+
+	if len(xml) == 0 {
+		t.Error("empty network filter XML")
+	}
+
+	found, err := env.conn.LookupNWFilterByName(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer found.Free()
+
+	foundUUID, err := found.UUID()
+	if err != nil {
+		t.Error(err)
+	}
+
+	if foundUUID != uuid {
+		t.Errorf("wrong network filter UUID after looking it up by name; got=%v, want=%v", foundUUID, uuid)
+	}
+}
@@ -0,0 +1,1095 @@
+package libvirt
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+)
+
+// DialOptions defines how a ReconnectingConnection opens the underlying
+// connection.
+type DialOptions struct {
+	// URI is the connection URI; DefaultURI may be used.
+	URI string
+	// ReadOnly opens a read-only connection instead of a read-write one.
+	ReadOnly bool
+	// RetryInterval is how long to wait before retrying to open the
+	// connection after a failure.
+	RetryInterval time.Duration
+	// MaxRetries is how many times opening the connection is retried after a
+	// failure before giving up. If zero, it's never retried.
+	MaxRetries int
+	// LogOutput receives the log messages of the underlying connections. If
+	// nil, the messages are discarded.
+	LogOutput io.Writer
+}
+
+// ReconnectingConnection holds a libvirt connection which is opened again
+// automatically after it's lost, e.g. when the daemon is restarted. It has
+// the same methods as Connection, which are called on the current connection
+// through Do, except for the ones changing the state of the connection itself
+// (e.g. Ref, SetKeepAlive, RegisterCloseCallback and the event callbacks),
+// which would be lost after reconnecting. The libvirt objects (e.g. Domain,
+// StoragePool) obtained from a previous connection can't be used after
+// reconnecting either; use OnReconnect to look them up again.
+type ReconnectingConnection struct {
+	opts DialOptions
+
+	mu          sync.RWMutex
+	conn        Connection
+	broken      bool // whether the connection's close callback has been invoked
+	closed      bool
+	onReconnect func(Connection)
+}
+
+// Dial opens a new ReconnectingConnection, retrying according to "opts". If
+// an event loop implementation has been registered, the connection is also
+// opened again when libvirt notifies that it has been closed.
+func Dial(opts DialOptions) (*ReconnectingConnection, error) {
+	if opts.LogOutput == nil {
+		opts.LogOutput = ioutil.Discard
+	}
+
+	rc := &ReconnectingConnection{
+		opts: opts,
+	}
+
+	conn, err := rc.open()
+	if err != nil {
+		return nil, err
+	}
+
+	rc.conn = conn
+
+	return rc, nil
+}
+
+// open opens a new connection, retrying if it fails.
+func (rc *ReconnectingConnection) open() (Connection, error) {
+	mode := ReadWrite
+	if rc.opts.ReadOnly {
+		mode = ReadOnly
+	}
+
+	var conn Connection
+	var err error
+
+	for retry := 0; ; retry++ {
+		if conn, err = Open(rc.opts.URI, mode, rc.opts.LogOutput); err == nil {
+			break
+		}
+
+		if retry >= rc.opts.MaxRetries {
+			return Connection{}, err
+		}
+
+		time.Sleep(rc.opts.RetryInterval)
+	}
+
+	if conn.eventLoop {
+		state := conn.state
+		cb := func(reason ConnectionCloseReason) {
+			if reason == ConnCloseReasonClient {
+				return
+			}
+
+			rc.mu.Lock()
+			if rc.conn.state == state {
+				rc.broken = true
+			}
+			rc.mu.Unlock()
+		}
+
+		if err = conn.RegisterCloseCallback(cb); err != nil {
+			conn.Close()
+			return Connection{}, err
+		}
+	}
+
+	return conn, nil
+}
+
+// Connection returns the current underlying connection. It may become invalid
+// at any time; the forwarded methods or Do should be used instead.
+func (rc *ReconnectingConnection) Connection() Connection {
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+
+	return rc.conn
+}
+
+// OnReconnect sets a function to be called with the new connection every time
+// it's opened again.
+func (rc *ReconnectingConnection) OnReconnect(fn func(Connection)) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	rc.onReconnect = fn
+}
+
+// Do calls "fn" with the current connection. If "fn" fails because the
+// connection has been lost, the connection is opened again and "fn" is called
+// once more with the new connection.
+func (rc *ReconnectingConnection) Do(fn func(conn Connection) error) error {
+	rc.mu.RLock()
+	conn, broken, closed := rc.conn, rc.broken, rc.closed
+	rc.mu.RUnlock()
+
+	if closed {
+		return ErrInvalidConnection
+	}
+
+	if !broken {
+		err := fn(conn)
+		if !isConnectionError(err) {
+			return err
+		}
+	}
+
+	conn, err := rc.reconnect(conn)
+	if err != nil {
+		return err
+	}
+
+	return fn(conn)
+}
+
+// Reconnect closes the current connection and opens it again.
+func (rc *ReconnectingConnection) Reconnect() error {
+	_, err := rc.reconnect(rc.Connection())
+
+	return err
+}
+
+// reconnect opens the connection again, unless it has already been opened
+// again since "stale" was obtained.
+func (rc *ReconnectingConnection) reconnect(stale Connection) (Connection, error) {
+	rc.mu.RLock()
+	conn, closed := rc.conn, rc.closed
+	rc.mu.RUnlock()
+
+	if closed {
+		return Connection{}, ErrInvalidConnection
+	}
+
+	if conn.state != stale.state {
+		return conn, nil
+	}
+
+	// opening the connection may wait between retries, so the lock isn't held
+	// meanwhile; another goroutine may reconnect at the same time, in which
+	// case only the first new connection is kept
+	newConn, err := rc.open()
+	if err != nil {
+		return Connection{}, err
+	}
+
+	rc.mu.Lock()
+
+	if rc.closed || rc.conn.state != stale.state {
+		conn, closed = rc.conn, rc.closed
+		rc.mu.Unlock()
+
+		newConn.Close()
+
+		if closed {
+			return Connection{}, ErrInvalidConnection
+		}
+
+		return conn, nil
+	}
+
+	rc.conn = newConn
+	rc.broken = false
+	onReconnect := rc.onReconnect
+
+	rc.mu.Unlock()
+
+	stale.Close()
+
+	if onReconnect != nil {
+		onReconnect(newConn)
+	}
+
+	return newConn, nil
+}
+
+// Close closes the underlying connection. The connection isn't opened again
+// afterwards.
+func (rc *ReconnectingConnection) Close() error {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	if rc.closed {
+		return ErrInvalidConnection
+	}
+
+	rc.closed = true
+
+	if err := rc.conn.valid(); err != nil {
+		return nil
+	}
+
+	_, err := rc.conn.Close()
+
+	return err
+}
+
+// isConnectionError tells whether "err" means that the connection has been
+// lost.
+func isConnectionError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if err == ErrInvalidConnection {
+		return true
+	}
+
+	virErr, ok := err.(*Error)
+	if !ok || virErr == nil {
+		return false
+	}
+
+	switch virErr.Code {
+	case ErrNoConnect, ErrInvalidConn:
+		return true
+	case ErrInternal, ErrSystem:
+		return virErr.Domain == ErrDomRPC
+	default:
+		return false
+	}
+}
+
+// Version forwards to Connection.Version.
+func (rc *ReconnectingConnection) Version() (uint64, error) {
+	var ret uint64
+	err := rc.Do(func(conn Connection) error {
+		var err error
+		ret, err = conn.Version()
+		return err
+	})
+
+	return ret, err
+}
+
+// LibVersion forwards to Connection.LibVersion.
+func (rc *ReconnectingConnection) LibVersion() (uint64, error) {
+	var ret uint64
+	err := rc.Do(func(conn Connection) error {
+		var err error
+		ret, err = conn.LibVersion()
+		return err
+	})
+
+	return ret, err
+}
+
+// VersionInfo forwards to Connection.VersionInfo.
+func (rc *ReconnectingConnection) VersionInfo() (VersionNumber, error) {
+	var ret VersionNumber
+	err := rc.Do(func(conn Connection) error {
+		var err error
+		ret, err = conn.VersionInfo()
+		return err
+	})
+
+	return ret, err
+}
+
+// LibVersionInfo forwards to Connection.LibVersionInfo.
+func (rc *ReconnectingConnection) LibVersionInfo() (VersionNumber, error) {
+	var ret VersionNumber
+	err := rc.Do(func(conn Connection) error {
+		var err error
+		ret, err = conn.LibVersionInfo()
+		return err
+	})
+
+	return ret, err
+}
+
+// IsAlive forwards to Connection.IsAlive.
+func (rc *ReconnectingConnection) IsAlive() (bool, error) {
+	var ret bool
+	err := rc.Do(func(conn Connection) error {
+		var err error
+		ret, err = conn.IsAlive()
+		return err
+	})
+
+	return ret, err
+}
+
+// IsEncrypted forwards to Connection.IsEncrypted.
+func (rc *ReconnectingConnection) IsEncrypted() (bool, error) {
+	var ret bool
+	err := rc.Do(func(conn Connection) error {
+		var err error
+		ret, err = conn.IsEncrypted()
+		return err
+	})
+
+	return ret, err
+}
+
+// IsSecure forwards to Connection.IsSecure.
+func (rc *ReconnectingConnection) IsSecure() (bool, error) {
+	var ret bool
+	err := rc.Do(func(conn Connection) error {
+		var err error
+		ret, err = conn.IsSecure()
+		return err
+	})
+
+	return ret, err
+}
+
+// Capabilities forwards to Connection.Capabilities.
+func (rc *ReconnectingConnection) Capabilities() (string, error) {
+	var ret string
+	err := rc.Do(func(conn Connection) error {
+		var err error
+		ret, err = conn.Capabilities()
+		return err
+	})
+
+	return ret, err
+}
+
+// DomainCapabilities forwards to Connection.DomainCapabilities.
+func (rc *ReconnectingConnection) DomainCapabilities(emulatorBin string, arch string, machine string, virtType string, flags uint32) (string, error) {
+	var ret string
+	err := rc.Do(func(conn Connection) error {
+		var err error
+		ret, err = conn.DomainCapabilities(emulatorBin, arch, machine, virtType, flags)
+		return err
+	})
+
+	return ret, err
+}
+
+// StoragePoolCapabilities forwards to Connection.StoragePoolCapabilities.
+func (rc *ReconnectingConnection) StoragePoolCapabilities(flags uint32) (string, error) {
+	var ret string
+	err := rc.Do(func(conn Connection) error {
+		var err error
+		ret, err = conn.StoragePoolCapabilities(flags)
+		return err
+	})
+
+	return ret, err
+}
+
+// Hostname forwards to Connection.Hostname.
+func (rc *ReconnectingConnection) Hostname() (string, error) {
+	var ret string
+	err := rc.Do(func(conn Connection) error {
+		var err error
+		ret, err = conn.Hostname()
+		return err
+	})
+
+	return ret, err
+}
+
+// Sysinfo forwards to Connection.Sysinfo.
+func (rc *ReconnectingConnection) Sysinfo() (string, error) {
+	var ret string
+	err := rc.Do(func(conn Connection) error {
+		var err error
+		ret, err = conn.Sysinfo()
+		return err
+	})
+
+	return ret, err
+}
+
+// Type forwards to Connection.Type.
+func (rc *ReconnectingConnection) Type() (string, error) {
+	var ret string
+	err := rc.Do(func(conn Connection) error {
+		var err error
+		ret, err = conn.Type()
+		return err
+	})
+
+	return ret, err
+}
+
+// URI forwards to Connection.URI.
+func (rc *ReconnectingConnection) URI() (string, error) {
+	var ret string
+	err := rc.Do(func(conn Connection) error {
+		var err error
+		ret, err = conn.URI()
+		return err
+	})
+
+	return ret, err
+}
+
+// NodeInfo forwards to Connection.NodeInfo.
+func (rc *ReconnectingConnection) NodeInfo() (NodeInfo, error) {
+	var ret NodeInfo
+	err := rc.Do(func(conn Connection) error {
+		var err error
+		ret, err = conn.NodeInfo()
+		return err
+	})
+
+	return ret, err
+}
+
+// FreeMemory forwards to Connection.FreeMemory.
+func (rc *ReconnectingConnection) FreeMemory() (uint64, error) {
+	var ret uint64
+	err := rc.Do(func(conn Connection) error {
+		var err error
+		ret, err = conn.FreeMemory()
+		return err
+	})
+
+	return ret, err
+}
+
+// CellsFreeMemory forwards to Connection.CellsFreeMemory.
+func (rc *ReconnectingConnection) CellsFreeMemory(startCell int, maxCells int) ([]uint64, error) {
+	var ret []uint64
+	err := rc.Do(func(conn Connection) error {
+		var err error
+		ret, err = conn.CellsFreeMemory(startCell, maxCells)
+		return err
+	})
+
+	return ret, err
+}
+
+// CPUStats forwards to Connection.CPUStats.
+func (rc *ReconnectingConnection) CPUStats(cpuNum int) (NodeCPUStats, error) {
+	var ret NodeCPUStats
+	err := rc.Do(func(conn Connection) error {
+		var err error
+		ret, err = conn.CPUStats(cpuNum)
+		return err
+	})
+
+	return ret, err
+}
+
+// MemoryStats forwards to Connection.MemoryStats.
+func (rc *ReconnectingConnection) MemoryStats(cellNum int) (NodeMemoryStats, error) {
+	var ret NodeMemoryStats
+	err := rc.Do(func(conn Connection) error {
+		var err error
+		ret, err = conn.MemoryStats(cellNum)
+		return err
+	})
+
+	return ret, err
+}
+
+// CPUMap forwards to Connection.CPUMap.
+func (rc *ReconnectingConnection) CPUMap() (online uint, cpus []bool, err error) {
+	err = rc.Do(func(conn Connection) error {
+		var err error
+		online, cpus, err = conn.CPUMap()
+		return err
+	})
+
+	return online, cpus, err
+}
+
+// FreePages forwards to Connection.FreePages.
+func (rc *ReconnectingConnection) FreePages(pageSizes []uint64, startCell int, cellCount int) (map[int]map[uint64]uint64, error) {
+	var ret map[int]map[uint64]uint64
+	err := rc.Do(func(conn Connection) error {
+		var err error
+		ret, err = conn.FreePages(pageSizes, startCell, cellCount)
+		return err
+	})
+
+	return ret, err
+}
+
+// AllocPages forwards to Connection.AllocPages.
+func (rc *ReconnectingConnection) AllocPages(pageCounts map[uint64]uint64, startCell int, cellCount int, flags NodeAllocPagesFlag) (int, error) {
+	var ret int
+	err := rc.Do(func(conn Connection) error {
+		var err error
+		ret, err = conn.AllocPages(pageCounts, startCell, cellCount, flags)
+		return err
+	})
+
+	return ret, err
+}
+
+// NodeMemoryParameters forwards to Connection.NodeMemoryParameters.
+func (rc *ReconnectingConnection) NodeMemoryParameters() (TypedParams, error) {
+	var ret TypedParams
+	err := rc.Do(func(conn Connection) error {
+		var err error
+		ret, err = conn.NodeMemoryParameters()
+		return err
+	})
+
+	return ret, err
+}
+
+// SetNodeMemoryParameters forwards to Connection.SetNodeMemoryParameters.
+func (rc *ReconnectingConnection) SetNodeMemoryParameters(params TypedParams, flags uint32) error {
+	return rc.Do(func(conn Connection) error {
+		return conn.SetNodeMemoryParameters(params, flags)
+	})
+}
+
+// SecurityModel forwards to Connection.SecurityModel.
+func (rc *ReconnectingConnection) SecurityModel() (SecurityModel, error) {
+	var ret SecurityModel
+	err := rc.Do(func(conn Connection) error {
+		var err error
+		ret, err = conn.SecurityModel()
+		return err
+	})
+
+	return ret, err
+}
+
+// CPUModelNames forwards to Connection.CPUModelNames.
+func (rc *ReconnectingConnection) CPUModelNames(arch string) ([]string, error) {
+	var ret []string
+	err := rc.Do(func(conn Connection) error {
+		var err error
+		ret, err = conn.CPUModelNames(arch)
+		return err
+	})
+
+	return ret, err
+}
+
+// CompareCPU forwards to Connection.CompareCPU.
+func (rc *ReconnectingConnection) CompareCPU(xml string, flags CompareCPUFlag) (CPUCompareResult, error) {
+	var ret CPUCompareResult
+	err := rc.Do(func(conn Connection) error {
+		var err error
+		ret, err = conn.CompareCPU(xml, flags)
+		return err
+	})
+
+	return ret, err
+}
+
+// BaselineCPU forwards to Connection.BaselineCPU.
+func (rc *ReconnectingConnection) BaselineCPU(xmls []string, flags BaselineCPUFlag) (string, error) {
+	var ret string
+	err := rc.Do(func(conn Connection) error {
+		var err error
+		ret, err = conn.BaselineCPU(xmls, flags)
+		return err
+	})
+
+	return ret, err
+}
+
+// BaselineHypervisorCPU forwards to Connection.BaselineHypervisorCPU.
+func (rc *ReconnectingConnection) BaselineHypervisorCPU(emulator string, arch string, machine string, virtType string, xmls []string, flags BaselineCPUFlag) (string, error) {
+	var ret string
+	err := rc.Do(func(conn Connection) error {
+		var err error
+		ret, err = conn.BaselineHypervisorCPU(emulator, arch, machine, virtType, xmls, flags)
+		return err
+	})
+
+	return ret, err
+}
+
+// MaxVCPUs forwards to Connection.MaxVCPUs.
+func (rc *ReconnectingConnection) MaxVCPUs(typ string) (int32, error) {
+	var ret int32
+	err := rc.Do(func(conn Connection) error {
+		var err error
+		ret, err = conn.MaxVCPUs(typ)
+		return err
+	})
+
+	return ret, err
+}
+
+// DomainXMLFromNative forwards to Connection.DomainXMLFromNative.
+func (rc *ReconnectingConnection) DomainXMLFromNative(format string, config string, flags uint32) (string, error) {
+	var ret string
+	err := rc.Do(func(conn Connection) error {
+		var err error
+		ret, err = conn.DomainXMLFromNative(format, config, flags)
+		return err
+	})
+
+	return ret, err
+}
+
+// DomainXMLToNative forwards to Connection.DomainXMLToNative.
+func (rc *ReconnectingConnection) DomainXMLToNative(format string, xml string, flags uint32) (string, error) {
+	var ret string
+	err := rc.Do(func(conn Connection) error {
+		var err error
+		ret, err = conn.DomainXMLToNative(format, xml, flags)
+		return err
+	})
+
+	return ret, err
+}
+
+// NumOfDomains forwards to Connection.NumOfDomains.
+func (rc *ReconnectingConnection) NumOfDomains() (int, error) {
+	var ret int
+	err := rc.Do(func(conn Connection) error {
+		var err error
+		ret, err = conn.NumOfDomains()
+		return err
+	})
+
+	return ret, err
+}
+
+// NumOfDefinedDomains forwards to Connection.NumOfDefinedDomains.
+func (rc *ReconnectingConnection) NumOfDefinedDomains() (int, error) {
+	var ret int
+	err := rc.Do(func(conn Connection) error {
+		var err error
+		ret, err = conn.NumOfDefinedDomains()
+		return err
+	})
+
+	return ret, err
+}
+
+// ListDomains forwards to Connection.ListDomains.
+func (rc *ReconnectingConnection) ListDomains(flags DomainListFlag) ([]Domain, error) {
+	var ret []Domain
+	err := rc.Do(func(conn Connection) error {
+		var err error
+		ret, err = conn.ListDomains(flags)
+		return err
+	})
+
+	return ret, err
+}
+
+// CreateDomain forwards to Connection.CreateDomain.
+func (rc *ReconnectingConnection) CreateDomain(xml string, flags DomainCreateFlag) (Domain, error) {
+	var ret Domain
+	err := rc.Do(func(conn Connection) error {
+		var err error
+		ret, err = conn.CreateDomain(xml, flags)
+		return err
+	})
+
+	return ret, err
+}
+
+// CreateDomainWithFiles forwards to Connection.CreateDomainWithFiles.
+func (rc *ReconnectingConnection) CreateDomainWithFiles(xml string, files []*os.File, flags DomainCreateFlag) (Domain, error) {
+	var ret Domain
+	err := rc.Do(func(conn Connection) error {
+		var err error
+		ret, err = conn.CreateDomainWithFiles(xml, files, flags)
+		return err
+	})
+
+	return ret, err
+}
+
+// DefineDomain forwards to Connection.DefineDomain.
+func (rc *ReconnectingConnection) DefineDomain(xml string) (Domain, error) {
+	var ret Domain
+	err := rc.Do(func(conn Connection) error {
+		var err error
+		ret, err = conn.DefineDomain(xml)
+		return err
+	})
+
+	return ret, err
+}
+
+// DefineDomainFlags forwards to Connection.DefineDomainFlags.
+func (rc *ReconnectingConnection) DefineDomainFlags(xml string, flags DomainDefineFlag) (Domain, error) {
+	var ret Domain
+	err := rc.Do(func(conn Connection) error {
+		var err error
+		ret, err = conn.DefineDomainFlags(xml, flags)
+		return err
+	})
+
+	return ret, err
+}
+
+// LookupDomainByID forwards to Connection.LookupDomainByID.
+func (rc *ReconnectingConnection) LookupDomainByID(id uint32) (Domain, error) {
+	var ret Domain
+	err := rc.Do(func(conn Connection) error {
+		var err error
+		ret, err = conn.LookupDomainByID(id)
+		return err
+	})
+
+	return ret, err
+}
+
+// LookupDomainByName forwards to Connection.LookupDomainByName.
+func (rc *ReconnectingConnection) LookupDomainByName(name string) (Domain, error) {
+	var ret Domain
+	err := rc.Do(func(conn Connection) error {
+		var err error
+		ret, err = conn.LookupDomainByName(name)
+		return err
+	})
+
+	return ret, err
+}
+
+// LookupDomainByUUIDString forwards to Connection.LookupDomainByUUIDString.
+func (rc *ReconnectingConnection) LookupDomainByUUIDString(uuid string) (Domain, error) {
+	var ret Domain
+	err := rc.Do(func(conn Connection) error {
+		var err error
+		ret, err = conn.LookupDomainByUUIDString(uuid)
+		return err
+	})
+
+	return ret, err
+}
+
+// LookupDomainByUUID forwards to Connection.LookupDomainByUUID.
+func (rc *ReconnectingConnection) LookupDomainByUUID(uuid [16]byte) (Domain, error) {
+	var ret Domain
+	err := rc.Do(func(conn Connection) error {
+		var err error
+		ret, err = conn.LookupDomainByUUID(uuid)
+		return err
+	})
+
+	return ret, err
+}
+
+// RestoreDomain forwards to Connection.RestoreDomain.
+func (rc *ReconnectingConnection) RestoreDomain(from string) error {
+	return rc.Do(func(conn Connection) error {
+		return conn.RestoreDomain(from)
+	})
+}
+
+// RestoreDomainFlags forwards to Connection.RestoreDomainFlags.
+func (rc *ReconnectingConnection) RestoreDomainFlags(from string, xml string, flags DomainSaveFlag) error {
+	return rc.Do(func(conn Connection) error {
+		return conn.RestoreDomainFlags(from, xml, flags)
+	})
+}
+
+// SaveImageXML forwards to Connection.SaveImageXML.
+func (rc *ReconnectingConnection) SaveImageXML(file string, flags DomainXMLFlag) (string, error) {
+	var ret string
+	err := rc.Do(func(conn Connection) error {
+		var err error
+		ret, err = conn.SaveImageXML(file, flags)
+		return err
+	})
+
+	return ret, err
+}
+
+// DefineSaveImageXML forwards to Connection.DefineSaveImageXML.
+func (rc *ReconnectingConnection) DefineSaveImageXML(file string, xml string, flags DomainSaveFlag) error {
+	return rc.Do(func(conn Connection) error {
+		return conn.DefineSaveImageXML(file, xml, flags)
+	})
+}
+
+// ListSecrets forwards to Connection.ListSecrets.
+func (rc *ReconnectingConnection) ListSecrets(flags SecretListFlag) ([]Secret, error) {
+	var ret []Secret
+	err := rc.Do(func(conn Connection) error {
+		var err error
+		ret, err = conn.ListSecrets(flags)
+		return err
+	})
+
+	return ret, err
+}
+
+// DefineSecret forwards to Connection.DefineSecret.
+func (rc *ReconnectingConnection) DefineSecret(xml string) (Secret, error) {
+	var ret Secret
+	err := rc.Do(func(conn Connection) error {
+		var err error
+		ret, err = conn.DefineSecret(xml)
+		return err
+	})
+
+	return ret, err
+}
+
+// LookupSecretByUUID forwards to Connection.LookupSecretByUUID.
+func (rc *ReconnectingConnection) LookupSecretByUUID(uuid string) (Secret, error) {
+	var ret Secret
+	err := rc.Do(func(conn Connection) error {
+		var err error
+		ret, err = conn.LookupSecretByUUID(uuid)
+		return err
+	})
+
+	return ret, err
+}
+
+// LookupSecretByUsage forwards to Connection.LookupSecretByUsage.
+func (rc *ReconnectingConnection) LookupSecretByUsage(usageType SecretUsageType, usageID string) (Secret, error) {
+	var ret Secret
+	err := rc.Do(func(conn Connection) error {
+		var err error
+		ret, err = conn.LookupSecretByUsage(usageType, usageID)
+		return err
+	})
+
+	return ret, err
+}
+
+// FindStoragePoolSources forwards to Connection.FindStoragePoolSources.
+func (rc *ReconnectingConnection) FindStoragePoolSources(typ string, source string) (string, error) {
+	var ret string
+	err := rc.Do(func(conn Connection) error {
+		var err error
+		ret, err = conn.FindStoragePoolSources(typ, source)
+		return err
+	})
+
+	return ret, err
+}
+
+// NumOfStoragePools forwards to Connection.NumOfStoragePools.
+func (rc *ReconnectingConnection) NumOfStoragePools() (int, error) {
+	var ret int
+	err := rc.Do(func(conn Connection) error {
+		var err error
+		ret, err = conn.NumOfStoragePools()
+		return err
+	})
+
+	return ret, err
+}
+
+// NumOfDefinedStoragePools forwards to Connection.NumOfDefinedStoragePools.
+func (rc *ReconnectingConnection) NumOfDefinedStoragePools() (int, error) {
+	var ret int
+	err := rc.Do(func(conn Connection) error {
+		var err error
+		ret, err = conn.NumOfDefinedStoragePools()
+		return err
+	})
+
+	return ret, err
+}
+
+// ListStoragePools forwards to Connection.ListStoragePools.
+func (rc *ReconnectingConnection) ListStoragePools(flags StoragePoolListFlag) ([]StoragePool, error) {
+	var ret []StoragePool
+	err := rc.Do(func(conn Connection) error {
+		var err error
+		ret, err = conn.ListStoragePools(flags)
+		return err
+	})
+
+	return ret, err
+}
+
+// DefineStoragePool forwards to Connection.DefineStoragePool.
+func (rc *ReconnectingConnection) DefineStoragePool(xml string) (StoragePool, error) {
+	var ret StoragePool
+	err := rc.Do(func(conn Connection) error {
+		var err error
+		ret, err = conn.DefineStoragePool(xml)
+		return err
+	})
+
+	return ret, err
+}
+
+// CreateStoragePool forwards to Connection.CreateStoragePool.
+func (rc *ReconnectingConnection) CreateStoragePool(xml string) (StoragePool, error) {
+	var ret StoragePool
+	err := rc.Do(func(conn Connection) error {
+		var err error
+		ret, err = conn.CreateStoragePool(xml)
+		return err
+	})
+
+	return ret, err
+}
+
+// LookupStoragePoolByName forwards to Connection.LookupStoragePoolByName.
+func (rc *ReconnectingConnection) LookupStoragePoolByName(name string) (StoragePool, error) {
+	var ret StoragePool
+	err := rc.Do(func(conn Connection) error {
+		var err error
+		ret, err = conn.LookupStoragePoolByName(name)
+		return err
+	})
+
+	return ret, err
+}
+
+// LookupStoragePoolByUUID forwards to Connection.LookupStoragePoolByUUID.
+func (rc *ReconnectingConnection) LookupStoragePoolByUUID(uuid string) (StoragePool, error) {
+	var ret StoragePool
+	err := rc.Do(func(conn Connection) error {
+		var err error
+		ret, err = conn.LookupStoragePoolByUUID(uuid)
+		return err
+	})
+
+	return ret, err
+}
+
+// LookupStorageVolumeByPath forwards to Connection.LookupStorageVolumeByPath.
+func (rc *ReconnectingConnection) LookupStorageVolumeByPath(path string) (StorageVolume, error) {
+	var ret StorageVolume
+	err := rc.Do(func(conn Connection) error {
+		var err error
+		ret, err = conn.LookupStorageVolumeByPath(path)
+		return err
+	})
+
+	return ret, err
+}
+
+// LookupStorageVolumeByKey forwards to Connection.LookupStorageVolumeByKey.
+func (rc *ReconnectingConnection) LookupStorageVolumeByKey(key string) (StorageVolume, error) {
+	var ret StorageVolume
+	err := rc.Do(func(conn Connection) error {
+		var err error
+		ret, err = conn.LookupStorageVolumeByKey(key)
+		return err
+	})
+
+	return ret, err
+}
+
+// NewStream forwards to Connection.NewStream.
+func (rc *ReconnectingConnection) NewStream(flags StreamFlag) (Stream, error) {
+	var ret Stream
+	err := rc.Do(func(conn Connection) error {
+		var err error
+		ret, err = conn.NewStream(flags)
+		return err
+	})
+
+	return ret, err
+}
+
+// ListInterfaces forwards to Connection.ListInterfaces.
+func (rc *ReconnectingConnection) ListInterfaces(flags InterfaceListFlag) ([]Interface, error) {
+	var ret []Interface
+	err := rc.Do(func(conn Connection) error {
+		var err error
+		ret, err = conn.ListInterfaces(flags)
+		return err
+	})
+
+	return ret, err
+}
+
+// NumOfNetworks forwards to Connection.NumOfNetworks.
+func (rc *ReconnectingConnection) NumOfNetworks() (int, error) {
+	var ret int
+	err := rc.Do(func(conn Connection) error {
+		var err error
+		ret, err = conn.NumOfNetworks()
+		return err
+	})
+
+	return ret, err
+}
+
+// NumOfDefinedNetworks forwards to Connection.NumOfDefinedNetworks.
+func (rc *ReconnectingConnection) NumOfDefinedNetworks() (int, error) {
+	var ret int
+	err := rc.Do(func(conn Connection) error {
+		var err error
+		ret, err = conn.NumOfDefinedNetworks()
+		return err
+	})
+
+	return ret, err
+}
+
+// ListNetworks forwards to Connection.ListNetworks.
+func (rc *ReconnectingConnection) ListNetworks(flags NetworkListFlag) ([]Network, error) {
+	var ret []Network
+	err := rc.Do(func(conn Connection) error {
+		var err error
+		ret, err = conn.ListNetworks(flags)
+		return err
+	})
+
+	return ret, err
+}
+
+// DefineNetwork forwards to Connection.DefineNetwork.
+func (rc *ReconnectingConnection) DefineNetwork(xml string) (Network, error) {
+	var ret Network
+	err := rc.Do(func(conn Connection) error {
+		var err error
+		ret, err = conn.DefineNetwork(xml)
+		return err
+	})
+
+	return ret, err
+}
+
+// LookupNetworkByName forwards to Connection.LookupNetworkByName.
+func (rc *ReconnectingConnection) LookupNetworkByName(name string) (Network, error) {
+	var ret Network
+	err := rc.Do(func(conn Connection) error {
+		var err error
+		ret, err = conn.LookupNetworkByName(name)
+		return err
+	})
+
+	return ret, err
+}
+
+// ListNWFilters forwards to Connection.ListNWFilters.
+func (rc *ReconnectingConnection) ListNWFilters() ([]NWFilter, error) {
+	var ret []NWFilter
+	err := rc.Do(func(conn Connection) error {
+		var err error
+		ret, err = conn.ListNWFilters()
+		return err
+	})
+
+	return ret, err
+}
+
+// LookupNWFilterByName forwards to Connection.LookupNWFilterByName.
+func (rc *ReconnectingConnection) LookupNWFilterByName(name string) (NWFilter, error) {
+	var ret NWFilter
+	err := rc.Do(func(conn Connection) error {
+		var err error
+		ret, err = conn.LookupNWFilterByName(name)
+		return err
+	})
+
+	return ret, err
+}
+
+// ListNodeDevices forwards to Connection.ListNodeDevices.
+func (rc *ReconnectingConnection) ListNodeDevices(flags NodeDeviceListFlag) ([]NodeDevice, error) {
+	var ret []NodeDevice
+	err := rc.Do(func(conn Connection) error {
+		var err error
+		ret, err = conn.ListNodeDevices(flags)
+		return err
+	})
+
+	return ret, err
+}
@@ -0,0 +1,184 @@
+package libvirt
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cd1/utils-golang"
+)
+
+func TestReconnectingConnectionDial(t *testing.T) {
+	if _, err := Dial(DialOptions{URI: utils.RandomString(), MaxRetries: 2, RetryInterval: time.Millisecond}); err == nil {
+		t.Error("an error was not returned when dialing a bad URI")
+	}
+
+	rc, err := Dial(DialOptions{URI: testConnectionURI, LogOutput: testLogOutput})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = rc.Close(); err != nil {
+		t.Error(err)
+	}
+
+	if err = rc.Close(); err != ErrInvalidConnection {
+		t.Errorf("unexpected error when closing a connection twice; got=%v, want=%v", err, ErrInvalidConnection)
+	}
+
+	if err = rc.Do(func(conn Connection) error { return nil }); err != ErrInvalidConnection {
+		t.Errorf("unexpected error when using a closed connection; got=%v, want=%v", err, ErrInvalidConnection)
+	}
+}
+
+func TestReconnectingConnectionReconnect(t *testing.T) {
+	rc, err := Dial(DialOptions{URI: testConnectionURI, LogOutput: testLogOutput})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+
+	reconnected := make(chan Connection, 1)
+	rc.OnReconnect(func(conn Connection) {
+		reconnected <- conn
+	})
+
+	// simulate a lost connection
+	if _, err = rc.Connection().Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	calls := 0
+	err = rc.Do(func(conn Connection) error {
+		calls++
+		_, err := conn.Version()
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if calls != 2 {
+		t.Errorf("unexpected number of calls; got=%v, want=2", calls)
+	}
+
+	select {
+	case conn := <-reconnected:
+		if alive, err := conn.IsAlive(); err != nil || !alive {
+			t.Errorf("the new connection should be alive; got=%v (error %v)", alive, err)
+		}
+	default:
+		t.Error("the reconnect hook was not called")
+	}
+
+	// errors unrelated to the connection are not retried
+	calls = 0
+	errFoo := errors.New("foo")
+	if err = rc.Do(func(conn Connection) error {
+		calls++
+		return errFoo
+	}); err != errFoo {
+		t.Errorf("unexpected error; got=%v, want=%v", err, errFoo)
+	}
+
+	if calls != 1 {
+		t.Errorf("unexpected number of calls; got=%v, want=1", calls)
+	}
+
+	if err = rc.Reconnect(); err != nil {
+		t.Error(err)
+	}
+
+	select {
+	case <-reconnected:
+	default:
+		t.Error("the reconnect hook was not called after reconnecting explicitly")
+	}
+}
+
+func TestReconnectingConnectionForward(t *testing.T) {
+	rc, err := Dial(DialOptions{URI: testConnectionURI, LogOutput: testLogOutput})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+
+	reconnected := make(chan Connection, 1)
+	rc.OnReconnect(func(conn Connection) {
+		reconnected <- conn
+	})
+
+	// simulate a lost connection
+	if _, err = rc.Connection().Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = rc.Version(); err != nil {
+		t.Error(err)
+	}
+
+	select {
+	case <-reconnected:
+	default:
+		t.Error("the reconnect hook was not called when using a forwarded method")
+	}
+
+	if _, err = rc.LookupDomainByName(utils.RandomString()); !errors.Is(err, &Error{Code: ErrNoDomain}) {
+		t.Errorf("unexpected error when looking up a nonexistent domain; got=%v, want=%v", err, ErrNoDomain)
+	}
+
+	if err = rc.Close(); err != nil {
+		t.Error(err)
+	}
+
+	if _, err = rc.Hostname(); err != ErrInvalidConnection {
+		t.Errorf("unexpected error when using a closed connection; got=%v, want=%v", err, ErrInvalidConnection)
+	}
+}
+
+func TestReconnectingConnectionConcurrentReconnect(t *testing.T) {
+	rc, err := Dial(DialOptions{URI: testConnectionURI, LogOutput: testLogOutput})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+
+	var mu sync.Mutex
+	reconnects := 0
+	rc.OnReconnect(func(conn Connection) {
+		mu.Lock()
+		reconnects++
+		mu.Unlock()
+	})
+
+	// simulate a lost connection
+	if _, err = rc.Connection().Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+
+	for i := 0; i < cap(errs); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			if _, err := rc.Version(); err != nil {
+				errs <- err
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+
+	if reconnects != 1 {
+		t.Errorf("unexpected number of reconnections; got=%v, want=1", reconnects)
+	}
+}
@@ -5,6 +5,7 @@ package libvirt
 import "C"
 import (
 	"log"
+	"runtime"
 	"unicode/utf8"
 	"unsafe"
 )
@@ -40,6 +41,9 @@ type Secret struct {
 
 // Free releases the secret handle. The underlying secret continues to exist.
 func (sec Secret) Free() error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	sec.log.Println("freeing secret...")
 	cRet := C.virSecretFree(sec.virSecret)
 	ret := int(cRet)
@@ -58,6 +62,9 @@ func (sec Secret) Free() error {
 // Undefine deletes the specified secret. This does not free the associated
 // "Secret" object.
 func (sec Secret) Undefine() error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	sec.log.Println("undefining secret...")
 	cRet := C.virSecretUndefine(sec.virSecret)
 	ret := int(cRet)
@@ -75,6 +82,9 @@ func (sec Secret) Undefine() error {
 
 // UUID fetches the UUID of the secret.
 func (sec Secret) UUID() (string, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	cUUID := (*C.char)(C.malloc(C.size_t(C.VIR_UUID_STRING_BUFLEN)))
 	defer C.free(unsafe.Pointer(cUUID))
 
@@ -96,6 +106,9 @@ func (sec Secret) UUID() (string, error) {
 
 // XML fetches an XML document describing attributes of the secret.
 func (sec Secret) XML() (string, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	sec.log.Println("reading secret XML...")
 	cXML := C.virSecretGetXMLDesc(sec.virSecret, 0)
 
@@ -120,6 +133,9 @@ func (sec Secret) XML() (string, error) {
 // within the set of all secrets sharing the same usage type. ie, there shall
 // only ever be one secret for each volume path.
 func (sec Secret) UsageID() (string, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	sec.log.Println("reading secret usage ID...")
 	cUsageID := C.virSecretGetUsageID(sec.virSecret)
 
@@ -141,6 +157,9 @@ func (sec Secret) UsageID() (string, error) {
 // values may be added to this enumeration in the future, so callers should
 // expect to see usage types they do not explicitly know about.
 func (sec Secret) UsageType() (SecretUsageType, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	sec.log.Println("reading secret usage type...")
 	cUsageType := C.virSecretGetUsageType(sec.virSecret)
 
@@ -159,6 +178,9 @@ func (sec Secret) UsageType() (SecretUsageType, error) {
 
 // SetValue sets the value of a secret.
 func (sec Secret) SetValue(value string) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	cSize := C.size_t(len(value))
 	cValue := (*C.uchar)(unsafe.Pointer(C.CString(value)))
 
@@ -179,6 +201,9 @@ func (sec Secret) SetValue(value string) error {
 
 // Value fetches the value of a secret.
 func (sec Secret) Value() (string, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	var cSize C.size_t
 
 	sec.log.Println("reading secret value...")
@@ -206,6 +231,9 @@ func (sec Secret) Value() (string, error) {
 // all threads have finished using it. ie, each new thread using a secret would
 // increment the reference count.
 func (sec Secret) Ref() error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	sec.log.Println("incrementing secret's reference count...")
 	cRet := C.virSecretRef(sec.virSecret)
 	ret := int32(cRet)
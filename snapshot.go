@@ -6,6 +6,7 @@ import "C"
 import (
 	"log"
 	"reflect"
+	"runtime"
 	"unicode/utf8"
 	"unsafe"
 )
@@ -77,6 +78,9 @@ type Snapshot struct {
 // Free frees the domain snapshot object. The snapshot itself is not modified.
 // The data structure is freed and should not be used thereafter.
 func (snap Snapshot) Free() error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	snap.log.Println("freeing snapshot object...")
 	cRet := C.virDomainSnapshotFree(snap.virSnapshot)
 	ret := int32(cRet)
@@ -94,6 +98,9 @@ func (snap Snapshot) Free() error {
 
 // Delete deletes the snapshot.
 func (snap Snapshot) Delete(flags SnapshotDeleteFlag) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	snap.log.Printf("deleting snapshot (flags = %v)...\n", flags)
 	cRet := C.virDomainSnapshotDelete(snap.virSnapshot, C.uint(flags))
 	ret := int32(cRet)
@@ -111,6 +118,9 @@ func (snap Snapshot) Delete(flags SnapshotDeleteFlag) error {
 
 // Name gets the public name for that snapshot.
 func (snap Snapshot) Name() (string, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	snap.log.Println("reading snapshot name...")
 	cName := C.virDomainSnapshotGetName(snap.virSnapshot)
 
@@ -128,6 +138,9 @@ func (snap Snapshot) Name() (string, error) {
 
 // Parent gets the parent snapshot for "snap", if any.
 func (snap Snapshot) Parent() (Snapshot, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	snap.log.Println("reading snapshot parent...")
 	cParent := C.virDomainSnapshotGetParent(snap.virSnapshot, 0)
 	if cParent == nil {
@@ -148,6 +161,9 @@ func (snap Snapshot) Parent() (Snapshot, error) {
 
 // XML provides an XML description of the domain snapshot.
 func (snap Snapshot) XML(flags DomainXMLFlag) (string, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	snap.log.Printf("reading snapshot XML (flags = %v)...\n", flags)
 	cXML := C.virDomainSnapshotGetXMLDesc(snap.virSnapshot, C.uint(flags))
 	if cXML == nil {
@@ -166,6 +182,9 @@ func (snap Snapshot) XML(flags DomainXMLFlag) (string, error) {
 // HasMetadata determines if the given snapshot is associated with libvirt
 // metadata that would prevent the deletion of the domain.
 func (snap Snapshot) HasMetadata() (bool, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	snap.log.Println("checking whether snapshot has metadata...")
 	cRet := C.virDomainSnapshotHasMetadata(snap.virSnapshot, 0)
 	ret := int32(cRet)
@@ -190,6 +209,9 @@ func (snap Snapshot) HasMetadata() (bool, error) {
 // IsCurrent determines if the given snapshot is the domain's current snapshot.
 // See also "<Domain>.HasCurrentSnapshot".
 func (snap Snapshot) IsCurrent() (bool, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	snap.log.Println("checking whether snapshot is current...")
 	cRet := C.virDomainSnapshotIsCurrent(snap.virSnapshot, 0)
 	ret := int32(cRet)
@@ -215,6 +237,9 @@ func (snap Snapshot) IsCurrent() (bool, error) {
 // to this method, there shall be a corresponding call to "<Snapshot>.Free" to
 // release the reference count, once the caller no longer needs the reference to this object.
 func (snap Snapshot) Ref() error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	snap.log.Println("incrementing snapshot's reference count...")
 	cRet := C.virDomainSnapshotRef(snap.virSnapshot)
 	ret := int32(cRet)
@@ -244,6 +269,9 @@ func (snap Snapshot) Ref() error {
 // it is possible to select an impossible combination, in that case a hypervisor
 // may return either 0 or an error.
 func (snap Snapshot) ListChildren(flags SnapshotListFlag) ([]Snapshot, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	var cSnaps []C.virDomainSnapshotPtr
 	snapsSH := (*reflect.SliceHeader)(unsafe.Pointer(&cSnaps))
 
@@ -304,6 +332,9 @@ func (snap Snapshot) ListChildren(flags SnapshotListFlag) ([]Snapshot, error) {
 // inactive snapshots with a "flags" request to start the domain after
 // the revert.
 func (snap Snapshot) Revert(flags SnapshotRevertFlag) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	snap.log.Printf("reverting to snapshot (flags = %v)...\n", flags)
 	cRet := C.virDomainRevertToSnapshot(snap.virSnapshot, C.uint(flags))
 	ret := int32(cRet)
@@ -6,6 +6,7 @@ import "C"
 import (
 	"log"
 	"reflect"
+	"runtime"
 	"unicode/utf8"
 	"unsafe"
 )
@@ -34,6 +35,8 @@ const (
 	PoolListSheepdog    StoragePoolListFlag = C.VIR_CONNECT_LIST_STORAGE_POOLS_SHEEPDOG
 	PoolListGluster     StoragePoolListFlag = C.VIR_CONNECT_LIST_STORAGE_POOLS_GLUSTER
 	PoolListZFS         StoragePoolListFlag = C.VIR_CONNECT_LIST_STORAGE_POOLS_ZFS
+	PoolListVStorage    StoragePoolListFlag = C.VIR_CONNECT_LIST_STORAGE_POOLS_VSTORAGE
+	PoolListISCSIDirect StoragePoolListFlag = C.VIR_CONNECT_LIST_STORAGE_POOLS_ISCSI_DIRECT
 )
 
 // StoragePoolDeleteFlag defines how a storage pool should be deleted.
@@ -96,6 +99,9 @@ type StoragePool struct {
 // Free frees a storage pool object, releasing all memory associated with it.
 // Does not change the state of the pool on the host.
 func (pool StoragePool) Free() error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	pool.log.Println("freeing storage pool object...")
 	cRet := C.virStoragePoolFree(pool.virStoragePool)
 	ret := int32(cRet)
@@ -113,6 +119,9 @@ func (pool StoragePool) Free() error {
 
 // Undefine undefines an inactive storage pool.
 func (pool StoragePool) Undefine() error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	pool.log.Println("undefining storage pool...")
 	cRet := C.virStoragePoolUndefine(pool.virStoragePool)
 	ret := int32(cRet)
@@ -130,6 +139,9 @@ func (pool StoragePool) Undefine() error {
 
 // Create starts an inactive storage pool.
 func (pool StoragePool) Create() error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	pool.log.Println("creating storage pool...")
 	cRet := C.virStoragePoolCreate(pool.virStoragePool, 0)
 	ret := int32(cRet)
@@ -150,6 +162,9 @@ func (pool StoragePool) Create() error {
 // persistent config it can later be restarted with "Create". This does not free
 // the associated StoragePool object.
 func (pool StoragePool) Destroy() error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	pool.log.Println("destroying storage pool...")
 	cRet := C.virStoragePoolDestroy(pool.virStoragePool)
 	ret := int32(cRet)
@@ -168,6 +183,9 @@ func (pool StoragePool) Destroy() error {
 // Delete deletes the underlying pool resources. This is a non-recoverable
 // operation. The StoragePool object itself is not free'd.
 func (pool StoragePool) Delete(flags StoragePoolDeleteFlag) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	pool.log.Printf("deleting storage pool (flags = %v)...\n", flags)
 	cRet := C.virStoragePoolDelete(pool.virStoragePool, C.uint(flags))
 	ret := int32(cRet)
@@ -185,6 +203,9 @@ func (pool StoragePool) Delete(flags StoragePoolDeleteFlag) error {
 
 // IsActive determines if the storage pool is currently running.
 func (pool StoragePool) IsActive() (bool, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	pool.log.Println("checking whether storage pool is active...")
 	cRet := C.virStoragePoolIsActive(pool.virStoragePool)
 	ret := int32(cRet)
@@ -209,6 +230,9 @@ func (pool StoragePool) IsActive() (bool, error) {
 // IsPersistent determines if the storage pool has a persistent configuration
 // which means it will still exist after shutting down.
 func (pool StoragePool) IsPersistent() (bool, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	pool.log.Println("checking whether storage pool is persistent...")
 	cRet := C.virStoragePoolIsPersistent(pool.virStoragePool)
 	ret := int32(cRet)
@@ -232,6 +256,9 @@ func (pool StoragePool) IsPersistent() (bool, error) {
 
 // Name fetches the locally unique name of the storage pool.
 func (pool StoragePool) Name() (string, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	pool.log.Println("reading storage pool name...")
 	cName := C.virStoragePoolGetName(pool.virStoragePool)
 
@@ -249,6 +276,9 @@ func (pool StoragePool) Name() (string, error) {
 
 // UUID fetches the globally unique ID of the storage pool as a string.
 func (pool StoragePool) UUID() (string, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	cUUID := (*C.char)(C.malloc(C.size_t(C.VIR_UUID_STRING_BUFLEN)))
 	defer C.free(unsafe.Pointer(cUUID))
 
@@ -272,6 +302,9 @@ func (pool StoragePool) UUID() (string, error) {
 // is suitable for later feeding back into the
 // "<Connection>.CreateStoragePool" method.
 func (pool StoragePool) XML(flags StorageXMLFlag) (string, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	pool.log.Printf("reading storage pool XML (flags = %v)...\n", flags)
 	cXML := C.virStoragePoolGetXMLDesc(pool.virStoragePool, C.uint(flags))
 
@@ -290,6 +323,9 @@ func (pool StoragePool) XML(flags StorageXMLFlag) (string, error) {
 
 // InfoState extracts the storage pool state.
 func (pool StoragePool) InfoState() (StoragePoolState, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	var cInfo C.virStoragePoolInfo
 
 	pool.log.Println("reading storage pool state...")
@@ -310,6 +346,9 @@ func (pool StoragePool) InfoState() (StoragePoolState, error) {
 
 // InfoCapacity extracts the storage pool logical size (bytes).
 func (pool StoragePool) InfoCapacity() (uint64, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	var cInfo C.virStoragePoolInfo
 
 	pool.log.Println("reading storage pool capacity...")
@@ -330,6 +369,9 @@ func (pool StoragePool) InfoCapacity() (uint64, error) {
 
 // InfoAllocation extracts the storage pool current allocation (bytes).
 func (pool StoragePool) InfoAllocation() (uint64, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	var cInfo C.virStoragePoolInfo
 
 	pool.log.Println("reading storage pool allocation...")
@@ -350,6 +392,9 @@ func (pool StoragePool) InfoAllocation() (uint64, error) {
 
 // InfoAvailable extracts the storage pool remaining free space (bytes)
 func (pool StoragePool) InfoAvailable() (uint64, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	var cInfo C.virStoragePoolInfo
 
 	pool.log.Println("reading storage pool available space...")
@@ -371,6 +416,9 @@ func (pool StoragePool) InfoAvailable() (uint64, error) {
 // Autostart fetches the value of the autostart flag, which determines whether
 // the pool is automatically started at boot time.
 func (pool StoragePool) Autostart() (bool, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	var cAutostart C.int
 
 	pool.log.Println("checking whether storage pool autostarts...")
@@ -396,6 +444,9 @@ func (pool StoragePool) Autostart() (bool, error) {
 
 // SetAutostart sets the autostart flag.
 func (pool StoragePool) SetAutostart(autostart bool) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	var autostartInt int32
 	if autostart {
 		pool.log.Println("enabling storage pool autostart...")
@@ -425,6 +476,9 @@ func (pool StoragePool) SetAutostart(autostart bool) error {
 
 // Build builds the underlying storage pool.
 func (pool StoragePool) Build(flags StoragePoolBuildFlag) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	pool.log.Printf("building storage pool (flags = %v)...\n", flags)
 	cRet := C.virStoragePoolBuild(pool.virStoragePool, C.uint(flags))
 	ret := int32(cRet)
@@ -444,6 +498,9 @@ func (pool StoragePool) Build(flags StoragePoolBuildFlag) error {
 // communicating with a remote server, and/or initializing new devices at the
 // OS layer.
 func (pool StoragePool) Refresh() error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	pool.log.Println("refreshing storage pool...")
 	cRet := C.virStoragePoolRefresh(pool.virStoragePool, 0)
 	ret := int32(cRet)
@@ -468,6 +525,9 @@ func (pool StoragePool) Refresh() error {
 // all threads have finished using it. ie, each new thread using a pool would
 // increment the reference count.
 func (pool StoragePool) Ref() error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	pool.log.Println("incrementing storage pool's reference count...")
 	cRet := C.virStoragePoolRef(pool.virStoragePool)
 	ret := int32(cRet)
@@ -484,6 +544,9 @@ func (pool StoragePool) Ref() error {
 // ListStorageVolumes collects the list of storage volumes, and allocate an
 // array to store those objects.
 func (pool StoragePool) ListStorageVolumes() ([]StorageVolume, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	var cStorageVolumes []C.virStorageVolPtr
 	cStorageVolumesSH := (*reflect.SliceHeader)(unsafe.Pointer(&cStorageVolumes))
 
@@ -522,6 +585,9 @@ func (pool StoragePool) ListStorageVolumes() ([]StorageVolume, error) {
 // "Free" should be used to free the resources after the storage volume object
 // is no longer needed.
 func (pool StoragePool) CreateStorageVolume(xml string, flags StorageVolumeCreateFlag) (StorageVolume, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	cXML := C.CString(xml)
 	defer C.free(unsafe.Pointer(cXML))
 
@@ -553,6 +619,9 @@ func (pool StoragePool) CreateStorageVolume(xml string, flags StorageVolumeCreat
 // "Free" should be used to free the resources after the storage volume object
 // is no longer needed.
 func (pool StoragePool) CreateStorageVolumeFrom(xml string, cloneVol StorageVolume, flags StorageVolumeCreateFlag) (StorageVolume, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	cXML := C.CString(xml)
 	defer C.free(unsafe.Pointer(cXML))
 
@@ -580,6 +649,9 @@ func (pool StoragePool) CreateStorageVolumeFrom(xml string, cloneVol StorageVolu
 // "Free" should be used to free the resources after the storage volume object
 // is no longer needed.
 func (pool StoragePool) LookupStorageVolumeByName(name string) (StorageVolume, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	cName := C.CString(name)
 	defer C.free(unsafe.Pointer(cName))
 
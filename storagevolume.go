@@ -5,6 +5,7 @@ package libvirt
 import "C"
 import (
 	"log"
+	"runtime"
 	"unicode/utf8"
 	"unsafe"
 )
@@ -58,6 +59,9 @@ type StorageVolume struct {
 // Free releases the storage volume handle. The underlying storage volume
 // continues to exist.
 func (vol StorageVolume) Free() error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	vol.log.Println("freeing storage volume object...")
 	cRet := C.virStorageVolFree(vol.virStorageVol)
 	ret := int32(cRet)
@@ -75,6 +79,9 @@ func (vol StorageVolume) Free() error {
 
 // Delete deletes the storage volume from the pool.
 func (vol StorageVolume) Delete() error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	vol.log.Println("deleting storage volume...")
 	cRet := C.virStorageVolDelete(vol.virStorageVol, 0)
 	ret := int32(cRet)
@@ -93,6 +100,9 @@ func (vol StorageVolume) Delete() error {
 // Key fetches the storage volume key. This is globally unique, so the same
 // volume will have the same key no matter what host it is accessed from.
 func (vol StorageVolume) Key() (string, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	vol.log.Println("reading storage volume key...")
 	cKey := C.virStorageVolGetKey(vol.virStorageVol)
 
@@ -111,6 +121,9 @@ func (vol StorageVolume) Key() (string, error) {
 // Name fetches the storage volume name. This is unique within the scope of
 // a pool.
 func (vol StorageVolume) Name() (string, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	vol.log.Println("reading storage volume name...")
 	cName := C.virStorageVolGetName(vol.virStorageVol)
 
@@ -131,6 +144,9 @@ func (vol StorageVolume) Name() (string, error) {
 // startup. Consult pool documentation for information on getting the
 // persistent naming.
 func (vol StorageVolume) Path() (string, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	vol.log.Println("reading storage volume path...")
 	cPath := C.virStorageVolGetPath(vol.virStorageVol)
 
@@ -149,6 +165,9 @@ func (vol StorageVolume) Path() (string, error) {
 
 // XML fetches an XML document describing all aspects of the storage volume.
 func (vol StorageVolume) XML() (string, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	vol.log.Println("reading storage volume XML...")
 	cXML := C.virStorageVolGetXMLDesc(vol.virStorageVol, 0)
 
@@ -167,6 +186,9 @@ func (vol StorageVolume) XML() (string, error) {
 // InfoType fetches volatile information about the storage volume:
 // current type.
 func (vol StorageVolume) InfoType() (StorageVolumeType, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	var cInfo C.virStorageVolInfo
 
 	vol.log.Println("reading storage volume type...")
@@ -188,6 +210,9 @@ func (vol StorageVolume) InfoType() (StorageVolumeType, error) {
 // InfoCapacity fetches volatile information about the storage volume:
 // current capacity.
 func (vol StorageVolume) InfoCapacity() (uint64, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	var cInfo C.virStorageVolInfo
 
 	vol.log.Println("reading storage volume capacity...")
@@ -209,6 +234,9 @@ func (vol StorageVolume) InfoCapacity() (uint64, error) {
 // InfoAllocation fetches volatile information about the storage volume:
 // current allocation.
 func (vol StorageVolume) InfoAllocation() (uint64, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	var cInfo C.virStorageVolInfo
 
 	vol.log.Println("reading storage volume allocation...")
@@ -249,6 +277,9 @@ func (vol StorageVolume) InfoAllocation() (uint64, error) {
 // "capacity" represents the absolute new size regardless of whether it is
 // larger or smaller than the current size.
 func (vol StorageVolume) Resize(capacity uint64, flags StorageVolumeResizeFlag) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	vol.log.Printf("resizing storage volume to %v bytes (flags = %v)...\n", capacity, flags)
 	cRet := C.virStorageVolResize(vol.virStorageVol, C.ulonglong(capacity), C.uint(flags))
 	ret := int32(cRet)
@@ -266,6 +297,9 @@ func (vol StorageVolume) Resize(capacity uint64, flags StorageVolumeResizeFlag)
 
 // Wipe ensure data previously on a volume is not accessible to future reads.
 func (vol StorageVolume) Wipe(alg StorageVolumeWipeAlgorithm) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	vol.log.Printf("wiping storage volume with algorithm %v...\n", alg)
 	cRet := C.virStorageVolWipePattern(vol.virStorageVol, C.uint(alg), 0)
 	ret := int32(cRet)
@@ -290,6 +324,9 @@ func (vol StorageVolume) Wipe(alg StorageVolumeWipeAlgorithm) error {
 // all threads have finished using it. ie, each new thread using a vol would
 // increment the reference count.
 func (vol StorageVolume) Ref() error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	vol.log.Println("incrementing storage volume's reference count...")
 	cRet := C.virStorageVolRef(vol.virStorageVol)
 	ret := int32(cRet)
@@ -309,6 +346,9 @@ func (vol StorageVolume) Ref() error {
 // "Free" should be used to free the resources after the storage pool object is
 // no longer needed.
 func (vol StorageVolume) StoragePool() (StoragePool, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	vol.log.Println("looking up storage pool by storage volume...")
 	cPool := C.virStoragePoolLookupByVolume(vol.virStorageVol)
 
@@ -343,6 +383,9 @@ func (vol StorageVolume) StoragePool() (StoragePool, error) {
 // characteristics from the source stream such as format type, capacity,
 // and allocation.
 func (vol StorageVolume) Upload(str Stream, offset uint64, length uint64) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	vol.log.Printf("setting up to upload %v bytes of data to storage volume in offset %v...\n", length, offset)
 	cRet := C.virStorageVolUpload(vol.virStorageVol, str.virStream, C.ulonglong(offset), C.ulonglong(length), 0)
 	ret := int32(cRet)
@@ -366,6 +409,9 @@ func (vol StorageVolume) Upload(str Stream, offset uint64, length uint64) error
 // successfully transferred, and detect any errors. The results will be
 // unpredictable if another active stream is writing to the storage volume.
 func (vol StorageVolume) Download(str Stream, offset uint64, length uint64) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	vol.log.Printf("setting up to download %v bytes of data from storage volume in offset %v...\n", length, offset)
 	cRet := C.virStorageVolDownload(vol.virStorageVol, str.virStream, C.ulonglong(offset), C.ulonglong(length), 0)
 	ret := int32(cRet)
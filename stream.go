@@ -6,6 +6,7 @@ import "C"
 import (
 	"io"
 	"log"
+	"runtime"
 	"unsafe"
 )
 
@@ -30,6 +31,9 @@ type Stream struct {
 // stream. If a stream needs to be disposed of prior to end of stream being
 // reached, then the Abort function should be called first.
 func (str Stream) Free() error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	str.log.Println("freeing stream object...")
 	cRet := C.virStreamFree(str.virStream)
 	ret := int32(cRet)
@@ -51,6 +55,9 @@ func (str Stream) Free() error {
 // input streams this can be used to inform the driver that it should stop
 // sending data.
 func (str Stream) Abort() error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	str.log.Println("aborting stream...")
 	cRet := C.virStreamAbort(str.virStream)
 	ret := int32(cRet)
@@ -74,6 +81,9 @@ func (str Stream) Abort() error {
 // this returns a success code the application can be sure that all data has
 // been successfully processed.
 func (str Stream) Finish() error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	str.log.Println("finishing stream...")
 	cRet := C.virStreamFinish(str.virStream)
 	ret := int32(cRet)
@@ -94,6 +104,9 @@ func (str Stream) Finish() error {
 // reference count, once the caller no longer needs the reference to this
 // object.
 func (str Stream) Ref() error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	str.log.Println("incrementing stream's reference count...")
 	cRet := C.virStreamRef(str.virStream)
 	ret := int32(cRet)
@@ -119,6 +132,9 @@ func (str Stream) Ref() error {
 // This function is equivalent to the libvirt function "Send" but it has been
 // renamed to "Write" in order to implement the standard interface io.Writer.
 func (str Stream) Write(data []byte) (int, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	cData := C.CString(string(data))
 	defer C.free(unsafe.Pointer(cData))
 
@@ -148,6 +164,9 @@ func (str Stream) Write(data []byte) (int, error) {
 // due to that interface requirement, this function now returns (0, io.EOF)
 // instead of (0, nil) when there's nothing left to be read from the stream.
 func (str Stream) Read(data []byte) (int, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	dataLen := len(data)
 
 	cData := (*C.char)(C.malloc(C.size_t(dataLen)))
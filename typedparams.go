@@ -0,0 +1,146 @@
+package libvirt
+
+/*
+#include <stdlib.h>
+#include <libvirt/libvirt.h>
+*/
+import "C"
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"sort"
+	"unsafe"
+)
+
+// TypedParams holds a set of libvirt typed parameters, indexed by their
+// names. The values may have one of the following types: int32, uint32,
+// int64, uint64, float64, bool or string.
+type TypedParams map[string]interface{}
+
+// newTypedParams converts "cNParams" C typed parameters, starting at
+// "cParams", into TypedParams. The C parameters are not freed.
+func newTypedParams(cParams C.virTypedParameterPtr, cNParams C.int) (TypedParams, error) {
+	var cParamsSlice []C.virTypedParameter
+	paramsSH := (*reflect.SliceHeader)(unsafe.Pointer(&cParamsSlice))
+	paramsSH.Data = uintptr(unsafe.Pointer(cParams))
+	paramsSH.Len = int(cNParams)
+	paramsSH.Cap = int(cNParams)
+
+	params := make(TypedParams, len(cParamsSlice))
+	for _, cParam := range cParamsSlice {
+		name := goStringFromArray(&cParam.field[0], len(cParam.field))
+		value := unsafe.Pointer(&cParam.value)
+
+		switch cParam._type {
+		case C.VIR_TYPED_PARAM_INT:
+			params[name] = int32(*(*C.int)(value))
+		case C.VIR_TYPED_PARAM_UINT:
+			params[name] = uint32(*(*C.uint)(value))
+		case C.VIR_TYPED_PARAM_LLONG:
+			params[name] = int64(*(*C.longlong)(value))
+		case C.VIR_TYPED_PARAM_ULLONG:
+			params[name] = uint64(*(*C.ulonglong)(value))
+		case C.VIR_TYPED_PARAM_DOUBLE:
+			params[name] = float64(*(*C.double)(value))
+		case C.VIR_TYPED_PARAM_BOOLEAN:
+			params[name] = *(*C.char)(value) != 0
+		case C.VIR_TYPED_PARAM_STRING:
+			params[name] = C.GoString(*(**C.char)(value))
+		default:
+			return nil, fmt.Errorf("typed parameter %q has an unknown type: %v", name, cParam._type)
+		}
+	}
+
+	return params, nil
+}
+
+// readTypedParams reads typed parameters from a libvirt getter which must be
+// called twice: first with nil parameters, to query how many there are, then
+// with an array with room for them. "get" makes that call and returns its
+// result.
+func readTypedParams(get func(cParams *C.virTypedParameter, cNParams *C.int) C.int) (TypedParams, error) {
+	var cNParams C.int
+
+	if get(nil, &cNParams) == -1 {
+		return nil, LastError()
+	}
+
+	if cNParams == 0 {
+		return TypedParams{}, nil
+	}
+
+	cParams := make([]C.virTypedParameter, cNParams)
+
+	if get(&cParams[0], &cNParams) == -1 {
+		return nil, LastError()
+	}
+	defer C.virTypedParamsClear(&cParams[0], cNParams)
+
+	return newTypedParams(&cParams[0], cNParams)
+}
+
+// cTypedParams converts the parameters into a C typed parameter array. The
+// returned array must be released with "freeCTypedParams".
+func (params TypedParams) cTypedParams() (C.virTypedParameterPtr, C.int, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	var cParams C.virTypedParameterPtr
+	var cNParams C.int
+	var cMaxParams C.int
+
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		cName := C.CString(name)
+
+		var cRet C.int
+		switch value := params[name].(type) {
+		case int32:
+			cRet = C.virTypedParamsAddInt(&cParams, &cNParams, &cMaxParams, cName, C.int(value))
+		case uint32:
+			cRet = C.virTypedParamsAddUInt(&cParams, &cNParams, &cMaxParams, cName, C.uint(value))
+		case int64:
+			cRet = C.virTypedParamsAddLLong(&cParams, &cNParams, &cMaxParams, cName, C.longlong(value))
+		case uint64:
+			cRet = C.virTypedParamsAddULLong(&cParams, &cNParams, &cMaxParams, cName, C.ulonglong(value))
+		case float64:
+			cRet = C.virTypedParamsAddDouble(&cParams, &cNParams, &cMaxParams, cName, C.double(value))
+		case bool:
+			var cValue C.int
+			if value {
+				cValue = 1
+			}
+			cRet = C.virTypedParamsAddBoolean(&cParams, &cNParams, &cMaxParams, cName, cValue)
+		case string:
+			cValue := C.CString(value)
+			cRet = C.virTypedParamsAddString(&cParams, &cNParams, &cMaxParams, cName, cValue)
+			C.free(unsafe.Pointer(cValue))
+		default:
+			C.free(unsafe.Pointer(cName))
+			freeCTypedParams(cParams, cNParams)
+			return nil, 0, fmt.Errorf("typed parameter %q has an unsupported type: %T", name, value)
+		}
+
+		C.free(unsafe.Pointer(cName))
+
+		if int32(cRet) == -1 {
+			err := LastError()
+			freeCTypedParams(cParams, cNParams)
+			return nil, 0, err
+		}
+	}
+
+	return cParams, cNParams, nil
+}
+
+// freeCTypedParams releases a C typed parameter array allocated by
+// "cTypedParams".
+func freeCTypedParams(cParams C.virTypedParameterPtr, cNParams C.int) {
+	C.virTypedParamsFree(cParams, cNParams)
+}
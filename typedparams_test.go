@@ -0,0 +1,47 @@
+package libvirt
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTypedParamsConversion(t *testing.T) {
+	params := TypedParams{
+		"int":    int32(-1),
+		"uint":   uint32(1),
+		"llong":  int64(-1 << 40),
+		"ullong": uint64(1 << 40),
+		"double": float64(1.5),
+		"bool":   true,
+		"string": "foo",
+	}
+
+	cParams, cNParams, err := params.cTypedParams()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer freeCTypedParams(cParams, cNParams)
+
+	if int(cNParams) != len(params) {
+		t.Errorf("unexpected number of typed parameters; got=%v, want=%v", cNParams, len(params))
+	}
+
+	converted, err := newTypedParams(cParams, cNParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(converted, params) {
+		t.Errorf("unexpected typed parameters after conversion; got=%v, want=%v", converted, params)
+	}
+}
+
+func TestTypedParamsUnsupportedType(t *testing.T) {
+	params := TypedParams{
+		"foo": 1,
+	}
+
+	if _, _, err := params.cTypedParams(); err == nil {
+		t.Error("an error was not returned when converting a parameter with an unsupported type")
+	}
+}
@@ -0,0 +1,47 @@
+package libvirt
+
+// #include <libvirt/libvirt.h>
+import "C"
+import (
+	"fmt"
+	"runtime"
+)
+
+// VersionNumber holds a version number decoded from the libvirt encoding,
+// which is "major * 1,000,000 + minor * 1,000 + release".
+type VersionNumber struct {
+	Major   uint
+	Minor   uint
+	Release uint
+}
+
+// newVersionNumber decodes a version number encoded by libvirt.
+func newVersionNumber(version uint64) VersionNumber {
+	return VersionNumber{
+		Major:   uint(version / 1000000),
+		Minor:   uint(version / 1000 % 1000),
+		Release: uint(version % 1000),
+	}
+}
+
+// String returns the version number in the format "major.minor.release".
+func (ver VersionNumber) String() string {
+	return fmt.Sprintf("%d.%d.%d", ver.Major, ver.Minor, ver.Release)
+}
+
+// Version provides the version of the libvirt client library used by this
+// package.
+func Version() (VersionNumber, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	var cVersion C.ulong
+	cRet := C.virGetVersion(&cVersion, nil, nil)
+	ret := int32(cRet)
+
+	if ret == -1 {
+		return VersionNumber{}, LastError()
+	}
+
+	return newVersionNumber(uint64(cVersion)), nil
+}
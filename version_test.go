@@ -0,0 +1,40 @@
+package libvirt
+
+import (
+	"testing"
+)
+
+func TestVersionNumberDecode(t *testing.T) {
+	versions := []struct {
+		encoded uint64
+		decoded VersionNumber
+		str     string
+	}{
+		{0, VersionNumber{0, 0, 0}, "0.0.0"},
+		{1002003, VersionNumber{1, 2, 3}, "1.2.3"},
+		{6002000, VersionNumber{6, 2, 0}, "6.2.0"},
+		{10010999, VersionNumber{10, 10, 999}, "10.10.999"},
+	}
+
+	for _, v := range versions {
+		ver := newVersionNumber(v.encoded)
+		if ver != v.decoded {
+			t.Errorf("unexpected decoded version of %v; got=%+v, want=%+v", v.encoded, ver, v.decoded)
+		}
+
+		if ver.String() != v.str {
+			t.Errorf("unexpected version string of %v; got=%v, want=%v", v.encoded, ver.String(), v.str)
+		}
+	}
+}
+
+func TestVersion(t *testing.T) {
+	ver, err := Version()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ver.Major == 0 {
+		t.Errorf("the libvirt client library major version should not be zero; got=%v", ver)
+	}
+}